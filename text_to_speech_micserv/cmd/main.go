@@ -8,10 +8,16 @@ import (
 	"os/signal"
 	"syscall"
 	"text_to_speech_app/internal/app_text_to_speech"
+	"text_to_speech_app/internal/audio_cache"
+	"text_to_speech_app/internal/blob_storage"
+	"text_to_speech_app/internal/grpc_server"
 	"text_to_speech_app/internal/kafka/consumer"
 	"text_to_speech_app/internal/kafka/producer"
+	"text_to_speech_app/internal/podcast_feed"
 	"text_to_speech_app/internal/server"
+	"text_to_speech_app/internal/ssml_digest"
 	"time"
+	_ "time/tzdata" // Встроенная база часовых поясов для контейнеров без tzdata
 
 	"github.com/joho/godotenv"
 
@@ -52,14 +58,76 @@ func main() {
 	kafkaConsumer := consumer.NewConsumer([]string{cfg.KafkaPort}, cfg.NameTopicProdus, cfg.KafkaGroupID)
 	slog.Info("Успешно создали Kafka-консьюмер", slog.String("topic", cfg.NameTopicProdus))
 
+	// Создаём дисковый кэш синтезированных аудиосегментов
+	audioCache, err := audio_cache.NewCache(cfg.AudioCacheDir, cfg.AudioCacheMaxBytes, cfg.AudioCacheTTL)
+	if err != nil {
+		slog.Error("Ошибка создания кэша аудиосегментов", slog.Any("error", err))
+		os.Exit(1)
+	}
+	slog.Info("Успешно создали кэш аудиосегментов", slog.String("dir", cfg.AudioCacheDir))
+
+	// Создаём хранилище итоговых аудиофайлов
+	var blobStorage blob_storage.Storage
+	var fileStorage *blob_storage.FileStorage
+	switch cfg.BlobStorage {
+	case "s3":
+		blobStorage, err = blob_storage.NewS3Storage(blob_storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Bucket:    cfg.S3Bucket,
+			Region:    cfg.S3Region,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		}, nil)
+	default:
+		fileStorage, err = blob_storage.NewFileStorage(cfg.BlobDir, cfg.PublicBaseURL)
+		blobStorage = fileStorage
+	}
+	if err != nil {
+		slog.Error("Ошибка создания хранилища аудиофайлов", slog.Any("error", err))
+		os.Exit(1)
+	}
+	slog.Info("Успешно создали хранилище аудиофайлов", slog.String("type", cfg.BlobStorage))
+
+	// Читаем обложку дайджеста, если она задана
+	var coverArt []byte
+	if cfg.CoverFile != "" {
+		coverArt, err = os.ReadFile(cfg.CoverFile)
+		if err != nil {
+			slog.Error("Ошибка чтения файла обложки", slog.Any("error", err))
+			os.Exit(1)
+		}
+		slog.Info("Успешно прочитали файл обложки", slog.String("file", cfg.CoverFile))
+	}
+
+	// Создаём подкаст-ленты, если задан секрет для их токенов
+	var podcastFeed *podcast_feed.Feed
+	if cfg.PodcastSecret != "" {
+		catalog, err := podcast_feed.NewCatalog(cfg.PodcastCatalogFile, cfg.BlobTTL)
+		if err != nil {
+			slog.Error("Ошибка открытия каталога подкастов", slog.Any("error", err))
+			os.Exit(1)
+		}
+		var coverURL string
+		if coverArt != nil {
+			coverURL = cfg.PublicBaseURL + "/podcast/cover"
+		}
+		podcastFeed = podcast_feed.NewFeed(catalog, cfg.PublicBaseURL, cfg.PodcastSecret, coverURL)
+		slog.Info("Успешно создали подкаст-ленты", slog.String("catalog", cfg.PodcastCatalogFile))
+	}
+
 	// Создаём слой бизнес-логики
-	ttsService := app_text_to_speech.NewService(cfg.GoogleCredentialsFile, kafkaProducer)
+	digestBuilder := ssml_digest.NewBuilder(cfg.TimeZone)
+	ttsService := app_text_to_speech.NewService(cfg.GoogleCredentialsFile, kafkaProducer, audioCache, blobStorage, cfg.BlobTTL, digestBuilder, cfg.SSMLEnabled, coverArt, podcastFeed)
 	slog.Info("Успешно создали объект Text-to-Speech сервиса")
 
 	// Создаём HTTP-сервер, внедряя бизнес-логику
-	srv := server.NewServer(cfg.ServerPort, ttsService)
+	srv := server.NewServer(cfg.ServerPort, ttsService, cfg.AdminToken)
 	slog.Info("Успешно создали HTTP-сервер")
 
+	// Создаём gRPC-сервер
+	grpcSrv := grpc_server.NewServer(cfg.GRPCPort, cfg.GRPCTimeout, ttsService)
+	slog.Info("Успешно создали gRPC-сервер")
+
 	// Создаём канал для получения сигналов ОС (для graceful shutdown)
 	quit := make(chan os.Signal, 1)
 
@@ -75,6 +143,22 @@ func main() {
 		}
 	}()
 
+	// Периодически удаляем из файлового хранилища аудиофайлы с истёкшим сроком действия
+	if fileStorage != nil {
+		go func() {
+			ticker := time.NewTicker(time.Hour)
+			defer ticker.Stop()
+			for range ticker.C {
+				removed, err := fileStorage.Cleanup(cfg.BlobTTL)
+				if err != nil {
+					slog.Error("Ошибка очистки хранилища аудиофайлов", slog.Any("error", err))
+					continue
+				}
+				slog.Info("Очистили хранилище аудиофайлов", slog.Int("removed", removed))
+			}
+		}()
+	}
+
 	// Запускаем HTTP-сервер в отдельной горутине
 	go func() {
 		slog.Info("Запуск HTTP-сервера", slog.String("address", cfg.ServerPort))
@@ -84,6 +168,15 @@ func main() {
 		}
 	}()
 
+	// Запускаем gRPC-сервер в отдельной горутине
+	go func() {
+		slog.Info("Запуск gRPC-сервера", slog.String("address", cfg.GRPCPort))
+		if err := grpcSrv.ListenAndServe(); err != nil {
+			slog.Error("Ошибка работы gRPC-сервера", slog.Any("error", err))
+			os.Exit(1)
+		}
+	}()
+
 	// Ожидаем сигнал завершения
 	<-quit
 	slog.Info("Получен сигнал завершения, инициируем graceful shutdown")
@@ -102,6 +195,9 @@ func main() {
 		slog.Error("Ошибка закрытия Kafka-продюсера", slog.Any("error", err))
 	}
 
+	// Останавливаем gRPC-сервер
+	grpcSrv.Shutdown(ctx)
+
 	// Выполняем graceful shutdown сервера
 	if err := srv.Shutdown(ctx); err != nil {
 		slog.Error("Ошибка при завершении работы сервера", slog.Any("error", err))
@@ -9,50 +9,332 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log/slog"
 	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/hajimehoshi/go-mp3"
-	"google.golang.org/api/option"
 	texttospeech "google.golang.org/api/texttospeech/v1"
+	"text_to_speech_app/internal/audio_cache"
+	"text_to_speech_app/internal/audio_tags"
+	"text_to_speech_app/internal/blob_storage"
 	"text_to_speech_app/internal/kafka/producer"
+	"text_to_speech_app/internal/podcast_feed"
+	"text_to_speech_app/internal/ssml_digest"
+	"text_to_speech_app/internal/transcript"
 
 	"text_to_speech_app/internal/model/model_text_to_speech"
 	"text_to_speech_app/tools/logger"
 )
 
+// Параметры синтеза речи по умолчанию
+const (
+	defaultLanguageCode  = "ru-RU"            // Код языка (русский)
+	defaultVoiceName     = "ru-RU-Standard-B" // Имя голоса
+	defaultSsmlGender    = "FEMALE"           // Пол голоса
+	defaultAudioEncoding = "MP3"              // Формат аудио
+)
+
 // Service представляет сервис Text-to-Speech
 type Service struct {
-	credentialsFile string             // Путь к файлу учетных данных Google Cloud
-	KafkaProducer   *producer.Producer // Указатель на Kafka-продюсер для отправки результатов
+	credentialsFile string               // Путь к файлу учетных данных Google Cloud
+	KafkaProducer   *producer.Producer   // Указатель на Kafka-продюсер для отправки результатов
+	audioCache      *audio_cache.Cache   // Кэш синтезированных сегментов (nil — кэш отключён)
+	blobStorage     blob_storage.Storage // Хранилище итоговых аудиофайлов
+	blobTTL         time.Duration        // Срок действия ссылки на аудиофайл
+	digestBuilder   *ssml_digest.Builder // Построитель текста дайджеста (SSML или простой текст)
+	ssmlEnabled     bool                 // Разрешено ли использовать SSML
+	coverArt        []byte               // Обложка дайджеста для тегов аудиофайла (nil — без обложки)
+	podcastFeed     *podcast_feed.Feed   // Подкаст-ленты сгенерированных дайджестов (nil — ленты отключены)
+
+	client      *texttospeech.Service // Клиент Google Text-to-Speech (создаётся лениво)
+	clientMutex sync.Mutex            // Защищает client
+
+	voices         []model_text_to_speech.Voice // Кэш списка голосов движка
+	voicesLoadedAt time.Time                    // Время загрузки списка голосов
+	voicesMutex    sync.Mutex                   // Защищает voices и voicesLoadedAt
 }
 
 // NewService создаёт новый экземпляр сервиса Text-to-Speech
-func NewService(credentialsFile string, kafkaProducer *producer.Producer) *Service {
+func NewService(credentialsFile string, kafkaProducer *producer.Producer, audioCache *audio_cache.Cache, blobStorage blob_storage.Storage, blobTTL time.Duration, digestBuilder *ssml_digest.Builder, ssmlEnabled bool, coverArt []byte, podcastFeed *podcast_feed.Feed) *Service {
 	return &Service{
 		credentialsFile: credentialsFile,
 		KafkaProducer:   kafkaProducer,
+		audioCache:      audioCache,
+		blobStorage:     blobStorage,
+		blobTTL:         blobTTL,
+		digestBuilder:   digestBuilder,
+		ssmlEnabled:     ssmlEnabled,
+		coverArt:        coverArt,
+		podcastFeed:     podcastFeed,
+	}
+}
+
+// BlobStorage возвращает хранилище итоговых аудиофайлов
+func (s *Service) BlobStorage() blob_storage.Storage {
+	return s.blobStorage
+}
+
+// CoverArt возвращает обложку дайджеста (nil, если она не задана)
+func (s *Service) CoverArt() []byte {
+	return s.coverArt
+}
+
+// PodcastFeed возвращает генератор подкаст-лент (nil, если ленты отключены)
+func (s *Service) PodcastFeed() *podcast_feed.Feed {
+	return s.podcastFeed
+}
+
+// MarshalForKafka сериализует ответ для Kafka: аудио передаётся только ссылкой, без самих байтов
+func MarshalForKafka(resp *model_text_to_speech.TextToSpeechResponse) ([]byte, error) {
+	kafkaResp := *resp
+	kafkaResp.AudioData = nil
+	return json.Marshal(&kafkaResp)
+}
+
+// CacheStats возвращает статистику кэша аудиосегментов
+func (s *Service) CacheStats() audio_cache.Stats {
+	if s.audioCache == nil {
+		return audio_cache.Stats{}
+	}
+	return s.audioCache.Stats()
+}
+
+// AudioFormat описывает формат итогового аудиофайла
+type AudioFormat struct {
+	Encoding    string // Кодировка аудио в терминах Google Text-to-Speech
+	ContentType string // MIME-тип аудиофайла
+	Ext         string // Расширение файла
+}
+
+// audioFormats содержит поддерживаемые форматы аудио
+var audioFormats = map[string]AudioFormat{
+	"MP3":      {Encoding: "MP3", ContentType: "audio/mpeg", Ext: ".mp3"},
+	"OGG_OPUS": {Encoding: "OGG_OPUS", ContentType: "audio/ogg", Ext: ".ogg"},
+}
+
+// ResolveAudioFormat возвращает формат по кодировке из запроса (по умолчанию MP3)
+func ResolveAudioFormat(encoding string) (AudioFormat, error) {
+	if encoding == "" {
+		encoding = defaultAudioEncoding
+	}
+	format, ok := audioFormats[strings.ToUpper(encoding)]
+	if !ok {
+		return AudioFormat{}, fmt.Errorf("неподдерживаемая кодировка аудио: %q", encoding)
+	}
+	return format, nil
+}
+
+// ContentTypeByKey возвращает MIME-тип аудиофайла или файла субтитров по расширению ключа объекта
+func ContentTypeByKey(key string) string {
+	for _, format := range audioFormats {
+		if strings.HasSuffix(key, format.Ext) {
+			return format.ContentType
+		}
+	}
+	for _, format := range subtitleFormats {
+		if strings.HasSuffix(key, format.Ext) {
+			return format.ContentType
+		}
 	}
+	return "application/octet-stream"
 }
 
-// Synthesize выполняет синтез речи на основе запроса
+// StreamKey возвращает ключ объекта для результата потокового синтеза.
+// Ключ вычисляется по содержимому запроса, поэтому известен до окончания синтеза.
+func StreamKey(req *model_text_to_speech.TextToSpeechRequest) (string, error) {
+	format, err := ResolveAudioFormat(req.AudioEncoding)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	return "streams/" + blob_storage.Checksum(data) + format.Ext, nil
+}
+
+// Synthesize выполняет синтез речи на основе запроса. Ошибка синтеза, в том числе некорректные параметры,
+// возвращается в поле Error ответа, чтобы запросивший через Kafka получил ответ и при неудаче.
+// Вызывающим, которым нужен тип ошибки, следует использовать SynthesizeDigest.
 func (s *Service) Synthesize(ctx context.Context, req *model_text_to_speech.TextToSpeechRequest) (*model_text_to_speech.TextToSpeechResponse, error) {
-	const lblSynthesize = "text_to_speech_micserv/internal/app_text_to_speech/app_text_to_speech.go → Synthesize()"
-	myLogger := logger.NewColorLogger(lblSynthesize)
+	response, err := s.SynthesizeDigest(ctx, req)
+	if err != nil {
+		return &model_text_to_speech.TextToSpeechResponse{ChatID: req.ChatID, RequestID: req.RequestID, Error: err.Error()}, nil
+	}
+	return response, nil
+}
+
+// SynthesizeDigest выполняет синтез речи на основе запроса и возвращает типизированную ошибку:
+// ErrInvalidRequest для некорректных параметров, ошибки контекста при отмене или истечении дедлайна.
+func (s *Service) SynthesizeDigest(ctx context.Context, req *model_text_to_speech.TextToSpeechRequest) (*model_text_to_speech.TextToSpeechResponse, error) {
+	const lblSynthesizeDigest = "text_to_speech_micserv/internal/app_text_to_speech/app_text_to_speech.go → SynthesizeDigest()"
+	myLogger := logger.NewColorLogger(lblSynthesizeDigest)
+
+	format, err := ResolveAudioFormat(req.AudioEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
 
-	ctx = context.Background()
+	// Синтезируем сегменты в порядке идентификаторов
+	var segments []Segment
+	err = s.SynthesizeSegments(ctx, req, func(seg Segment) error {
+		segments = append(segments, seg)
+		myLogger.Info("Аудио сегмента получено", slog.Int64("id", seg.ID))
+		return nil
+	})
+	if err != nil {
+		myLogger.Error("Ошибка синтеза речи", slog.Any("error", err))
+		return nil, err
+	}
+
+	// Объединяем сегменты в один файл с главами и метаданными
+	combinedAudio, chapters, err := s.assemble(format, req, segments)
+	if err != nil {
+		myLogger.Error("Ошибка объединения аудио", slog.Any("error", err))
+		return nil, err
+	}
+	myLogger.Info("Успешно объединили все аудиофайлы", slog.Int("chapter_count", len(chapters)))
 
-	// Создаём новый клиент Text-to-Speech
-	client, err := texttospeech.NewService(ctx, option.WithCredentialsFile(s.credentialsFile))
+	// Загружаем объединённый файл в хранилище: ключ определяется содержимым файла
+	audioKey := "digests/" + blob_storage.Checksum(combinedAudio) + format.Ext
+	audioRef, err := blob_storage.Upload(ctx, s.blobStorage, audioKey, combinedAudio, format.ContentType, s.blobTTL)
 	if err != nil {
-		myLogger.Error("Не удалось создать клиент Text-to-Speech", slog.Any("error", err))
-		return &model_text_to_speech.TextToSpeechResponse{Error: fmt.Sprintf("Не удалось создать клиент: %v", err)}, nil
+		myLogger.Error("Ошибка загрузки аудио в хранилище", slog.Any("error", err))
+		return nil, fmt.Errorf("Ошибка загрузки аудио в хранилище: %w", err)
+	}
+	myLogger.Info("Успешно загрузили аудио в хранилище", slog.String("key", audioRef.Key), slog.Int64("size", audioRef.Size))
+
+	// Формируем расшифровку по длительностям сегментов и сохраняем её рядом с аудио
+	cues := buildTranscript(segments, chapters)
+	subtitles, err := s.uploadSubtitles(ctx, strings.TrimSuffix(audioKey, format.Ext), cues)
+	if err != nil {
+		myLogger.Error("Ошибка загрузки субтитров в хранилище", slog.Any("error", err))
+		return nil, fmt.Errorf("Ошибка загрузки субтитров в хранилище: %w", err)
+	}
+	myLogger.Info("Успешно сформировали расшифровку", slog.Int("cue_count", len(cues)))
+
+	// Добавляем дайджест в подкаст-ленты пользователя и канала; ошибка каталога не должна ломать синтез
+	if s.podcastFeed != nil {
+		if err := s.podcastFeed.Catalog().Add(newEpisode(req, audioRef, chapters)); err != nil {
+			myLogger.Error("Не удалось добавить выпуск в каталог подкастов", slog.Any("error", err))
+		}
+	}
+
+	// Создаём ответ с объединёнными аудиоданными, ссылкой на них, главами и расшифровкой
+	response := &model_text_to_speech.TextToSpeechResponse{
+		ChatID:    req.ChatID,
+		RequestID: req.RequestID,
+		AudioData: combinedAudio,
+		AudioRef:  audioRef,
+		Chapters:  chapters,
+		Subtitles: subtitles,
+	}
+	for _, cue := range cues {
+		response.Transcript = append(response.Transcript, model_text_to_speech.Cue{
+			StartMs: cue.Start.Milliseconds(),
+			EndMs:   cue.End.Milliseconds(),
+			Text:    cue.Text,
+		})
+	}
+
+	return response, nil
+}
+
+// ErrStreamFormat возвращается при потоковом синтезе в формате, сегменты которого нельзя склеить в один файл:
+// сегменты Ogg Opus — отдельные потоки со своими заголовками, и плееры обрывают воспроизведение на первом
+var ErrStreamFormat = fmt.Errorf("%w: потоковый синтез поддерживается только в формате MP3", ErrInvalidRequest)
+
+// SynthesizeStream синтезирует речь и передаёт аудио в onSegment по мере готовности сегментов.
+// После окончания синтеза отданные байты сохраняются в хранилище под ключом StreamKey без изменений,
+// чтобы по этому ключу скачивался (в том числе по частям, через HTTP Range) тот же файл, что был отдан потоком.
+func (s *Service) SynthesizeStream(ctx context.Context, req *model_text_to_speech.TextToSpeechRequest, onSegment func(seg Segment) error) (*model_text_to_speech.AudioRef, error) {
+	const lblSynthesizeStream = "text_to_speech_micserv/internal/app_text_to_speech/app_text_to_speech.go → SynthesizeStream()"
+	myLogger := logger.NewColorLogger(lblSynthesizeStream)
+
+	format, err := ResolveAudioFormat(req.AudioEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	if format.Encoding != "MP3" {
+		return nil, ErrStreamFormat
+	}
+	key, err := StreamKey(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Кадры MP3 склеиваются без перекодирования, поэтому сохраняем ровно то, что отдали потоком
+	var streamed bytes.Buffer
+	err = s.SynthesizeSegments(ctx, req, func(seg Segment) error {
+		streamed.Write(seg.Audio)
+		return onSegment(seg)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	audioRef, err := blob_storage.Upload(ctx, s.blobStorage, key, streamed.Bytes(), format.ContentType, s.blobTTL)
+	if err != nil {
+		return nil, err
+	}
+	myLogger.Info("Успешно сохранили результат потокового синтеза", slog.String("key", audioRef.Key), slog.Int64("size", audioRef.Size))
+	return audioRef, nil
+}
+
+// Segment описывает озвучиваемый фрагмент дайджеста: вступление или отдельный пост
+type Segment struct {
+	ID      int64  // Идентификатор поста из запроса (0 — вступление)
+	Ordinal int    // Порядковый номер поста, начиная с 1 (0 — вступление)
+	Title   string // Название главы в итоговом аудиофайле
+	Text    string // Исходный текст поста
+	Spoken  string // Озвучиваемый текст без разметки (для расшифровки)
+	Input   string // Текст или SSML-документ, передаваемый движку синтеза
+	SSML    bool   // Признак того, что Input — SSML-документ
+	Audio   []byte // Синтезированное аудио
+}
+
+// buildSegments формирует сегменты дайджеста: посты из req.Posts — в переданном порядке,
+// посты из req.Text — в порядке возрастания идентификаторов.
+// Если в запросе указан канал, первым идёт вступление со сводкой по каналу.
+func (s *Service) buildSegments(req *model_text_to_speech.TextToSpeechRequest, useSSML bool) []Segment {
+	posts := digestPosts(req)
+	segments := make([]Segment, 0, len(posts)+1)
+	if req.ChannelName != "" && len(posts) > 0 {
+		introText := s.digestBuilder.IntroText(req.ChannelName, req.PeriodHours, len(posts))
+		intro := Segment{Title: ssml_digest.IntroTitle, Text: introText, Spoken: introText, Input: introText}
+		if useSSML {
+			intro.Input, intro.SSML = s.digestBuilder.IntroSSML(req.ChannelName, req.PeriodHours, len(posts)), true
+		}
+		segments = append(segments, intro)
+	}
+
+	for i, post := range posts {
+		post.Ordinal = i + 1
+		seg := Segment{ID: post.ID, Ordinal: post.Ordinal, Title: s.digestBuilder.Title(post), Text: post.Text}
+		seg.Spoken = s.digestBuilder.PostText(post)
+		seg.Input = seg.Spoken
+		if useSSML {
+			seg.Input, seg.SSML = s.digestBuilder.PostSSML(post), true
+		}
+		segments = append(segments, seg)
+	}
+	return segments
+}
+
+// digestPosts возвращает посты запроса по порядку озвучивания; идентификатором поста для объявления
+// служит время публикации
+func digestPosts(req *model_text_to_speech.TextToSpeechRequest) []ssml_digest.Post {
+	if len(req.Posts) > 0 {
+		posts := make([]ssml_digest.Post, 0, len(req.Posts))
+		for _, post := range req.Posts {
+			posts = append(posts, ssml_digest.Post{ID: post.Timestamp, Text: post.Text})
+		}
+		return posts
 	}
-	myLogger.Info("Создали клиента Text-to-Speech")
 
-	// Создаём мапу для хранения аудиоданных по идентификатору
-	audioDataMap := make(map[int64][]byte)
 	// Создаём срез для хранения ключей мапы
 	keys := make([]int64, 0, len(req.Text))
 	// Заполняем срез ключами
@@ -61,26 +343,185 @@ func (s *Service) Synthesize(ctx context.Context, req *model_text_to_speech.Text
 	}
 	// Сортируем ключи по возрастанию
 	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
-	// Логируем количество текстов для обработки
-	myLogger.Info("Отсортированы ключи мапы", slog.Int("key_count", len(keys)))
 
-	// Итерируем по отсортированным ключам
+	posts := make([]ssml_digest.Post, 0, len(keys))
 	for _, id := range keys {
-		text := req.Text[id] // Получаем текст для текущего идентификатора
-		myLogger.Info("Синтез речи для текста", slog.Int64("id", id), slog.String("text", text))
-		input := &texttospeech.SynthesisInput{ // Определяем входной текст
-			Text: text,
+		posts = append(posts, ssml_digest.Post{ID: id, Text: req.Text[id]})
+	}
+	return posts
+}
+
+// assemble объединяет синтезированные сегменты в один аудиофайл с главами по постам и метаданными
+// дайджеста: название, канал, дата и обложка
+func (s *Service) assemble(format AudioFormat, req *model_text_to_speech.TextToSpeechRequest, segments []Segment) ([]byte, []model_text_to_speech.Chapter, error) {
+	tracks := make([]audio_tags.Track, len(segments))
+	for i, seg := range segments {
+		tracks[i] = audio_tags.Track{Title: seg.Title, Audio: seg.Audio}
+	}
+
+	now := time.Now().In(s.digestBuilder.Location())
+	meta := audio_tags.Metadata{
+		Title:  s.digestBuilder.DigestTitle(req.ChannelName, now),
+		Artist: strings.TrimPrefix(req.ChannelName, "@"),
+		Date:   now,
+		Cover:  s.coverArt,
+	}
+	audio, tagChapters, err := audio_tags.Build(format.Encoding, tracks, meta)
+	if err != nil {
+		return nil, nil, fmt.Errorf("не удалось записать главы и метаданные: %w", err)
+	}
+
+	chapters := make([]model_text_to_speech.Chapter, len(tagChapters))
+	for i, chapter := range tagChapters {
+		chapters[i] = model_text_to_speech.Chapter{
+			ID:      segments[i].ID,
+			Title:   chapter.Title,
+			StartMs: chapter.Start.Milliseconds(),
+			EndMs:   chapter.End.Milliseconds(),
+		}
+	}
+	return audio, chapters, nil
+}
+
+// subtitleFormats содержит форматы файлов субтитров, сохраняемых рядом с аудио
+var subtitleFormats = []struct {
+	Ext         string                             // Расширение файла
+	ContentType string                             // MIME-тип файла
+	Render      func(cues []transcript.Cue) []byte // Функция формирования файла
+}{
+	{Ext: "." + transcript.FormatSRT, ContentType: "application/x-subrip; charset=utf-8", Render: transcript.SRT},
+	{Ext: "." + transcript.FormatWebVTT, ContentType: "text/vtt; charset=utf-8", Render: transcript.WebVTT},
+}
+
+// newEpisode описывает сгенерированный дайджест как выпуск подкаста
+func newEpisode(req *model_text_to_speech.TextToSpeechRequest, audioRef *model_text_to_speech.AudioRef, chapters []model_text_to_speech.Chapter) podcast_feed.Episode {
+	episode := podcast_feed.Episode{
+		GUID:        podcast_feed.GUID(req.ChatID, req.ChannelName, audioRef.Key),
+		ChatID:      req.ChatID,
+		Channel:     req.ChannelName,
+		AudioKey:    audioRef.Key,
+		ContentType: audioRef.ContentType,
+		Size:        audioRef.Size,
+		PublishedAt: time.Now(),
+	}
+	titles := make([]string, 0, len(chapters))
+	for _, chapter := range chapters {
+		titles = append(titles, chapter.Title)
+		episode.Duration = time.Duration(chapter.EndMs) * time.Millisecond
+	}
+	episode.Description = strings.Join(titles, "\n")
+
+	episode.Title = "Сводка от " + episode.PublishedAt.Format("02.01.2006 15:04")
+	if req.ChannelName != "" {
+		episode.Title = "@" + podcast_feed.NormalizeChannel(req.ChannelName) + ": " + episode.Title
+	}
+	return episode
+}
+
+// buildTranscript формирует расшифровку: время каждого сегмента берётся из глав итогового файла
+func buildTranscript(segments []Segment, chapters []model_text_to_speech.Chapter) []transcript.Cue {
+	parts := make([]transcript.Segment, 0, len(segments))
+	for i, seg := range segments {
+		if i >= len(chapters) {
+			break
+		}
+		parts = append(parts, transcript.Segment{
+			Text:  seg.Spoken,
+			Start: time.Duration(chapters[i].StartMs) * time.Millisecond,
+			End:   time.Duration(chapters[i].EndMs) * time.Millisecond,
+		})
+	}
+	return transcript.Build(parts)
+}
+
+// uploadSubtitles сохраняет расшифровку в форматах SRT и WebVTT под ключами baseKey.srt и baseKey.vtt
+func (s *Service) uploadSubtitles(ctx context.Context, baseKey string, cues []transcript.Cue) ([]*model_text_to_speech.AudioRef, error) {
+	if len(cues) == 0 {
+		return nil, nil
+	}
+	refs := make([]*model_text_to_speech.AudioRef, 0, len(subtitleFormats))
+	for _, format := range subtitleFormats {
+		ref, err := blob_storage.Upload(ctx, s.blobStorage, baseKey+format.Ext, format.Render(cues), format.ContentType, s.blobTTL)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// useSSML определяет, озвучивать ли дайджест через SSML: это должно быть разрешено конфигурацией
+// и запросом, а выбранный голос должен поддерживать SSML
+func (s *Service) useSSML(req *model_text_to_speech.TextToSpeechRequest) bool {
+	return s.ssmlEnabled && !req.PlainText && voiceSupportsSSML(req.VoiceName)
+}
+
+// SynthesizeSegments синтезирует сегменты запроса в порядке возрастания идентификаторов
+// и передаёт каждый готовый сегмент в onSegment. Сегменты сначала ищутся в кэше.
+func (s *Service) SynthesizeSegments(ctx context.Context, req *model_text_to_speech.TextToSpeechRequest, onSegment func(seg Segment) error) error {
+	const lblSynthesizeSegments = "text_to_speech_micserv/internal/app_text_to_speech/app_text_to_speech.go → SynthesizeSegments()"
+	myLogger := logger.NewColorLogger(lblSynthesizeSegments)
+
+	format, err := ResolveAudioFormat(req.AudioEncoding)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+
+	// Заполняем параметры голоса по умолчанию и проверяем их по списку голосов движка
+	if err := s.PrepareVoice(ctx, req); err != nil {
+		myLogger.Error("Некорректные параметры голоса", slog.Any("error", err))
+		return err
+	}
+	voiceID := voiceCacheID(req)
+
+	useSSML := s.useSSML(req)
+	segments := s.buildSegments(req, useSSML)
+	myLogger.Info("Сформированы сегменты дайджеста", slog.Int("segment_count", len(segments)), slog.Bool("ssml", useSSML))
+
+	// Итерируем по сегментам в порядке воспроизведения
+	for _, seg := range segments {
+		myLogger.Info("Синтез речи для сегмента", slog.Int64("id", seg.ID), slog.String("input", seg.Input))
+
+		// Ищем сегмент в кэше, прежде чем обращаться к движку синтеза
+		cacheKey := audio_cache.Key(seg.Input, voiceID, req.SpeakingRate, format.Encoding)
+		if s.audioCache != nil {
+			if audioData, ok := s.audioCache.Get(cacheKey); ok {
+				myLogger.Info("Сегмент взят из кэша", slog.Int64("id", seg.ID))
+				seg.Audio = audioData
+				if err := onSegment(seg); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		// Получаем клиент Text-to-Speech
+		client, err := s.ttsClient(ctx)
+		if err != nil {
+			myLogger.Error("Не удалось создать клиент Text-to-Speech", slog.Any("error", err))
+			return err
+		}
+
+		// Определяем входной текст: SSML-документ или простой текст
+		input := &texttospeech.SynthesisInput{}
+		if seg.SSML {
+			input.Ssml = seg.Input
+		} else {
+			input.Text = seg.Input
 		}
 		// Настраиваем параметры голоса
 		voice := &texttospeech.VoiceSelectionParams{
-			LanguageCode: "ru-RU",            // Код языка (русский)
-			Name:         "ru-RU-Standard-B", // Имя голоса
-			SsmlGender:   "FEMALE",           // Пол голоса
+			LanguageCode: req.LanguageCode, // Код языка
+			Name:         req.VoiceName,    // Имя голоса
+			SsmlGender:   req.SsmlGender,   // Пол голоса
 		}
 		// Настраиваем параметры аудио
 		audioConfig := &texttospeech.AudioConfig{
-			AudioEncoding: "MP3",            // Формат аудио
-			SpeakingRate:  req.SpeakingRate, // Скорость речи
+			AudioEncoding:    format.Encoding,    // Формат аудио
+			SpeakingRate:     req.SpeakingRate,   // Скорость речи
+			Pitch:            req.Pitch,          // Высота голоса
+			VolumeGainDb:     req.VolumeGainDb,   // Усиление громкости
+			EffectsProfileId: req.EffectsProfile, // Профили аудиоэффектов
 		}
 		// Создаём запрос для синтеза речи
 		ttsReq := &texttospeech.SynthesizeSpeechRequest{
@@ -90,67 +531,46 @@ func (s *Service) Synthesize(ctx context.Context, req *model_text_to_speech.Text
 		}
 
 		// Выполняем запрос синтеза речи
-		resp, err := client.Text.Synthesize(ttsReq).Do()
+		resp, err := client.Text.Synthesize(ttsReq).Context(ctx).Do()
 		if err != nil {
-			myLogger.Error("Не удалось синтезировать речь", slog.Int64("id", id), slog.Any("error", err))
-			return &model_text_to_speech.TextToSpeechResponse{Error: fmt.Sprintf("Не удалось синтезировать речь для id %d: %v", id, err)}, nil
+			myLogger.Error("Не удалось синтезировать речь", slog.Int64("id", seg.ID), slog.Any("error", err))
+			return fmt.Errorf("Не удалось синтезировать речь для id %d: %w", seg.ID, err)
 		}
-		myLogger.Info("Успешно синтэзировали речь", slog.Int64("id", id))
+		myLogger.Info("Успешно синтэзировали речь", slog.Int64("id", seg.ID))
 
 		// Декодируем base64-строку в байты
 		audioData, err := base64.StdEncoding.DecodeString(resp.AudioContent)
 		if err != nil {
-			myLogger.Error("Не удалось декодировать аудио base64", slog.Int64("id", id), slog.Any("error", err))
-			return &model_text_to_speech.TextToSpeechResponse{Error: fmt.Sprintf("Не удалось декодировать аудио для id %d: %v", id, err)}, nil
+			myLogger.Error("Не удалось декодировать аудио base64", slog.Int64("id", seg.ID), slog.Any("error", err))
+			return fmt.Errorf("Не удалось декодировать аудио для id %d: %w", seg.ID, err)
 		}
-		myLogger.Info("Успешно декодировали аудио base64 в []byte", slog.Int64("id", id))
-
-		// Сохраняем аудиоданные в мапу
-		audioDataMap[id] = audioData
-	}
-
-	// Создаём буфер для объединённого аудиофайла, 	Итерируем по отсортированным ключам для объединения аудио
-	var combinedAudio bytes.Buffer
-	for _, id := range keys {
-		audioData := audioDataMap[id]
+		myLogger.Info("Успешно декодировали аудио base64 в []byte", slog.Int64("id", seg.ID))
 
 		// Проверяем корректность MP3, создавая декодер
-		_, err := mp3.NewDecoder(bytes.NewReader(audioData))
-		if err != nil {
-			myLogger.Error("Не удалось создать MP3 декодер", slog.Int64("id", id), slog.Any("error", err))
-			return &model_text_to_speech.TextToSpeechResponse{Error: fmt.Sprintf("Некорректный MP3 для id %d: %v", id, err)}, nil
+		if format.Encoding == "MP3" {
+			if _, err := mp3.NewDecoder(bytes.NewReader(audioData)); err != nil {
+				myLogger.Error("Не удалось создать MP3 декодер", slog.Int64("id", seg.ID), slog.Any("error", err))
+				return fmt.Errorf("Некорректный MP3 для id %d: %w", seg.ID, err)
+			}
 		}
 
-		// Копируем аудиоданные в буфер
-		_, err = io.Copy(&combinedAudio, bytes.NewReader(audioData))
-		if err != nil {
-			myLogger.Error("Ошибка объединения аудио", slog.Int64("id", id), slog.Any("error", err))
-			return &model_text_to_speech.TextToSpeechResponse{Error: fmt.Sprintf("Ошибка объединения аудио для id %d: %v", id, err)}, nil
+		// Сохраняем сегмент в кэш; ошибка кэша не должна ломать синтез
+		if s.audioCache != nil {
+			if err := s.audioCache.Put(cacheKey, audioData); err != nil {
+				myLogger.Error("Не удалось сохранить сегмент в кэш", slog.Int64("id", seg.ID), slog.Any("error", err))
+			}
 		}
-		myLogger.Info("Аудио добавлено в объединённый буфер", slog.Int64("id", id))
-	}
-	myLogger.Info("Успешно объединили все аудиофайлы")
 
-	// Создаём ответ с объединёнными аудиоданными
-	response := &model_text_to_speech.TextToSpeechResponse{
-		AudioData: combinedAudio.Bytes(),
-	}
-
-	// Сериализуем ответ для отправки в Kafka
-	respData, err := json.Marshal(response)
-	if err != nil {
-		myLogger.Error("Ошибка сериализации ответа для Kafka", slog.Any("error", err))
-		return &model_text_to_speech.TextToSpeechResponse{Error: fmt.Sprintf("Ошибка сериализации ответа: %v", err)}, nil
+		seg.Audio = audioData
+		if err := onSegment(seg); err != nil {
+			return err
+		}
 	}
-	myLogger.Info("Успешно сериализовали ответ для Kafka")
 
-	// Отправляем результат в Kafka
-	err = s.KafkaProducer.SendMessage("text-to-speech-responses", respData)
-	if err != nil {
-		myLogger.Error("Ошибка отправки в Kafka", slog.Any("error", err))
-		return &model_text_to_speech.TextToSpeechResponse{Error: fmt.Sprintf("Ошибка отправки в Kafka: %v", err)}, nil
-	}
-	myLogger.Info("Успешно отправили результат в Kafka", slog.String("topic", "text-to-speech-responses"))
+	// Логируем статистику кэша
+	stats := s.CacheStats()
+	myLogger.Info("Статистика кэша аудиосегментов", slog.Int64("hits", stats.Hits), slog.Int64("misses", stats.Misses),
+		slog.Int("entries", stats.Entries), slog.Int64("size_bytes", stats.SizeBytes))
 
-	return response, nil
+	return nil
 }
@@ -1 +1,128 @@
 package app_text_to_speech
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"text_to_speech_app/internal/model/model_text_to_speech"
+)
+
+var testVoices = []model_text_to_speech.Voice{
+	{Name: "ru-RU-Standard-B", LanguageCodes: []string{"ru-RU"}, SsmlGender: "MALE"},
+	{Name: "ru-RU-Wavenet-A", LanguageCodes: []string{"ru-RU"}, SsmlGender: "FEMALE"},
+	{Name: "en-US-Standard-C", LanguageCodes: []string{"en-US"}, SsmlGender: "FEMALE"},
+}
+
+func TestVoiceDefaultsAndValidation(t *testing.T) {
+	req := &model_text_to_speech.TextToSpeechRequest{}
+	if err := normalizeVoice(req); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateVoice(req, testVoices); err != nil {
+		t.Fatal(err)
+	}
+	if req.VoiceName != defaultVoiceName || req.LanguageCode != defaultLanguageCode || req.SpeakingRate != 1.0 {
+		t.Fatalf("не заполнены значения по умолчанию: %+v", req)
+	}
+
+	// Язык подставляется из описания голоса, пол берётся у голоса
+	req = &model_text_to_speech.TextToSpeechRequest{VoiceName: "ru-RU-Wavenet-A", SsmlGender: "male"}
+	normalizeVoice(req)
+	if err := validateVoice(req, testVoices); err != nil {
+		t.Fatal(err)
+	}
+	if req.LanguageCode != "ru-RU" || req.SsmlGender != "FEMALE" {
+		t.Fatalf("неожиданные параметры голоса: %+v", req)
+	}
+}
+
+func TestVoiceValidationErrors(t *testing.T) {
+	cases := []model_text_to_speech.TextToSpeechRequest{
+		{VoiceName: "ru-RU-Unknown"},
+		{VoiceName: "en-US-Standard-C", LanguageCode: "ru-RU"},
+		{LanguageCode: "xx-XX"},
+		{Pitch: 25},
+		{VolumeGainDb: 20},
+		{SpeakingRate: 5},
+		{EffectsProfile: []string{"spaceship-class-device"}},
+	}
+	for _, req := range cases {
+		err := normalizeVoice(&req)
+		if err == nil {
+			err = validateVoice(&req, testVoices)
+		}
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("для %+v ожидали ErrInvalidRequest, получили %v", req, err)
+		}
+	}
+}
+
+func TestDigestPosts(t *testing.T) {
+	// Посты разных каналов, опубликованные в одну секунду, озвучиваются все и в переданном порядке
+	req := &model_text_to_speech.TextToSpeechRequest{
+		Text: map[int64]string{1: "не используется"},
+		Posts: []model_text_to_speech.Post{
+			{Channel: "@b", ID: 7, Timestamp: 1700000100, Text: "второй канал"},
+			{Channel: "@a", ID: 3, Timestamp: 1700000100, Text: "первый канал"},
+			{Channel: "@a", ID: 2, Timestamp: 1700000000, Text: "раньше"},
+		},
+	}
+	posts := digestPosts(req)
+	if len(posts) != 3 || posts[0].Text != "второй канал" || posts[1].Text != "первый канал" || posts[2].ID != 1700000000 {
+		t.Fatalf("неожиданные посты: %+v", posts)
+	}
+
+	// Без списка постов используется карта текстов по возрастанию ключей
+	req = &model_text_to_speech.TextToSpeechRequest{Text: map[int64]string{20: "б", 10: "а"}}
+	posts = digestPosts(req)
+	if len(posts) != 2 || posts[0].Text != "а" || posts[1].Text != "б" {
+		t.Fatalf("неожиданные посты из карты: %+v", posts)
+	}
+}
+
+func TestSynthesizeEchoesRequestIDs(t *testing.T) {
+	// Дайджест в том виде, в каком его публикует tg_app по запросу бота
+	data := []byte(`{"request_id":"req-1","chat_id":42,"channel_name":"@news","period_hours":24,"speaking_rate":1.5,"voice_name":"ru-RU-Wavenet-A","audio_encoding":"WAV","posts":[{"channel":"@news","id":5,"timestamp":1700000000,"text":"текст"}]}`)
+	var req model_text_to_speech.TextToSpeechRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatalf("не удалось разобрать дайджест: %v", err)
+	}
+	if len(req.Posts) != 1 || req.VoiceName != "ru-RU-Wavenet-A" {
+		t.Fatalf("неожиданный запрос: %+v", req)
+	}
+
+	// Даже при ошибке синтеза ответ в Kafka несёт чат и идентификатор запроса, чтобы бот доставил результат
+	resp, err := (&Service{}).Synthesize(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("неожиданная ошибка: %v", err)
+	}
+	out, err := MarshalForKafka(resp)
+	if err != nil {
+		t.Fatalf("не удалось сериализовать ответ: %v", err)
+	}
+	var got struct {
+		ChatID    int64  `json:"chat_id"`
+		RequestID string `json:"request_id"`
+		Error     string `json:"error"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("не удалось разобрать ответ: %v", err)
+	}
+	if got.ChatID != 42 || got.RequestID != "req-1" || got.Error == "" {
+		t.Fatalf("неожиданный ответ: %s", out)
+	}
+}
+
+func TestSynthesizeStreamRejectsOpus(t *testing.T) {
+	// Сегменты Ogg Opus нельзя склеить в один поток, поэтому потоковый синтез в этом формате отклоняется до синтеза
+	req := &model_text_to_speech.TextToSpeechRequest{AudioEncoding: "OGG_OPUS", Text: map[int64]string{1: "текст"}}
+	_, err := (&Service{}).SynthesizeStream(context.Background(), req, func(Segment) error {
+		t.Fatal("сегмент не должен синтезироваться")
+		return nil
+	})
+	if !errors.Is(err, ErrStreamFormat) || !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("ожидали ErrStreamFormat, получили %v", err)
+	}
+}
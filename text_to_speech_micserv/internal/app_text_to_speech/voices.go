@@ -0,0 +1,210 @@
+// Файл voices.go отвечает за выбор голоса: получение списка голосов движка синтеза,
+// заполнение параметров голоса по умолчанию и проверку параметров запроса.
+
+package app_text_to_speech
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/option"
+	texttospeech "google.golang.org/api/texttospeech/v1"
+
+	"text_to_speech_app/internal/model/model_text_to_speech"
+	"text_to_speech_app/tools/logger"
+)
+
+// ErrInvalidRequest возвращается, если параметры запроса на синтез некорректны
+var ErrInvalidRequest = errors.New("некорректные параметры синтеза")
+
+// voicesCacheTTL — время, в течение которого список голосов движка считается актуальным
+const voicesCacheTTL = time.Hour
+
+// Допустимые диапазоны параметров синтеза (ограничения Google Text-to-Speech)
+const (
+	minSpeakingRate  = 0.25
+	maxSpeakingRate  = 4.0
+	minPitch         = -20.0
+	maxPitch         = 20.0
+	minVolumeGainDb  = -96.0
+	maxVolumeGainDb  = 16.0
+	defaultSpeakRate = 1.0
+)
+
+// ssmlGenders содержит допустимые значения пола голоса
+var ssmlGenders = []string{"MALE", "FEMALE", "NEUTRAL", "SSML_VOICE_GENDER_UNSPECIFIED"}
+
+// effectsProfiles содержит профили аудиоэффектов, поддерживаемые Google Text-to-Speech
+var effectsProfiles = []string{
+	"wearable-class-device",
+	"handset-class-device",
+	"headphone-class-device",
+	"small-bluetooth-speaker-class-device",
+	"medium-bluetooth-speaker-class-device",
+	"large-home-entertainment-class-device",
+	"large-automotive-class-device",
+	"telephony-class-application",
+}
+
+// ttsClient возвращает клиент Google Text-to-Speech, создавая его при первом обращении
+func (s *Service) ttsClient(ctx context.Context) (*texttospeech.Service, error) {
+	s.clientMutex.Lock()
+	defer s.clientMutex.Unlock()
+
+	if s.client != nil {
+		return s.client, nil
+	}
+	client, err := texttospeech.NewService(ctx, option.WithCredentialsFile(s.credentialsFile))
+	if err != nil {
+		return nil, fmt.Errorf("Не удалось создать клиент: %w", err)
+	}
+	s.client = client
+	return client, nil
+}
+
+// ListVoices возвращает голоса движка синтеза, при необходимости отфильтрованные по коду языка.
+// Список кэшируется в памяти на voicesCacheTTL.
+func (s *Service) ListVoices(ctx context.Context, languageCode string) ([]model_text_to_speech.Voice, error) {
+	const lblListVoices = "text_to_speech_micserv/internal/app_text_to_speech/voices.go → ListVoices()"
+	myLogger := logger.NewColorLogger(lblListVoices)
+
+	s.voicesMutex.Lock()
+	defer s.voicesMutex.Unlock()
+
+	if s.voices == nil || time.Since(s.voicesLoadedAt) > voicesCacheTTL {
+		client, err := s.ttsClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Voices.List().Context(ctx).Do()
+		if err != nil {
+			myLogger.Error("Не удалось получить список голосов", slog.Any("error", err))
+			return nil, fmt.Errorf("не удалось получить список голосов: %w", err)
+		}
+
+		voices := make([]model_text_to_speech.Voice, 0, len(resp.Voices))
+		for _, v := range resp.Voices {
+			voices = append(voices, model_text_to_speech.Voice{
+				Name:                   v.Name,
+				LanguageCodes:          v.LanguageCodes,
+				SsmlGender:             v.SsmlGender,
+				NaturalSampleRateHertz: v.NaturalSampleRateHertz,
+			})
+		}
+		s.voices = voices
+		s.voicesLoadedAt = time.Now()
+		myLogger.Info("Обновили список голосов", slog.Int("count", len(voices)))
+	}
+
+	if languageCode == "" {
+		return s.voices, nil
+	}
+	var filtered []model_text_to_speech.Voice
+	for _, v := range s.voices {
+		if slices.ContainsFunc(v.LanguageCodes, func(lc string) bool { return strings.EqualFold(lc, languageCode) }) {
+			filtered = append(filtered, v)
+		}
+	}
+	return filtered, nil
+}
+
+// PrepareVoice заполняет параметры голоса по умолчанию и проверяет их по списку голосов движка
+func (s *Service) PrepareVoice(ctx context.Context, req *model_text_to_speech.TextToSpeechRequest) error {
+	if err := normalizeVoice(req); err != nil {
+		return err
+	}
+
+	voices, err := s.ListVoices(ctx, "")
+	if err != nil {
+		return err
+	}
+	return validateVoice(req, voices)
+}
+
+// normalizeVoice заполняет незаданные параметры значениями по умолчанию и проверяет диапазоны
+func normalizeVoice(req *model_text_to_speech.TextToSpeechRequest) error {
+	if req.VoiceName == "" && req.LanguageCode == "" {
+		req.VoiceName = defaultVoiceName
+		req.LanguageCode = defaultLanguageCode
+		if req.SsmlGender == "" {
+			req.SsmlGender = defaultSsmlGender
+		}
+	}
+	if req.SpeakingRate == 0 {
+		req.SpeakingRate = defaultSpeakRate
+	}
+	req.SsmlGender = strings.ToUpper(req.SsmlGender)
+
+	if req.SpeakingRate < minSpeakingRate || req.SpeakingRate > maxSpeakingRate {
+		return fmt.Errorf("%w: скорость речи %.2f вне диапазона [%.2f, %.1f]", ErrInvalidRequest, req.SpeakingRate, minSpeakingRate, maxSpeakingRate)
+	}
+	if req.Pitch < minPitch || req.Pitch > maxPitch {
+		return fmt.Errorf("%w: высота голоса %.1f вне диапазона [%.0f, %.0f]", ErrInvalidRequest, req.Pitch, minPitch, maxPitch)
+	}
+	if req.VolumeGainDb < minVolumeGainDb || req.VolumeGainDb > maxVolumeGainDb {
+		return fmt.Errorf("%w: усиление громкости %.1f дБ вне диапазона [%.0f, %.0f]", ErrInvalidRequest, req.VolumeGainDb, minVolumeGainDb, maxVolumeGainDb)
+	}
+	if req.SsmlGender != "" && !slices.Contains(ssmlGenders, req.SsmlGender) {
+		return fmt.Errorf("%w: неизвестный пол голоса %q", ErrInvalidRequest, req.SsmlGender)
+	}
+	for _, profile := range req.EffectsProfile {
+		if !slices.Contains(effectsProfiles, profile) {
+			return fmt.Errorf("%w: неизвестный профиль аудиоэффектов %q", ErrInvalidRequest, profile)
+		}
+	}
+	return nil
+}
+
+// validateVoice проверяет, что выбранный голос существует и поддерживает указанный язык
+func validateVoice(req *model_text_to_speech.TextToSpeechRequest, voices []model_text_to_speech.Voice) error {
+	if req.VoiceName == "" {
+		// Голос подберёт движок по языку и полу — проверяем, что язык вообще поддерживается
+		for _, v := range voices {
+			if slices.ContainsFunc(v.LanguageCodes, func(lc string) bool { return strings.EqualFold(lc, req.LanguageCode) }) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: нет голосов для языка %q", ErrInvalidRequest, req.LanguageCode)
+	}
+
+	idx := slices.IndexFunc(voices, func(v model_text_to_speech.Voice) bool { return v.Name == req.VoiceName })
+	if idx < 0 {
+		return fmt.Errorf("%w: неизвестный голос %q", ErrInvalidRequest, req.VoiceName)
+	}
+	voice := voices[idx]
+
+	if req.LanguageCode == "" {
+		if len(voice.LanguageCodes) > 0 {
+			req.LanguageCode = voice.LanguageCodes[0]
+		}
+	} else if !slices.ContainsFunc(voice.LanguageCodes, func(lc string) bool { return strings.EqualFold(lc, req.LanguageCode) }) {
+		return fmt.Errorf("%w: голос %q не поддерживает язык %q", ErrInvalidRequest, req.VoiceName, req.LanguageCode)
+	}
+
+	// Пол конкретного голоса фиксирован, поэтому берём его из описания голоса
+	req.SsmlGender = voice.SsmlGender
+	return nil
+}
+
+// voiceSupportsSSML сообщает, поддерживает ли голос SSML: голоса семейств Journey и Chirp его не поддерживают
+func voiceSupportsSSML(voiceName string) bool {
+	return !strings.Contains(voiceName, "-Journey-") && !strings.Contains(voiceName, "-Chirp")
+}
+
+// voiceCacheID возвращает строку с параметрами голоса для ключа кэша аудиосегментов
+func voiceCacheID(req *model_text_to_speech.TextToSpeechRequest) string {
+	return strings.Join([]string{
+		req.VoiceName,
+		req.LanguageCode,
+		req.SsmlGender,
+		strconv.FormatFloat(req.Pitch, 'f', -1, 64),
+		strconv.FormatFloat(req.VolumeGainDb, 'f', -1, 64),
+		strings.Join(req.EffectsProfile, ","),
+	}, "|")
+}
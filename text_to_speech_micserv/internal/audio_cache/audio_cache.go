@@ -0,0 +1,264 @@
+// Файл audio_cache.go реализует контентно-адресуемый кэш синтезированных аудиосегментов.
+// Ключ кэша — хэш от нормализованного текста и параметров синтеза (голос, скорость, кодировка).
+// Данные хранятся на диске, размер кэша ограничен (вытеснение по LRU), записи устаревают по TTL.
+
+package audio_cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// fileExt — расширение файлов кэша на диске
+const fileExt = ".audio"
+
+// Cache представляет дисковый кэш аудиосегментов с LRU-вытеснением и TTL
+type Cache struct {
+	dir      string                   // Директория для хранения файлов кэша
+	maxBytes int64                    // Максимальный суммарный размер кэша в байтах (0 — без ограничения)
+	ttl      time.Duration            // Время жизни записи (0 — без ограничения)
+	mutex    sync.Mutex               // Защищает entries, lru и size
+	entries  map[string]*list.Element // Индекс записей по ключу
+	lru      *list.List               // Список записей: в начале — недавно использованные
+	size     int64                    // Текущий суммарный размер кэша в байтах
+	hits     atomic.Int64             // Счётчик попаданий в кэш
+	misses   atomic.Int64             // Счётчик промахов кэша
+}
+
+// entry описывает одну запись кэша
+type entry struct {
+	key       string    // Ключ записи (он же имя файла без расширения)
+	size      int64     // Размер аудиоданных в байтах
+	createdAt time.Time // Время создания записи (для TTL)
+}
+
+// Stats содержит статистику работы кэша
+type Stats struct {
+	Hits      int64 `json:"hits"`       // Количество попаданий
+	Misses    int64 `json:"misses"`     // Количество промахов
+	Entries   int   `json:"entries"`    // Количество записей в кэше
+	SizeBytes int64 `json:"size_bytes"` // Суммарный размер записей в байтах
+}
+
+// NewCache создаёт кэш в указанной директории и загружает уже сохранённые на диске записи
+func NewCache(dir string, maxBytes int64, ttl time.Duration) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("не удалось создать директорию кэша %s: %w", dir, err)
+	}
+
+	c := &Cache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Key вычисляет ключ кэша по нормализованному тексту и параметрам синтеза
+func Key(text, voice string, rate float64, encoding string) string {
+	h := sha256.New()
+	// Разделяем поля нулевым байтом, чтобы исключить коллизии при конкатенации
+	h.Write([]byte(NormalizeText(text)))
+	h.Write([]byte{0})
+	h.Write([]byte(voice))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.FormatFloat(rate, 'f', -1, 64)))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.ToUpper(encoding)))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NormalizeText приводит текст к каноническому виду: схлопывает пробельные символы и обрезает края
+func NormalizeText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// Get возвращает аудиоданные по ключу, если запись есть в кэше и не устарела
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mutex.Lock()
+	elem, ok := c.entries[key]
+	if !ok {
+		c.mutex.Unlock()
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	e := elem.Value.(*entry)
+	// Удаляем устаревшую запись
+	if c.expired(e) {
+		c.removeElement(elem)
+		c.mutex.Unlock()
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	c.mutex.Unlock()
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		// Файл пропал или повреждён — забываем о записи
+		c.mutex.Lock()
+		if elem, ok := c.entries[key]; ok {
+			c.removeElement(elem)
+		}
+		c.mutex.Unlock()
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	c.hits.Add(1)
+	return data, true
+}
+
+// Put сохраняет аудиоданные в кэш и при необходимости вытесняет старые записи
+func (c *Cache) Put(key string, data []byte) error {
+	// Записываем во временный файл и атомарно переименовываем, чтобы не оставить обрезанный файл
+	tmp, err := os.CreateTemp(c.dir, key+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("не удалось создать временный файл кэша: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("не удалось записать файл кэша: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("не удалось закрыть файл кэша: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("не удалось сохранить файл кэша: %w", err)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Если запись уже была — заменяем её
+	if elem, ok := c.entries[key]; ok {
+		c.size -= elem.Value.(*entry).size
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+	}
+
+	c.entries[key] = c.lru.PushFront(&entry{
+		key:       key,
+		size:      int64(len(data)),
+		createdAt: time.Now(),
+	})
+	c.size += int64(len(data))
+	c.evict()
+
+	return nil
+}
+
+// Stats возвращает текущую статистику кэша
+func (c *Cache) Stats() Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return Stats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Entries:   len(c.entries),
+		SizeBytes: c.size,
+	}
+}
+
+// load восстанавливает индекс кэша по файлам в директории
+func (c *Cache) load() error {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать директорию кэша %s: %w", c.dir, err)
+	}
+
+	var loaded []*entry
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), fileExt) {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		loaded = append(loaded, &entry{
+			key:       strings.TrimSuffix(f.Name(), fileExt),
+			size:      info.Size(),
+			createdAt: info.ModTime(),
+		})
+	}
+
+	// Более свежие файлы считаем недавно использованными
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].createdAt.After(loaded[j].createdAt) })
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, e := range loaded {
+		c.entries[e.key] = c.lru.PushBack(e)
+		c.size += e.size
+	}
+	c.evict()
+
+	return nil
+}
+
+// evict удаляет устаревшие записи и вытесняет давно не использованные, пока кэш превышает лимит.
+// Вызывается под мьютексом.
+func (c *Cache) evict() {
+	if c.ttl > 0 {
+		for elem := c.lru.Back(); elem != nil; {
+			prev := elem.Prev()
+			if c.expired(elem.Value.(*entry)) {
+				c.removeElement(elem)
+			}
+			elem = prev
+		}
+	}
+
+	if c.maxBytes <= 0 {
+		return
+	}
+	for c.size > c.maxBytes {
+		elem := c.lru.Back()
+		if elem == nil {
+			return
+		}
+		c.removeElement(elem)
+	}
+}
+
+// removeElement удаляет запись из индекса и файл с диска. Вызывается под мьютексом.
+func (c *Cache) removeElement(elem *list.Element) {
+	e := elem.Value.(*entry)
+	c.lru.Remove(elem)
+	delete(c.entries, e.key)
+	c.size -= e.size
+	os.Remove(c.path(e.key))
+}
+
+// expired проверяет, истёк ли TTL записи
+func (c *Cache) expired(e *entry) bool {
+	return c.ttl > 0 && time.Since(e.createdAt) > c.ttl
+}
+
+// path возвращает путь к файлу записи на диске
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+fileExt)
+}
@@ -0,0 +1,69 @@
+package audio_cache
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestKeyNormalizesWhitespace(t *testing.T) {
+	a := Key("  Привет,\n  мир ", "ru-RU-Standard-B", 1.0, "mp3")
+	b := Key("Привет, мир", "ru-RU-Standard-B", 1.0, "MP3")
+	if a != b {
+		t.Fatalf("ключи для одинакового нормализованного текста различаются")
+	}
+	if a == Key("Привет, мир", "ru-RU-Standard-B", 1.2, "MP3") {
+		t.Fatalf("ключи для разной скорости речи совпадают")
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c, err := NewCache(t.TempDir(), 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Put("a", []byte("aaaa"))
+	c.Put("b", []byte("bbbb"))
+	// Обращаемся к "a", чтобы вытеснялась "b"
+	if _, ok := c.Get("a"); !ok {
+		t.Fatalf("ожидали попадание по ключу a")
+	}
+	c.Put("c", []byte("cccc"))
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatalf("запись b должна быть вытеснена")
+	}
+	if data, ok := c.Get("a"); !ok || !bytes.Equal(data, []byte("aaaa")) {
+		t.Fatalf("запись a должна остаться в кэше")
+	}
+
+	stats := c.Stats()
+	if stats.Entries != 2 || stats.SizeBytes != 8 || stats.Hits != 2 || stats.Misses != 1 {
+		t.Fatalf("неожиданная статистика: %+v", stats)
+	}
+}
+
+func TestCacheExpiresByTTLAndReloads(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewCache(dir, 0, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Put("a", []byte("aaaa"))
+
+	// Новый экземпляр видит записи, сохранённые на диске
+	reloaded, err := NewCache(dir, 0, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reloaded.Get("a"); !ok {
+		t.Fatalf("запись должна загрузиться с диска")
+	}
+
+	// Сдвигаем время создания, чтобы запись устарела
+	reloaded.entries["a"].Value.(*entry).createdAt = time.Now().Add(-2 * time.Hour)
+	if _, ok := reloaded.Get("a"); ok {
+		t.Fatalf("устаревшая запись не должна возвращаться")
+	}
+}
@@ -0,0 +1,126 @@
+// Файл audio_tags.go собирает итоговый аудиофайл дайджеста из синтезированных сегментов
+// и добавляет в него метаданные: название, исполнителя (канал), дату, обложку и главы по постам.
+// Границы глав вычисляются по длительности декодированных сегментов.
+
+package audio_tags
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hajimehoshi/go-mp3"
+)
+
+// Track описывает сегмент дайджеста, который становится отдельной главой
+type Track struct {
+	Title string // Название главы
+	Audio []byte // Аудио сегмента (MP3 или Ogg Opus)
+}
+
+// Metadata содержит общие метаданные аудиофайла
+type Metadata struct {
+	Title  string    // Название дайджеста
+	Artist string    // Исполнитель — имя канала
+	Date   time.Time // Дата выпуска дайджеста
+	Cover  []byte    // Обложка (JPEG или PNG), может отсутствовать
+}
+
+// Chapter описывает главу итогового аудиофайла
+type Chapter struct {
+	Title string        // Название главы
+	Start time.Duration // Начало главы от начала файла
+	End   time.Duration // Конец главы от начала файла
+}
+
+// Build объединяет сегменты в один файл указанной кодировки (MP3 или OGG_OPUS) и записывает в него
+// метаданные и главы. Возвращает итоговый файл и вычисленные главы.
+func Build(encoding string, tracks []Track, meta Metadata) ([]byte, []Chapter, error) {
+	switch encoding {
+	case "MP3":
+		return buildMP3(tracks, meta)
+	case "OGG_OPUS":
+		return buildOgg(tracks, meta)
+	default:
+		return nil, nil, fmt.Errorf("неподдерживаемая кодировка для записи тегов: %q", encoding)
+	}
+}
+
+// MP3Duration возвращает длительность MP3 по количеству декодированных сэмплов
+func MP3Duration(data []byte) (time.Duration, error) {
+	decoder, err := mp3.NewDecoder(bytes.NewReader(stripID3(data)))
+	if err != nil {
+		return 0, fmt.Errorf("не удалось декодировать MP3: %w", err)
+	}
+	// Декодер отдаёт 16-битный стерео PCM: 4 байта на сэмпл
+	samples := decoder.Length() / 4
+	return time.Duration(samples) * time.Second / time.Duration(decoder.SampleRate()), nil
+}
+
+// buildMP3 объединяет MP3-сегменты и добавляет перед ними тег ID3v2.4 с главами
+func buildMP3(tracks []Track, meta Metadata) ([]byte, []Chapter, error) {
+	durations := make([]time.Duration, len(tracks))
+	var audio bytes.Buffer
+	for i, track := range tracks {
+		data := stripID3(track.Audio)
+		duration, err := MP3Duration(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("сегмент %d: %w", i, err)
+		}
+		durations[i] = duration
+		audio.Write(data)
+	}
+
+	chapters := makeChapters(tracks, durations)
+	tag := id3Tag(meta, chapters)
+	return append(tag, audio.Bytes()...), chapters, nil
+}
+
+// buildOgg объединяет Ogg Opus сегменты в цепочку логических потоков и записывает
+// метаданные и главы в заголовок OpusTags первого потока
+func buildOgg(tracks []Track, meta Metadata) ([]byte, []Chapter, error) {
+	durations := make([]time.Duration, len(tracks))
+	for i, track := range tracks {
+		duration, err := OpusDuration(track.Audio)
+		if err != nil {
+			return nil, nil, fmt.Errorf("сегмент %d: %w", i, err)
+		}
+		durations[i] = duration
+	}
+
+	chapters := makeChapters(tracks, durations)
+	comments := opusComments(meta, chapters)
+
+	var out bytes.Buffer
+	for i, track := range tracks {
+		// Каждому потоку цепочки нужен свой серийный номер
+		serial := oggSerialBase + uint32(i)
+		var tags []string
+		if i == 0 {
+			tags = comments
+		}
+		data, err := rewriteOgg(track.Audio, serial, tags)
+		if err != nil {
+			return nil, nil, fmt.Errorf("сегмент %d: %w", i, err)
+		}
+		out.Write(data)
+	}
+	return out.Bytes(), chapters, nil
+}
+
+// makeChapters раскладывает сегменты на шкале времени итогового файла
+func makeChapters(tracks []Track, durations []time.Duration) []Chapter {
+	chapters := make([]Chapter, len(tracks))
+	var offset time.Duration
+	for i, track := range tracks {
+		chapters[i] = Chapter{Title: track.Title, Start: offset, End: offset + durations[i]}
+		offset += durations[i]
+	}
+	return chapters
+}
+
+// coverMIME определяет MIME-тип обложки по содержимому
+func coverMIME(cover []byte) string {
+	return http.DetectContentType(cover)
+}
@@ -0,0 +1,118 @@
+package audio_tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testOpus собирает минимальный поток Ogg Opus указанной длительности
+func testOpus(serial uint32, samples int64) []byte {
+	head := append([]byte("OpusHead"), 1, 1, 0x38, 0x01, 0x80, 0xbb, 0, 0, 0, 0, 0) // pre-skip 312
+	tags := append([]byte("OpusTags"), 6, 0, 0, 0)
+	tags = append(tags, "google"...)
+	tags = append(tags, 0, 0, 0, 0)
+
+	var sequence uint32
+	var out bytes.Buffer
+	for i, packet := range [][]byte{head, tags, make([]byte, 300)} {
+		for _, page := range packetPages(packet, serial, &sequence) {
+			switch i {
+			case 0:
+				page.headerType = 0x02
+			case 2:
+				page.headerType |= 0x04
+				if page.granule == 0 {
+					page.granule = samples + 312
+				}
+			}
+			out.Write(page.bytes())
+		}
+	}
+	return out.Bytes()
+}
+
+func TestBuildOggWritesChapters(t *testing.T) {
+	tracks := []Track{
+		{Title: "Вступление", Audio: testOpus(7, 48000)},
+		{Title: "12:30 — первые слова поста", Audio: testOpus(7, 96000)},
+	}
+	// Большая обложка заставляет разложить OpusTags на несколько страниц
+	meta := Metadata{Title: "Сводка", Artist: "news", Cover: bytes.Repeat([]byte{0xff, 0xd8, 0xff}, 40000)}
+
+	data, chapters, err := Build("OGG_OPUS", tracks, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chapters) != 2 || chapters[1].Start != time.Second || chapters[1].End != 3*time.Second {
+		t.Fatalf("неожиданные главы: %+v", chapters)
+	}
+
+	pages, err := parseOggPages(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serials := map[uint32]bool{}
+	var tagsPacket []byte
+	for i, page := range pages {
+		serials[page.serial] = true
+		// Контрольная сумма каждой страницы должна сходиться
+		raw := page.bytes()
+		if !bytes.Equal(raw, data[:len(raw)]) {
+			t.Fatalf("страница %d сериализована некорректно", i)
+		}
+		data = data[len(raw):]
+	}
+	for _, page := range pages[1:] {
+		tagsPacket = append(tagsPacket, page.body...)
+		if page.endsPacket() {
+			break
+		}
+	}
+	if !bytes.HasPrefix(tagsPacket, []byte("OpusTags\x06\x00\x00\x00google")) || !bytes.Contains(tagsPacket, []byte("CHAPTER001NAME=")) {
+		t.Fatalf("заголовок OpusTags не перезаписан")
+	}
+	if len(serials) != 2 {
+		t.Fatalf("потоки цепочки должны иметь разные серийные номера: %v", serials)
+	}
+
+	if duration, err := OpusDuration(tracks[0].Audio); err != nil || duration != time.Second {
+		t.Fatalf("OpusDuration: %v, %v", duration, err)
+	}
+
+	comments := strings.Join(opusComments(meta, chapters), "\n")
+	for _, want := range []string{"TITLE=Сводка", "ARTIST=news", "CHAPTER001=00:00:01.000", "CHAPTER001NAME=12:30 — первые слова поста"} {
+		if !strings.Contains(comments, want) {
+			t.Errorf("нет комментария %q", want)
+		}
+	}
+}
+
+func TestID3TagChapters(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Вступление", End: 1500 * time.Millisecond},
+		{Title: "12:30 — пост", Start: 1500 * time.Millisecond, End: 4 * time.Second},
+	}
+	tag := id3Tag(Metadata{Title: "Сводка", Artist: "news", Date: time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC)}, chapters)
+
+	if string(tag[:3]) != "ID3" || tag[3] != 4 {
+		t.Fatalf("некорректный заголовок ID3: %x", tag[:10])
+	}
+	if len(stripID3(append(tag, 0xff, 0xfb))) != 2 {
+		t.Fatalf("размер тега записан некорректно")
+	}
+
+	idx := bytes.Index(tag, []byte("CHAP"))
+	if idx < 0 || !bytes.Contains(tag, []byte("CTOC")) || !bytes.Contains(tag, []byte("TDRC")) {
+		t.Fatalf("в теге нет глав или даты")
+	}
+	// Второй фрейм CHAP: ch1, начало 1500 мс, конец 4000 мс
+	idx = bytes.LastIndex(tag, []byte("ch1\x00"))
+	start := binary.BigEndian.Uint32(tag[idx+4:])
+	end := binary.BigEndian.Uint32(tag[idx+8:])
+	if start != 1500 || end != 4000 {
+		t.Fatalf("неожиданные границы главы: %d–%d", start, end)
+	}
+}
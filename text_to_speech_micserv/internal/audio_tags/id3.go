@@ -0,0 +1,130 @@
+// Файл id3.go формирует тег ID3v2.4 для MP3: название, исполнитель, дата, обложка
+// и главы (фреймы CHAP и оглавление CTOC по спецификации ID3v2 Chapter Frame Addendum).
+
+package audio_tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Параметры тега ID3
+const (
+	id3EncodingUTF8 = 3          // Кодировка текста UTF-8 (ID3v2.4)
+	id3CoverFront   = 3          // Тип изображения APIC: передняя обложка
+	id3NoOffset     = ^uint32(0) // Смещение в байтах не задано — используется время
+	id3MaxTOCItems  = 255        // Максимальное количество глав в одном фрейме CTOC
+	id3TOCID        = "toc"      // Идентификатор оглавления
+)
+
+// id3Tag возвращает тег ID3v2.4 с метаданными и главами
+func id3Tag(meta Metadata, chapters []Chapter) []byte {
+	var frames bytes.Buffer
+	if meta.Title != "" {
+		frames.Write(id3TextFrame("TIT2", meta.Title))
+	}
+	if meta.Artist != "" {
+		frames.Write(id3TextFrame("TPE1", meta.Artist))
+	}
+	if !meta.Date.IsZero() {
+		frames.Write(id3TextFrame("TDRC", meta.Date.Format("2006-01-02T15:04")))
+	}
+	if len(meta.Cover) > 0 {
+		frames.Write(id3CoverFrame(meta.Cover))
+	}
+
+	if len(chapters) > 0 {
+		ids := make([]string, len(chapters))
+		for i := range chapters {
+			ids[i] = fmt.Sprintf("ch%d", i)
+		}
+		frames.Write(id3TOCFrame(ids, meta.Title))
+		for i, chapter := range chapters {
+			frames.Write(id3ChapterFrame(ids[i], chapter))
+		}
+	}
+
+	header := []byte{'I', 'D', '3', 4, 0, 0}
+	header = binary.BigEndian.AppendUint32(header, syncsafe(frames.Len()))
+	return append(header, frames.Bytes()...)
+}
+
+// id3Frame возвращает фрейм ID3v2.4 с указанным идентификатором и содержимым
+func id3Frame(id string, body []byte) []byte {
+	frame := append([]byte(id), 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(frame[4:8], syncsafe(len(body)))
+	return append(frame, body...)
+}
+
+// id3TextFrame возвращает текстовый фрейм в кодировке UTF-8
+func id3TextFrame(id, text string) []byte {
+	return id3Frame(id, append([]byte{id3EncodingUTF8}, text...))
+}
+
+// id3CoverFrame возвращает фрейм APIC с обложкой
+func id3CoverFrame(cover []byte) []byte {
+	var body bytes.Buffer
+	body.WriteByte(id3EncodingUTF8)
+	body.WriteString(coverMIME(cover))
+	body.WriteByte(0)
+	body.WriteByte(id3CoverFront)
+	body.WriteByte(0) // Пустое описание
+	body.Write(cover)
+	return id3Frame("APIC", body.Bytes())
+}
+
+// id3TOCFrame возвращает фрейм оглавления CTOC со ссылками на главы
+func id3TOCFrame(ids []string, title string) []byte {
+	if len(ids) > id3MaxTOCItems {
+		ids = ids[:id3MaxTOCItems]
+	}
+	var body bytes.Buffer
+	body.WriteString(id3TOCID)
+	body.WriteByte(0)
+	body.WriteByte(0x03) // Оглавление верхнего уровня, главы упорядочены
+	body.WriteByte(byte(len(ids)))
+	for _, id := range ids {
+		body.WriteString(id)
+		body.WriteByte(0)
+	}
+	if title != "" {
+		body.Write(id3TextFrame("TIT2", title))
+	}
+	return id3Frame("CTOC", body.Bytes())
+}
+
+// id3ChapterFrame возвращает фрейм главы CHAP с названием во вложенном фрейме TIT2
+func id3ChapterFrame(id string, chapter Chapter) []byte {
+	var body bytes.Buffer
+	body.WriteString(id)
+	body.WriteByte(0)
+	binary.Write(&body, binary.BigEndian, uint32(chapter.Start.Milliseconds()))
+	binary.Write(&body, binary.BigEndian, uint32(chapter.End.Milliseconds()))
+	binary.Write(&body, binary.BigEndian, id3NoOffset)
+	binary.Write(&body, binary.BigEndian, id3NoOffset)
+	body.Write(id3TextFrame("TIT2", chapter.Title))
+	return id3Frame("CHAP", body.Bytes())
+}
+
+// syncsafe кодирует размер в формате synchsafe integer (по 7 бит в байте)
+func syncsafe(n int) uint32 {
+	v := uint32(n)
+	return v&0x7f | (v>>7&0x7f)<<8 | (v>>14&0x7f)<<16 | (v>>21&0x7f)<<24
+}
+
+// stripID3 удаляет тег ID3v2 в начале MP3, если он есть
+func stripID3(data []byte) []byte {
+	if len(data) < 10 || string(data[:3]) != "ID3" {
+		return data
+	}
+	size := int(data[6])<<21 | int(data[7])<<14 | int(data[8])<<7 | int(data[9])
+	size += 10
+	if data[5]&0x10 != 0 {
+		size += 10 // Футер тега
+	}
+	if size > len(data) {
+		return data
+	}
+	return data[size:]
+}
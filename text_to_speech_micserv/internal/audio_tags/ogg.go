@@ -0,0 +1,280 @@
+// Файл ogg.go работает с контейнером Ogg Opus: разбирает страницы, вычисляет длительность потока
+// и перезаписывает заголовок OpusTags с метаданными и главами (Vorbis Comment Chapter Extension).
+
+package audio_tags
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Параметры контейнера Ogg
+const (
+	oggHeaderSize    = 27         // Размер заголовка страницы без таблицы сегментов
+	oggMaxSegments   = 255        // Максимальное количество сегментов на странице
+	oggContinued     = 0x01       // Флаг: страница продолжает пакет с предыдущей страницы
+	oggNoGranule     = -1         // Позиция не задана: на странице не завершается ни один пакет
+	oggSerialBase    = 0x54545300 // Начальный серийный номер потоков цепочки
+	opusSampleRate   = 48000      // Частота, в которой считаются позиции Opus
+	flacPictureFront = 3          // Тип изображения METADATA_BLOCK_PICTURE: передняя обложка
+)
+
+// oggPage описывает страницу Ogg
+type oggPage struct {
+	headerType byte   // Флаги страницы (продолжение, начало и конец потока)
+	granule    int64  // Позиция последнего завершённого на странице пакета
+	serial     uint32 // Серийный номер логического потока
+	sequence   uint32 // Порядковый номер страницы в потоке
+	segments   []byte // Таблица сегментов (lacing values)
+	body       []byte // Данные страницы
+}
+
+// parseOggPages разбирает файл Ogg на страницы
+func parseOggPages(data []byte) ([]oggPage, error) {
+	var pages []oggPage
+	for len(data) > 0 {
+		if len(data) < oggHeaderSize || string(data[:4]) != "OggS" {
+			return nil, errors.New("некорректная страница Ogg")
+		}
+		count := int(data[26])
+		if len(data) < oggHeaderSize+count {
+			return nil, errors.New("обрезанная страница Ogg")
+		}
+		segments := data[oggHeaderSize : oggHeaderSize+count]
+		bodySize := 0
+		for _, s := range segments {
+			bodySize += int(s)
+		}
+		end := oggHeaderSize + count + bodySize
+		if len(data) < end {
+			return nil, errors.New("обрезанная страница Ogg")
+		}
+		pages = append(pages, oggPage{
+			headerType: data[5],
+			granule:    int64(binary.LittleEndian.Uint64(data[6:14])),
+			serial:     binary.LittleEndian.Uint32(data[14:18]),
+			sequence:   binary.LittleEndian.Uint32(data[18:22]),
+			segments:   segments,
+			body:       data[oggHeaderSize+count : end],
+		})
+		data = data[end:]
+	}
+	if len(pages) == 0 {
+		return nil, errors.New("пустой поток Ogg")
+	}
+	return pages, nil
+}
+
+// bytes сериализует страницу с пересчитанной контрольной суммой
+func (p oggPage) bytes() []byte {
+	page := make([]byte, oggHeaderSize, oggHeaderSize+len(p.segments)+len(p.body))
+	copy(page, "OggS")
+	page[5] = p.headerType
+	binary.LittleEndian.PutUint64(page[6:14], uint64(p.granule))
+	binary.LittleEndian.PutUint32(page[14:18], p.serial)
+	binary.LittleEndian.PutUint32(page[18:22], p.sequence)
+	page[26] = byte(len(p.segments))
+	page = append(page, p.segments...)
+	page = append(page, p.body...)
+	binary.LittleEndian.PutUint32(page[22:26], oggCRC(page))
+	return page
+}
+
+// endsPacket сообщает, завершается ли на странице хотя бы один пакет
+func (p oggPage) endsPacket() bool {
+	for _, s := range p.segments {
+		if s < oggMaxSegments {
+			return true
+		}
+	}
+	return false
+}
+
+// OpusDuration возвращает длительность первого логического потока Ogg Opus
+// по позиции последней страницы за вычетом pre-skip
+func OpusDuration(data []byte) (time.Duration, error) {
+	pages, err := parseOggPages(data)
+	if err != nil {
+		return 0, err
+	}
+	head := pages[0].body
+	if len(head) < 19 || string(head[:8]) != "OpusHead" {
+		return 0, errors.New("отсутствует заголовок OpusHead")
+	}
+	preSkip := int64(binary.LittleEndian.Uint16(head[10:12]))
+
+	var granule int64
+	for _, page := range pages {
+		if page.serial == pages[0].serial && page.granule != oggNoGranule {
+			granule = page.granule
+		}
+	}
+	samples := max(granule-preSkip, 0)
+	return time.Duration(samples) * time.Second / opusSampleRate, nil
+}
+
+// rewriteOgg переписывает страницы первого логического потока с новым серийным номером.
+// Если comments не nil, заголовок OpusTags заменяется новым с этими комментариями.
+func rewriteOgg(data []byte, serial uint32, comments []string) ([]byte, error) {
+	pages, err := parseOggPages(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) < 2 {
+		return nil, errors.New("в потоке Ogg нет заголовка OpusTags")
+	}
+
+	var out bytes.Buffer
+	var sequence uint32
+	for i := 0; i < len(pages); i++ {
+		page := pages[i]
+		if page.serial != pages[0].serial {
+			return nil, errors.New("сегмент содержит несколько логических потоков Ogg")
+		}
+
+		// Страницы со второго пакета (OpusTags) до его конца заменяем новым заголовком
+		if i == 1 && comments != nil {
+			var original []byte
+			for ; i < len(pages); i++ {
+				original = append(original, pages[i].body...)
+				if pages[i].endsPacket() {
+					break
+				}
+			}
+			tags, err := opusTagsPacket(original, comments)
+			if err != nil {
+				return nil, err
+			}
+			for _, tagPage := range packetPages(tags, serial, &sequence) {
+				out.Write(tagPage.bytes())
+			}
+			continue
+		}
+
+		page.serial = serial
+		page.sequence = sequence
+		sequence++
+		out.Write(page.bytes())
+	}
+	return out.Bytes(), nil
+}
+
+// packetPages раскладывает заголовочный пакет по страницам с позицией 0
+func packetPages(packet []byte, serial uint32, sequence *uint32) []oggPage {
+	// Таблица сегментов: полные сегменты по 255 байт и завершающий сегмент меньшего размера
+	lacing := bytes.Repeat([]byte{oggMaxSegments}, len(packet)/oggMaxSegments)
+	lacing = append(lacing, byte(len(packet)%oggMaxSegments))
+
+	var pages []oggPage
+	for len(lacing) > 0 {
+		n := min(len(lacing), oggMaxSegments)
+		page := oggPage{serial: serial, sequence: *sequence, segments: lacing[:n], granule: oggNoGranule}
+		size := 0
+		for _, s := range page.segments {
+			size += int(s)
+		}
+		page.body, packet = packet[:size], packet[size:]
+		if len(pages) > 0 {
+			page.headerType = oggContinued
+		}
+		if page.endsPacket() {
+			page.granule = 0
+		}
+		pages = append(pages, page)
+		lacing = lacing[n:]
+		*sequence++
+	}
+	return pages
+}
+
+// opusTagsPacket формирует пакет OpusTags с исходной строкой vendor и новыми комментариями
+func opusTagsPacket(original []byte, comments []string) ([]byte, error) {
+	if len(original) < 12 || string(original[:8]) != "OpusTags" {
+		return nil, errors.New("отсутствует заголовок OpusTags")
+	}
+	vendorLen := int(binary.LittleEndian.Uint32(original[8:12]))
+	if len(original) < 12+vendorLen {
+		return nil, errors.New("некорректный заголовок OpusTags")
+	}
+
+	packet := append([]byte("OpusTags"), original[8:12+vendorLen]...)
+	packet = binary.LittleEndian.AppendUint32(packet, uint32(len(comments)))
+	for _, comment := range comments {
+		packet = binary.LittleEndian.AppendUint32(packet, uint32(len(comment)))
+		packet = append(packet, comment...)
+	}
+	return packet, nil
+}
+
+// opusComments формирует комментарии Vorbis Comment с метаданными и главами
+func opusComments(meta Metadata, chapters []Chapter) []string {
+	comments := []string{}
+	if meta.Title != "" {
+		comments = append(comments, "TITLE="+meta.Title)
+	}
+	if meta.Artist != "" {
+		comments = append(comments, "ARTIST="+meta.Artist)
+	}
+	if !meta.Date.IsZero() {
+		comments = append(comments, "DATE="+meta.Date.Format("2006-01-02"))
+	}
+	if len(meta.Cover) > 0 {
+		comments = append(comments, "METADATA_BLOCK_PICTURE="+base64.StdEncoding.EncodeToString(flacPicture(meta.Cover)))
+	}
+	for i, chapter := range chapters {
+		comments = append(comments,
+			fmt.Sprintf("CHAPTER%03d=%s", i, formatChapterTime(chapter.Start)),
+			fmt.Sprintf("CHAPTER%03dNAME=%s", i, chapter.Title),
+		)
+	}
+	return comments
+}
+
+// formatChapterTime форматирует время начала главы как ЧЧ:ММ:СС.ммм
+func formatChapterTime(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// flacPicture формирует блок METADATA_BLOCK_PICTURE формата FLAC с обложкой.
+// Размеры и глубина цвета не указываются — проигрыватели определяют их по самому изображению.
+func flacPicture(cover []byte) []byte {
+	mime := coverMIME(cover)
+	block := binary.BigEndian.AppendUint32(nil, flacPictureFront)
+	block = binary.BigEndian.AppendUint32(block, uint32(len(mime)))
+	block = append(block, mime...)
+	block = binary.BigEndian.AppendUint32(block, 0) // Пустое описание
+	block = append(block, make([]byte, 16)...)      // Ширина, высота, глубина цвета, размер палитры
+	block = binary.BigEndian.AppendUint32(block, uint32(len(cover)))
+	return append(block, cover...)
+}
+
+// oggCRCTable — таблица CRC-32 Ogg (полином 0x04c11db7 без отражения)
+var oggCRCTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		r := uint32(i) << 24
+		for range 8 {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		table[i] = r
+	}
+	return table
+}()
+
+// oggCRC вычисляет контрольную сумму страницы Ogg
+func oggCRC(data []byte) uint32 {
+	var crc uint32
+	for _, b := range data {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
@@ -0,0 +1,65 @@
+// Файл blob_storage.go определяет абстракцию хранилища больших бинарных объектов (аудиофайлов).
+// Вместо передачи аудио через Kafka сервис загружает его в хранилище и публикует ссылку на объект;
+// потребитель (бот) скачивает аудио по URL из ссылки и сверяет размер и контрольную сумму.
+// Реализации: локальная файловая система (FileStorage) и S3-совместимое хранилище (S3Storage).
+
+package blob_storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+
+	"text_to_speech_app/internal/model/model_text_to_speech"
+)
+
+// ErrInvalidKey возвращается для ключа, который не может быть ключом объекта (пустой, с «..» и т. п.)
+var ErrInvalidKey = errors.New("некорректный ключ объекта")
+
+// ValidKey сообщает, может ли строка быть ключом объекта: непустой путь через «/» без «.», «..» и пустых частей
+func ValidKey(key string) bool {
+	return key != "" && fs.ValidPath(key)
+}
+
+// ErrNotFound возвращается, если объект отсутствует в хранилище
+var ErrNotFound = errors.New("объект не найден в хранилище")
+
+// Storage описывает хранилище бинарных объектов
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error // Сохраняет объект по ключу
+	Get(ctx context.Context, key string) (io.ReadCloser, error)                 // Открывает объект для чтения
+	Delete(ctx context.Context, key string) error                               // Удаляет объект
+	URL(key string, expiry time.Duration) (string, error)                       // Возвращает ссылку для скачивания объекта
+}
+
+// Upload сохраняет данные в хранилище и возвращает ссылку на объект с размером, контрольной суммой и сроком действия
+func Upload(ctx context.Context, storage Storage, key string, data []byte, contentType string, ttl time.Duration) (*model_text_to_speech.AudioRef, error) {
+	if err := storage.Put(ctx, key, data, contentType); err != nil {
+		return nil, fmt.Errorf("не удалось загрузить объект %s: %w", key, err)
+	}
+
+	url, err := storage.URL(key, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить ссылку на объект %s: %w", key, err)
+	}
+
+	return &model_text_to_speech.AudioRef{
+		Key:         key,
+		URL:         url,
+		Size:        int64(len(data)),
+		SHA256:      Checksum(data),
+		ContentType: contentType,
+		ExpiresAt:   time.Now().Add(ttl).UTC(),
+	}, nil
+}
+
+// Checksum возвращает SHA-256 данных в шестнадцатеричном виде
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,136 @@
+package blob_storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 — минимальная замена MinIO: хранит объекты в памяти и требует подпись запроса
+type fakeS3 struct {
+	mutex   sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	signed := strings.HasPrefix(r.Header.Get("Authorization"), sigAlgorithm+" Credential=test/") ||
+		r.URL.Query().Get("X-Amz-Signature") != ""
+	if !signed {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = data
+	case http.MethodGet:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3StorageRoundTrip(t *testing.T) {
+	fake := &fakeS3{objects: make(map[string][]byte)}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	storage, err := NewS3Storage(S3Config{Endpoint: srv.URL, Bucket: "audio", AccessKey: "test", SecretKey: "secret"}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	ref, err := Upload(ctx, storage, "digests/a b.mp3", []byte("ID3 audio"), "audio/mpeg", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.objects["/audio/digests/a b.mp3"]; !ok {
+		t.Fatalf("объект не сохранён в бакете: %v", fake.objects)
+	}
+
+	if data := read(t, storage, ref.Key); data != "ID3 audio" {
+		t.Fatalf("Get: %q", data)
+	}
+
+	// Потребитель без доступа к хранилищу скачивает по подписанной ссылке
+	if !strings.Contains(ref.URL, "X-Amz-Signature=") {
+		t.Fatalf("ссылка не подписана: %s", ref.URL)
+	}
+	resp, err := srv.Client().Get(ref.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(data) != "ID3 audio" {
+		t.Fatalf("скачивание по ссылке: %s, %q", resp.Status, data)
+	}
+
+	if err := storage.Delete(ctx, ref.Key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.Get(ctx, ref.Key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("ожидали ErrNotFound, получили %v", err)
+	}
+}
+
+func TestFileStorage(t *testing.T) {
+	storage, err := NewFileStorage(t.TempDir(), "http://localhost:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	ref, err := Upload(ctx, storage, "digests/x.mp3", []byte("audio"), "audio/mpeg", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.URL != "http://localhost:8080/audio/digests/x.mp3" {
+		t.Fatalf("неожиданная ссылка: %s", ref.URL)
+	}
+
+	if ref.Size != 5 || ref.SHA256 != Checksum([]byte("audio")) {
+		t.Fatalf("неожиданные размер или контрольная сумма: %+v", ref)
+	}
+	if data := read(t, storage, ref.Key); data != "audio" {
+		t.Fatalf("Get: %q", data)
+	}
+
+	if _, err := storage.Get(ctx, "../secret"); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("ключ вне хранилища должен отклоняться, получили %v", err)
+	}
+	if _, err := storage.Get(ctx, "digests"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("ожидали ErrNotFound для префикса ключа, получили %v", err)
+	}
+}
+
+// read читает объект из хранилища целиком
+func read(t *testing.T, storage Storage, key string) string {
+	t.Helper()
+	rc, err := storage.Get(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,135 @@
+// Файл file_storage.go реализует хранилище объектов в локальной файловой системе.
+// Объекты отдаются потребителям через HTTP-эндпоинт /audio/ сервиса Text-to-Speech.
+
+package blob_storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileStorage хранит объекты в директории на локальном диске
+type FileStorage struct {
+	dir     string // Корневая директория хранилища
+	baseURL string // Публичный адрес сервиса, по которому отдаются объекты
+}
+
+// NewFileStorage создаёт файловое хранилище в указанной директории
+func NewFileStorage(dir, baseURL string) (*FileStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("не удалось создать директорию хранилища %s: %w", dir, err)
+	}
+	return &FileStorage{
+		dir:     dir,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}, nil
+}
+
+// Put сохраняет объект в файл, записывая его атомарно через временный файл
+func (f *FileStorage) Put(_ context.Context, key string, data []byte, _ string) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("не удалось создать директорию для %s: %w", key, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("не удалось создать временный файл: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("не удалось записать объект %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("не удалось закрыть объект %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("не удалось сохранить объект %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get открывает файл объекта для чтения
+func (f *FileStorage) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть объект %s: %w", key, err)
+	}
+	// Ключ-префикс указывает на директорию, а не на объект
+	if info, err := file.Stat(); err == nil && info.IsDir() {
+		file.Close()
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return file, nil
+}
+
+// Delete удаляет файл объекта
+func (f *FileStorage) Delete(_ context.Context, key string) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("не удалось удалить объект %s: %w", key, err)
+	}
+	return nil
+}
+
+// URL возвращает адрес объекта на HTTP-эндпоинте сервиса; срок действия контролирует Cleanup
+func (f *FileStorage) URL(key string, _ time.Duration) (string, error) {
+	if _, err := f.path(key); err != nil {
+		return "", err
+	}
+	return f.baseURL + "/audio/" + (&url.URL{Path: key}).EscapedPath(), nil
+}
+
+// Cleanup удаляет объекты, которые старше заданного возраста, и возвращает их количество
+func (f *FileStorage) Cleanup(olderThan time.Duration) (int, error) {
+	threshold := time.Now().Add(-olderThan)
+	removed := 0
+	err := filepath.WalkDir(f.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().Before(threshold) {
+			if err := os.Remove(path); err == nil {
+				removed++
+			}
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// path преобразует ключ объекта в путь на диске, не позволяя выйти за пределы корневой директории
+func (f *FileStorage) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return filepath.Join(f.dir, filepath.FromSlash(key)), nil
+}
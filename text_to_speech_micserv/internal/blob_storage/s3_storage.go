@@ -0,0 +1,292 @@
+// Файл s3_storage.go реализует хранилище объектов в S3-совместимом сервисе (AWS S3, MinIO и т.п.).
+// Запросы подписываются по схеме AWS Signature V4, используется адресация path-style (endpoint/bucket/key).
+
+package blob_storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Параметры подписи AWS Signature V4
+const (
+	sigAlgorithm      = "AWS4-HMAC-SHA256"
+	sigService        = "s3"
+	sigTerminator     = "aws4_request"
+	sigTimeFormat     = "20060102T150405Z"
+	sigDateFormat     = "20060102"
+	unsignedPayload   = "UNSIGNED-PAYLOAD"
+	maxPresignExpires = 7 * 24 * time.Hour // Максимальный срок действия подписанной ссылки в S3
+)
+
+// S3Config содержит параметры подключения к S3-совместимому хранилищу
+type S3Config struct {
+	Endpoint  string // Адрес сервиса, например http://localhost:9000
+	Bucket    string // Имя бакета
+	Region    string // Регион (для MinIO обычно us-east-1)
+	AccessKey string // Ключ доступа
+	SecretKey string // Секретный ключ
+}
+
+// S3Storage хранит объекты в S3-совместимом хранилище
+type S3Storage struct {
+	cfg      S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time // Источник времени (подменяется в тестах)
+}
+
+// NewS3Storage создаёт клиента S3-совместимого хранилища
+func NewS3Storage(cfg S3Config, client *http.Client) (*S3Storage, error) {
+	endpoint, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("некорректный адрес S3: %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("не указан бакет S3")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+	return &S3Storage{
+		cfg:      cfg,
+		endpoint: endpoint,
+		client:   client,
+		now:      time.Now,
+	}, nil
+}
+
+// Put загружает объект в бакет (PutObject)
+func (s *S3Storage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("не удалось создать запрос PutObject: %w", err)
+	}
+	req.ContentLength = int64(len(data))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	sum := sha256.Sum256(data)
+	s.sign(req, hex.EncodeToString(sum[:]))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса PutObject: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s.responseError("PutObject", key, resp)
+	}
+	return nil
+}
+
+// Get открывает объект из бакета для чтения (GetObject)
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать запрос GetObject: %w", err)
+	}
+	s.sign(req, hex.EncodeToString(emptySHA256()))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса GetObject: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	default:
+		defer resp.Body.Close()
+		return nil, s.responseError("GetObject", key, resp)
+	}
+}
+
+// Delete удаляет объект из бакета (DeleteObject)
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return fmt.Errorf("не удалось создать запрос DeleteObject: %w", err)
+	}
+	s.sign(req, hex.EncodeToString(emptySHA256()))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса DeleteObject: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s.responseError("DeleteObject", key, resp)
+	}
+	return nil
+}
+
+// URL возвращает подписанную ссылку (presigned URL) на скачивание объекта
+func (s *S3Storage) URL(key string, expiry time.Duration) (string, error) {
+	if expiry <= 0 || expiry > maxPresignExpires {
+		expiry = maxPresignExpires
+	}
+
+	now := s.now().UTC()
+	u := s.objectURL(key)
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", sigAlgorithm)
+	query.Set("X-Amz-Credential", s.cfg.AccessKey+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(sigTimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		canonicalQuery(query),
+		"host:" + u.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+	query.Set("X-Amz-Signature", s.signature(now, canonical))
+
+	u.RawQuery = canonicalQuery(query)
+	return u.String(), nil
+}
+
+// objectURL возвращает адрес объекта в стиле path-style
+func (s *S3Storage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	u.Path = s.endpoint.Path + "/" + s.cfg.Bucket + "/" + key
+	u.RawPath = s.endpoint.Path + "/" + uriEncode(s.cfg.Bucket, true) + "/" + uriEncode(key, false)
+	return &u
+}
+
+// sign добавляет в запрос заголовки подписи AWS Signature V4
+func (s *S3Storage) sign(req *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format(sigTimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Собираем подписываемые заголовки в каноническом виде
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigAlgorithm, s.cfg.AccessKey, s.scope(now), signedHeaders, s.signature(now, canonical)))
+}
+
+// signature вычисляет подпись канонического запроса
+func (s *S3Storage) signature(now time.Time, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		sigAlgorithm,
+		now.Format(sigTimeFormat),
+		s.scope(now),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), now.Format(sigDateFormat))
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, sigService)
+	key = hmacSHA256(key, sigTerminator)
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// scope возвращает область действия учётных данных: дата/регион/сервис/aws4_request
+func (s *S3Storage) scope(now time.Time) string {
+	return strings.Join([]string{now.Format(sigDateFormat), s.cfg.Region, sigService, sigTerminator}, "/")
+}
+
+// responseError формирует ошибку по ответу S3 с кодом статуса и телом ответа
+func (s *S3Storage) responseError(op, key string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("ошибка %s для %s: статус %s: %s", op, key, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// canonicalQuery кодирует параметры запроса в каноническом виде (отсортированы, RFC 3986)
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		vals := append([]string(nil), values[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode кодирует строку по правилам AWS: незарезервированные символы остаются как есть
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// hmacSHA256 вычисляет HMAC-SHA256
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// emptySHA256 возвращает SHA-256 пустого тела запроса
+func emptySHA256() []byte {
+	sum := sha256.Sum256(nil)
+	return sum[:]
+}
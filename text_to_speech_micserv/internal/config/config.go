@@ -3,16 +3,38 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 )
 
 // Структура Config содержит конфигурационные параметры
 type Config struct {
-	ServerPort            string // Порт HTTP-сервера
-	GoogleCredentialsFile string // Путь к файлу учетных данных Google Cloud
-	KafkaPort             string // адрес брокера Kafka
-	NameTopicProdus       string // Имя топика Kafka для отправки сообщений
-	NameTopicConsum       string // Имя топика Kafka для принятия сообщений
-	KafkaGroupID          string // Идентификатор группы консьюмеров Kafka
+	ServerPort            string         // Порт HTTP-сервера
+	GoogleCredentialsFile string         // Путь к файлу учетных данных Google Cloud
+	KafkaPort             string         // адрес брокера Kafka
+	NameTopicProdus       string         // Имя топика Kafka для отправки сообщений
+	NameTopicConsum       string         // Имя топика Kafka для принятия сообщений
+	KafkaGroupID          string         // Идентификатор группы консьюмеров Kafka
+	AudioCacheDir         string         // Директория дискового кэша аудиосегментов
+	AudioCacheMaxBytes    int64          // Максимальный размер кэша аудиосегментов в байтах
+	AudioCacheTTL         time.Duration  // Время жизни записи в кэше аудиосегментов
+	BlobStorage           string         // Тип хранилища аудиофайлов: file или s3
+	BlobDir               string         // Директория файлового хранилища аудиофайлов
+	BlobTTL               time.Duration  // Срок действия ссылки на аудиофайл
+	PublicBaseURL         string         // Публичный адрес сервиса для ссылок на аудиофайлы
+	S3Endpoint            string         // Адрес S3-совместимого хранилища
+	S3Bucket              string         // Имя бакета S3
+	S3Region              string         // Регион S3
+	S3AccessKey           string         // Ключ доступа S3
+	S3SecretKey           string         // Секретный ключ S3
+	SSMLEnabled           bool           // Использовать SSML при синтезе дайджестов
+	TimeZone              *time.Location // Часовой пояс для озвучивания времени публикации постов
+	CoverFile             string         // Путь к файлу обложки дайджеста (JPEG или PNG), необязательный
+	PodcastSecret         string         // Секрет для токенов подкаст-лент (пусто — ленты отключены)
+	PodcastCatalogFile    string         // Путь к файлу каталога выпусков подкастов
+	AdminToken            string         // Токен служебных эндпоинтов, например выдачи ссылок на подкаст-ленты (пусто — эндпоинты выключены)
+	GRPCPort              string         // Порт gRPC-сервера
+	GRPCTimeout           time.Duration  // Максимальное время обработки gRPC-запроса
 }
 
 // Load загружает конфигурацию из переменных окружения
@@ -45,12 +67,141 @@ func Load() (*Config, error) {
 	if kafkaGroupID == "" {
 		return nil, fmt.Errorf("KAFKA_GROUP_ID не указан")
 	}
-	
+
+	// Получаем директорию кэша аудиосегментов
+	audioCacheDir := os.Getenv("AUDIO_CACHE_DIR")
+	if audioCacheDir == "" {
+		audioCacheDir = "audio_cache"
+	}
+
+	// Получаем максимальный размер кэша в мегабайтах
+	audioCacheMaxMB := int64(512)
+	if v := os.Getenv("AUDIO_CACHE_MAX_MB"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("AUDIO_CACHE_MAX_MB указан неверно: %q", v)
+		}
+		audioCacheMaxMB = parsed
+	}
+
+	// Получаем время жизни записи в кэше в часах
+	audioCacheTTLHours := 168
+	if v := os.Getenv("AUDIO_CACHE_TTL_HOURS"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("AUDIO_CACHE_TTL_HOURS указан неверно: %q", v)
+		}
+		audioCacheTTLHours = parsed
+	}
+
+	// Получаем тип хранилища аудиофайлов
+	blobStorage := os.Getenv("BLOB_STORAGE")
+	if blobStorage == "" {
+		blobStorage = "file"
+	}
+	if blobStorage != "file" && blobStorage != "s3" {
+		return nil, fmt.Errorf("BLOB_STORAGE указан неверно: %q (ожидается file или s3)", blobStorage)
+	}
+
+	// Получаем директорию файлового хранилища
+	blobDir := os.Getenv("BLOB_DIR")
+	if blobDir == "" {
+		blobDir = "audio_blobs"
+	}
+
+	// Получаем срок действия ссылки на аудиофайл в часах
+	blobTTLHours := 72
+	if v := os.Getenv("BLOB_TTL_HOURS"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("BLOB_TTL_HOURS указан неверно: %q", v)
+		}
+		blobTTLHours = parsed
+	}
+
+	// Получаем публичный адрес сервиса
+	publicBaseURL := os.Getenv("PUBLIC_BASE_URL")
+	if publicBaseURL == "" {
+		publicBaseURL = "http://localhost" + port
+	}
+
+	// Получаем параметры S3-совместимого хранилища
+	s3Endpoint := os.Getenv("S3_ENDPOINT")
+	s3Bucket := os.Getenv("S3_BUCKET")
+	s3Region := os.Getenv("S3_REGION")
+	s3AccessKey := os.Getenv("S3_ACCESS_KEY")
+	s3SecretKey := os.Getenv("S3_SECRET_KEY")
+	if blobStorage == "s3" && (s3Endpoint == "" || s3Bucket == "" || s3AccessKey == "" || s3SecretKey == "") {
+		return nil, fmt.Errorf("для BLOB_STORAGE=s3 необходимо указать S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY и S3_SECRET_KEY")
+	}
+
+	// Получаем признак использования SSML (по умолчанию включено)
+	ssmlEnabled := true
+	if v := os.Getenv("TTS_SSML_ENABLED"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("TTS_SSML_ENABLED указан неверно: %q", v)
+		}
+		ssmlEnabled = parsed
+	}
+
+	// Получаем часовой пояс для озвучивания времени публикации
+	timeZoneName := os.Getenv("TTS_TIMEZONE")
+	if timeZoneName == "" {
+		timeZoneName = "Europe/Moscow"
+	}
+	timeZone, err := time.LoadLocation(timeZoneName)
+	if err != nil {
+		return nil, fmt.Errorf("TTS_TIMEZONE указан неверно: %w", err)
+	}
+
+	// Получаем путь к каталогу выпусков подкастов
+	podcastCatalogFile := os.Getenv("PODCAST_CATALOG_FILE")
+	if podcastCatalogFile == "" {
+		podcastCatalogFile = "podcast_catalog.json"
+	}
+
+	// Получаем порт gRPC-сервера
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = ":9090"
+	}
+
+	// Получаем максимальное время обработки gRPC-запроса в секундах
+	grpcTimeoutSec := 300
+	if v := os.Getenv("GRPC_TIMEOUT_SEC"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("GRPC_TIMEOUT_SEC указан неверно: %q", v)
+		}
+		grpcTimeoutSec = parsed
+	}
+
 	return &Config{
 		ServerPort:            port,
 		GoogleCredentialsFile: credentialsFile,
 		KafkaPort:             kafkaPort,
 		NameTopicProdus:       kafkaTopic,
 		KafkaGroupID:          kafkaGroupID,
+		AudioCacheDir:         audioCacheDir,
+		AudioCacheMaxBytes:    audioCacheMaxMB << 20,
+		AudioCacheTTL:         time.Duration(audioCacheTTLHours) * time.Hour,
+		BlobStorage:           blobStorage,
+		BlobDir:               blobDir,
+		BlobTTL:               time.Duration(blobTTLHours) * time.Hour,
+		PublicBaseURL:         publicBaseURL,
+		S3Endpoint:            s3Endpoint,
+		S3Bucket:              s3Bucket,
+		S3Region:              s3Region,
+		S3AccessKey:           s3AccessKey,
+		S3SecretKey:           s3SecretKey,
+		SSMLEnabled:           ssmlEnabled,
+		TimeZone:              timeZone,
+		CoverFile:             os.Getenv("TTS_COVER_FILE"),
+		PodcastSecret:         os.Getenv("PODCAST_SECRET"),
+		PodcastCatalogFile:    podcastCatalogFile,
+		AdminToken:            os.Getenv("ADMIN_TOKEN"),
+		GRPCPort:              grpcPort,
+		GRPCTimeout:           time.Duration(grpcTimeoutSec) * time.Second,
 	}, nil
 }
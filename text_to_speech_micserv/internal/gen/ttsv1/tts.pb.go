@@ -0,0 +1,853 @@
+// Файл tts.proto описывает gRPC API микросервиса Text-to-Speech.
+// Go-код генерируется командой `buf generate` в директорию internal/gen/ttsv1.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: tts/v1/tts.proto
+
+package ttsv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// SynthesizeRequest — запрос на синтез дайджеста
+type SynthesizeRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Text           map[int64]string       `protobuf:"bytes,1,rep,name=text,proto3" json:"text,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // тексты постов по идентификатору (unix-время публикации)
+	SpeakingRate   float64                `protobuf:"fixed64,2,opt,name=speaking_rate,json=speakingRate,proto3" json:"speaking_rate,omitempty"`                                      // скорость речи (1.0 — стандартная)
+	AudioEncoding  string                 `protobuf:"bytes,3,opt,name=audio_encoding,json=audioEncoding,proto3" json:"audio_encoding,omitempty"`                                     // формат аудио: MP3 (по умолчанию) или OGG_OPUS
+	VoiceName      string                 `protobuf:"bytes,4,opt,name=voice_name,json=voiceName,proto3" json:"voice_name,omitempty"`                                                 // имя голоса, например ru-RU-Wavenet-A
+	LanguageCode   string                 `protobuf:"bytes,5,opt,name=language_code,json=languageCode,proto3" json:"language_code,omitempty"`                                        // код языка, например ru-RU
+	SsmlGender     string                 `protobuf:"bytes,6,opt,name=ssml_gender,json=ssmlGender,proto3" json:"ssml_gender,omitempty"`                                              // пол голоса: MALE, FEMALE или NEUTRAL
+	Pitch          float64                `protobuf:"fixed64,7,opt,name=pitch,proto3" json:"pitch,omitempty"`                                                                        // высота голоса в полутонах, от -20 до 20
+	VolumeGainDb   float64                `protobuf:"fixed64,8,opt,name=volume_gain_db,json=volumeGainDb,proto3" json:"volume_gain_db,omitempty"`                                    // усиление громкости в дБ, от -96 до 16
+	EffectsProfile []string               `protobuf:"bytes,9,rep,name=effects_profile,json=effectsProfile,proto3" json:"effects_profile,omitempty"`                                  // профили аудиоэффектов
+	ChannelName    string                 `protobuf:"bytes,10,opt,name=channel_name,json=channelName,proto3" json:"channel_name,omitempty"`                                          // имя канала для вступления
+	PeriodHours    int32                  `protobuf:"varint,11,opt,name=period_hours,json=periodHours,proto3" json:"period_hours,omitempty"`                                         // период дайджеста в часах
+	PlainText      bool                   `protobuf:"varint,12,opt,name=plain_text,json=plainText,proto3" json:"plain_text,omitempty"`                                               // озвучивать простым текстом, без SSML
+	ChatId         int64                  `protobuf:"varint,13,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`                                                        // чат пользователя Telegram
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SynthesizeRequest) Reset() {
+	*x = SynthesizeRequest{}
+	mi := &file_tts_v1_tts_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SynthesizeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SynthesizeRequest) ProtoMessage() {}
+
+func (x *SynthesizeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tts_v1_tts_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SynthesizeRequest.ProtoReflect.Descriptor instead.
+func (*SynthesizeRequest) Descriptor() ([]byte, []int) {
+	return file_tts_v1_tts_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SynthesizeRequest) GetText() map[int64]string {
+	if x != nil {
+		return x.Text
+	}
+	return nil
+}
+
+func (x *SynthesizeRequest) GetSpeakingRate() float64 {
+	if x != nil {
+		return x.SpeakingRate
+	}
+	return 0
+}
+
+func (x *SynthesizeRequest) GetAudioEncoding() string {
+	if x != nil {
+		return x.AudioEncoding
+	}
+	return ""
+}
+
+func (x *SynthesizeRequest) GetVoiceName() string {
+	if x != nil {
+		return x.VoiceName
+	}
+	return ""
+}
+
+func (x *SynthesizeRequest) GetLanguageCode() string {
+	if x != nil {
+		return x.LanguageCode
+	}
+	return ""
+}
+
+func (x *SynthesizeRequest) GetSsmlGender() string {
+	if x != nil {
+		return x.SsmlGender
+	}
+	return ""
+}
+
+func (x *SynthesizeRequest) GetPitch() float64 {
+	if x != nil {
+		return x.Pitch
+	}
+	return 0
+}
+
+func (x *SynthesizeRequest) GetVolumeGainDb() float64 {
+	if x != nil {
+		return x.VolumeGainDb
+	}
+	return 0
+}
+
+func (x *SynthesizeRequest) GetEffectsProfile() []string {
+	if x != nil {
+		return x.EffectsProfile
+	}
+	return nil
+}
+
+func (x *SynthesizeRequest) GetChannelName() string {
+	if x != nil {
+		return x.ChannelName
+	}
+	return ""
+}
+
+func (x *SynthesizeRequest) GetPeriodHours() int32 {
+	if x != nil {
+		return x.PeriodHours
+	}
+	return 0
+}
+
+func (x *SynthesizeRequest) GetPlainText() bool {
+	if x != nil {
+		return x.PlainText
+	}
+	return false
+}
+
+func (x *SynthesizeRequest) GetChatId() int64 {
+	if x != nil {
+		return x.ChatId
+	}
+	return 0
+}
+
+// SynthesizeResponse — результат синтеза дайджеста
+type SynthesizeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AudioData     []byte                 `protobuf:"bytes,1,opt,name=audio_data,json=audioData,proto3" json:"audio_data,omitempty"` // итоговый аудиофайл
+	AudioRef      *AudioRef              `protobuf:"bytes,2,opt,name=audio_ref,json=audioRef,proto3" json:"audio_ref,omitempty"`    // ссылка на аудиофайл в хранилище
+	Chapters      []*Chapter             `protobuf:"bytes,3,rep,name=chapters,proto3" json:"chapters,omitempty"`                    // главы аудиофайла
+	Transcript    []*Cue                 `protobuf:"bytes,4,rep,name=transcript,proto3" json:"transcript,omitempty"`                // расшифровка, выровненная по аудио
+	Subtitles     []*AudioRef            `protobuf:"bytes,5,rep,name=subtitles,proto3" json:"subtitles,omitempty"`                  // файлы субтитров (SRT и WebVTT)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SynthesizeResponse) Reset() {
+	*x = SynthesizeResponse{}
+	mi := &file_tts_v1_tts_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SynthesizeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SynthesizeResponse) ProtoMessage() {}
+
+func (x *SynthesizeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tts_v1_tts_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SynthesizeResponse.ProtoReflect.Descriptor instead.
+func (*SynthesizeResponse) Descriptor() ([]byte, []int) {
+	return file_tts_v1_tts_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SynthesizeResponse) GetAudioData() []byte {
+	if x != nil {
+		return x.AudioData
+	}
+	return nil
+}
+
+func (x *SynthesizeResponse) GetAudioRef() *AudioRef {
+	if x != nil {
+		return x.AudioRef
+	}
+	return nil
+}
+
+func (x *SynthesizeResponse) GetChapters() []*Chapter {
+	if x != nil {
+		return x.Chapters
+	}
+	return nil
+}
+
+func (x *SynthesizeResponse) GetTranscript() []*Cue {
+	if x != nil {
+		return x.Transcript
+	}
+	return nil
+}
+
+func (x *SynthesizeResponse) GetSubtitles() []*AudioRef {
+	if x != nil {
+		return x.Subtitles
+	}
+	return nil
+}
+
+// AudioChunk — часть аудио потокового синтеза
+type AudioChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SegmentId     int64                  `protobuf:"varint,1,opt,name=segment_id,json=segmentId,proto3" json:"segment_id,omitempty"`      // идентификатор поста (0 — вступление)
+	Ordinal       int32                  `protobuf:"varint,2,opt,name=ordinal,proto3" json:"ordinal,omitempty"`                           // порядковый номер поста (0 — вступление)
+	Title         string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`                                // название главы
+	Audio         []byte                 `protobuf:"bytes,4,opt,name=audio,proto3" json:"audio,omitempty"`                                // очередная часть аудио
+	ContentType   string                 `protobuf:"bytes,5,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"` // MIME-тип аудио
+	AudioRef      *AudioRef              `protobuf:"bytes,6,opt,name=audio_ref,json=audioRef,proto3" json:"audio_ref,omitempty"`          // ссылка на сохранённый файл (только в последнем сообщении)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AudioChunk) Reset() {
+	*x = AudioChunk{}
+	mi := &file_tts_v1_tts_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AudioChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AudioChunk) ProtoMessage() {}
+
+func (x *AudioChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_tts_v1_tts_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AudioChunk.ProtoReflect.Descriptor instead.
+func (*AudioChunk) Descriptor() ([]byte, []int) {
+	return file_tts_v1_tts_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AudioChunk) GetSegmentId() int64 {
+	if x != nil {
+		return x.SegmentId
+	}
+	return 0
+}
+
+func (x *AudioChunk) GetOrdinal() int32 {
+	if x != nil {
+		return x.Ordinal
+	}
+	return 0
+}
+
+func (x *AudioChunk) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *AudioChunk) GetAudio() []byte {
+	if x != nil {
+		return x.Audio
+	}
+	return nil
+}
+
+func (x *AudioChunk) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *AudioChunk) GetAudioRef() *AudioRef {
+	if x != nil {
+		return x.AudioRef
+	}
+	return nil
+}
+
+// AudioRef — ссылка на файл в хранилище объектов
+type AudioRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`                                    // ключ объекта
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`                                    // адрес для скачивания
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`                                 // размер в байтах
+	Sha256        string                 `protobuf:"bytes,4,opt,name=sha256,proto3" json:"sha256,omitempty"`                              // контрольная сумма SHA-256
+	ContentType   string                 `protobuf:"bytes,5,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"` // MIME-тип
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`       // срок действия ссылки
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AudioRef) Reset() {
+	*x = AudioRef{}
+	mi := &file_tts_v1_tts_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AudioRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AudioRef) ProtoMessage() {}
+
+func (x *AudioRef) ProtoReflect() protoreflect.Message {
+	mi := &file_tts_v1_tts_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AudioRef.ProtoReflect.Descriptor instead.
+func (*AudioRef) Descriptor() ([]byte, []int) {
+	return file_tts_v1_tts_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AudioRef) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *AudioRef) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *AudioRef) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *AudioRef) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *AudioRef) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *AudioRef) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+// Chapter — глава аудиофайла
+type Chapter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`                          // идентификатор поста (0 — вступление)
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`                     // название главы
+	StartMs       int64                  `protobuf:"varint,3,opt,name=start_ms,json=startMs,proto3" json:"start_ms,omitempty"` // начало главы в миллисекундах
+	EndMs         int64                  `protobuf:"varint,4,opt,name=end_ms,json=endMs,proto3" json:"end_ms,omitempty"`       // конец главы в миллисекундах
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Chapter) Reset() {
+	*x = Chapter{}
+	mi := &file_tts_v1_tts_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Chapter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chapter) ProtoMessage() {}
+
+func (x *Chapter) ProtoReflect() protoreflect.Message {
+	mi := &file_tts_v1_tts_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chapter.ProtoReflect.Descriptor instead.
+func (*Chapter) Descriptor() ([]byte, []int) {
+	return file_tts_v1_tts_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Chapter) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Chapter) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Chapter) GetStartMs() int64 {
+	if x != nil {
+		return x.StartMs
+	}
+	return 0
+}
+
+func (x *Chapter) GetEndMs() int64 {
+	if x != nil {
+		return x.EndMs
+	}
+	return 0
+}
+
+// Cue — фраза расшифровки
+type Cue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartMs       int64                  `protobuf:"varint,1,opt,name=start_ms,json=startMs,proto3" json:"start_ms,omitempty"` // начало фразы в миллисекундах
+	EndMs         int64                  `protobuf:"varint,2,opt,name=end_ms,json=endMs,proto3" json:"end_ms,omitempty"`       // конец фразы в миллисекундах
+	Text          string                 `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`                       // текст фразы
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Cue) Reset() {
+	*x = Cue{}
+	mi := &file_tts_v1_tts_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Cue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Cue) ProtoMessage() {}
+
+func (x *Cue) ProtoReflect() protoreflect.Message {
+	mi := &file_tts_v1_tts_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Cue.ProtoReflect.Descriptor instead.
+func (*Cue) Descriptor() ([]byte, []int) {
+	return file_tts_v1_tts_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Cue) GetStartMs() int64 {
+	if x != nil {
+		return x.StartMs
+	}
+	return 0
+}
+
+func (x *Cue) GetEndMs() int64 {
+	if x != nil {
+		return x.EndMs
+	}
+	return 0
+}
+
+func (x *Cue) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+// ListVoicesRequest — запрос списка голосов
+type ListVoicesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LanguageCode  string                 `protobuf:"bytes,1,opt,name=language_code,json=languageCode,proto3" json:"language_code,omitempty"` // код языка для фильтрации (пусто — все голоса)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVoicesRequest) Reset() {
+	*x = ListVoicesRequest{}
+	mi := &file_tts_v1_tts_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVoicesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVoicesRequest) ProtoMessage() {}
+
+func (x *ListVoicesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tts_v1_tts_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVoicesRequest.ProtoReflect.Descriptor instead.
+func (*ListVoicesRequest) Descriptor() ([]byte, []int) {
+	return file_tts_v1_tts_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListVoicesRequest) GetLanguageCode() string {
+	if x != nil {
+		return x.LanguageCode
+	}
+	return ""
+}
+
+// ListVoicesResponse — список голосов
+type ListVoicesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Voices        []*Voice               `protobuf:"bytes,1,rep,name=voices,proto3" json:"voices,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVoicesResponse) Reset() {
+	*x = ListVoicesResponse{}
+	mi := &file_tts_v1_tts_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVoicesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVoicesResponse) ProtoMessage() {}
+
+func (x *ListVoicesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tts_v1_tts_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVoicesResponse.ProtoReflect.Descriptor instead.
+func (*ListVoicesResponse) Descriptor() ([]byte, []int) {
+	return file_tts_v1_tts_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListVoicesResponse) GetVoices() []*Voice {
+	if x != nil {
+		return x.Voices
+	}
+	return nil
+}
+
+// Voice — голос движка синтеза
+type Voice struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	Name                   string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                                                                        // имя голоса
+	LanguageCodes          []string               `protobuf:"bytes,2,rep,name=language_codes,json=languageCodes,proto3" json:"language_codes,omitempty"`                                 // поддерживаемые языки
+	SsmlGender             string                 `protobuf:"bytes,3,opt,name=ssml_gender,json=ssmlGender,proto3" json:"ssml_gender,omitempty"`                                          // пол голоса
+	NaturalSampleRateHertz int64                  `protobuf:"varint,4,opt,name=natural_sample_rate_hertz,json=naturalSampleRateHertz,proto3" json:"natural_sample_rate_hertz,omitempty"` // естественная частота дискретизации
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *Voice) Reset() {
+	*x = Voice{}
+	mi := &file_tts_v1_tts_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Voice) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Voice) ProtoMessage() {}
+
+func (x *Voice) ProtoReflect() protoreflect.Message {
+	mi := &file_tts_v1_tts_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Voice.ProtoReflect.Descriptor instead.
+func (*Voice) Descriptor() ([]byte, []int) {
+	return file_tts_v1_tts_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Voice) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Voice) GetLanguageCodes() []string {
+	if x != nil {
+		return x.LanguageCodes
+	}
+	return nil
+}
+
+func (x *Voice) GetSsmlGender() string {
+	if x != nil {
+		return x.SsmlGender
+	}
+	return ""
+}
+
+func (x *Voice) GetNaturalSampleRateHertz() int64 {
+	if x != nil {
+		return x.NaturalSampleRateHertz
+	}
+	return 0
+}
+
+var File_tts_v1_tts_proto protoreflect.FileDescriptor
+
+const file_tts_v1_tts_proto_rawDesc = "" +
+	"\n" +
+	"\x10tts/v1/tts.proto\x12\x06tts.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x99\x04\n" +
+	"\x11SynthesizeRequest\x127\n" +
+	"\x04text\x18\x01 \x03(\v2#.tts.v1.SynthesizeRequest.TextEntryR\x04text\x12#\n" +
+	"\rspeaking_rate\x18\x02 \x01(\x01R\fspeakingRate\x12%\n" +
+	"\x0eaudio_encoding\x18\x03 \x01(\tR\raudioEncoding\x12\x1d\n" +
+	"\n" +
+	"voice_name\x18\x04 \x01(\tR\tvoiceName\x12#\n" +
+	"\rlanguage_code\x18\x05 \x01(\tR\flanguageCode\x12\x1f\n" +
+	"\vssml_gender\x18\x06 \x01(\tR\n" +
+	"ssmlGender\x12\x14\n" +
+	"\x05pitch\x18\a \x01(\x01R\x05pitch\x12$\n" +
+	"\x0evolume_gain_db\x18\b \x01(\x01R\fvolumeGainDb\x12'\n" +
+	"\x0feffects_profile\x18\t \x03(\tR\x0eeffectsProfile\x12!\n" +
+	"\fchannel_name\x18\n" +
+	" \x01(\tR\vchannelName\x12!\n" +
+	"\fperiod_hours\x18\v \x01(\x05R\vperiodHours\x12\x1d\n" +
+	"\n" +
+	"plain_text\x18\f \x01(\bR\tplainText\x12\x17\n" +
+	"\achat_id\x18\r \x01(\x03R\x06chatId\x1a7\n" +
+	"\tTextEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x03R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xec\x01\n" +
+	"\x12SynthesizeResponse\x12\x1d\n" +
+	"\n" +
+	"audio_data\x18\x01 \x01(\fR\taudioData\x12-\n" +
+	"\taudio_ref\x18\x02 \x01(\v2\x10.tts.v1.AudioRefR\baudioRef\x12+\n" +
+	"\bchapters\x18\x03 \x03(\v2\x0f.tts.v1.ChapterR\bchapters\x12+\n" +
+	"\n" +
+	"transcript\x18\x04 \x03(\v2\v.tts.v1.CueR\n" +
+	"transcript\x12.\n" +
+	"\tsubtitles\x18\x05 \x03(\v2\x10.tts.v1.AudioRefR\tsubtitles\"\xc3\x01\n" +
+	"\n" +
+	"AudioChunk\x12\x1d\n" +
+	"\n" +
+	"segment_id\x18\x01 \x01(\x03R\tsegmentId\x12\x18\n" +
+	"\aordinal\x18\x02 \x01(\x05R\aordinal\x12\x14\n" +
+	"\x05title\x18\x03 \x01(\tR\x05title\x12\x14\n" +
+	"\x05audio\x18\x04 \x01(\fR\x05audio\x12!\n" +
+	"\fcontent_type\x18\x05 \x01(\tR\vcontentType\x12-\n" +
+	"\taudio_ref\x18\x06 \x01(\v2\x10.tts.v1.AudioRefR\baudioRef\"\xb8\x01\n" +
+	"\bAudioRef\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x16\n" +
+	"\x06sha256\x18\x04 \x01(\tR\x06sha256\x12!\n" +
+	"\fcontent_type\x18\x05 \x01(\tR\vcontentType\x129\n" +
+	"\n" +
+	"expires_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"a\n" +
+	"\aChapter\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x19\n" +
+	"\bstart_ms\x18\x03 \x01(\x03R\astartMs\x12\x15\n" +
+	"\x06end_ms\x18\x04 \x01(\x03R\x05endMs\"K\n" +
+	"\x03Cue\x12\x19\n" +
+	"\bstart_ms\x18\x01 \x01(\x03R\astartMs\x12\x15\n" +
+	"\x06end_ms\x18\x02 \x01(\x03R\x05endMs\x12\x12\n" +
+	"\x04text\x18\x03 \x01(\tR\x04text\"8\n" +
+	"\x11ListVoicesRequest\x12#\n" +
+	"\rlanguage_code\x18\x01 \x01(\tR\flanguageCode\";\n" +
+	"\x12ListVoicesResponse\x12%\n" +
+	"\x06voices\x18\x01 \x03(\v2\r.tts.v1.VoiceR\x06voices\"\x9e\x01\n" +
+	"\x05Voice\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12%\n" +
+	"\x0elanguage_codes\x18\x02 \x03(\tR\rlanguageCodes\x12\x1f\n" +
+	"\vssml_gender\x18\x03 \x01(\tR\n" +
+	"ssmlGender\x129\n" +
+	"\x19natural_sample_rate_hertz\x18\x04 \x01(\x03R\x16naturalSampleRateHertz2\xe4\x01\n" +
+	"\x13TextToSpeechService\x12C\n" +
+	"\n" +
+	"Synthesize\x12\x19.tts.v1.SynthesizeRequest\x1a\x1a.tts.v1.SynthesizeResponse\x12C\n" +
+	"\x10SynthesizeStream\x12\x19.tts.v1.SynthesizeRequest\x1a\x12.tts.v1.AudioChunk0\x01\x12C\n" +
+	"\n" +
+	"ListVoices\x12\x19.tts.v1.ListVoicesRequest\x1a\x1a.tts.v1.ListVoicesResponseB-Z+text_to_speech_app/internal/gen/ttsv1;ttsv1b\x06proto3"
+
+var (
+	file_tts_v1_tts_proto_rawDescOnce sync.Once
+	file_tts_v1_tts_proto_rawDescData []byte
+)
+
+func file_tts_v1_tts_proto_rawDescGZIP() []byte {
+	file_tts_v1_tts_proto_rawDescOnce.Do(func() {
+		file_tts_v1_tts_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_tts_v1_tts_proto_rawDesc), len(file_tts_v1_tts_proto_rawDesc)))
+	})
+	return file_tts_v1_tts_proto_rawDescData
+}
+
+var file_tts_v1_tts_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_tts_v1_tts_proto_goTypes = []any{
+	(*SynthesizeRequest)(nil),     // 0: tts.v1.SynthesizeRequest
+	(*SynthesizeResponse)(nil),    // 1: tts.v1.SynthesizeResponse
+	(*AudioChunk)(nil),            // 2: tts.v1.AudioChunk
+	(*AudioRef)(nil),              // 3: tts.v1.AudioRef
+	(*Chapter)(nil),               // 4: tts.v1.Chapter
+	(*Cue)(nil),                   // 5: tts.v1.Cue
+	(*ListVoicesRequest)(nil),     // 6: tts.v1.ListVoicesRequest
+	(*ListVoicesResponse)(nil),    // 7: tts.v1.ListVoicesResponse
+	(*Voice)(nil),                 // 8: tts.v1.Voice
+	nil,                           // 9: tts.v1.SynthesizeRequest.TextEntry
+	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
+}
+var file_tts_v1_tts_proto_depIdxs = []int32{
+	9,  // 0: tts.v1.SynthesizeRequest.text:type_name -> tts.v1.SynthesizeRequest.TextEntry
+	3,  // 1: tts.v1.SynthesizeResponse.audio_ref:type_name -> tts.v1.AudioRef
+	4,  // 2: tts.v1.SynthesizeResponse.chapters:type_name -> tts.v1.Chapter
+	5,  // 3: tts.v1.SynthesizeResponse.transcript:type_name -> tts.v1.Cue
+	3,  // 4: tts.v1.SynthesizeResponse.subtitles:type_name -> tts.v1.AudioRef
+	3,  // 5: tts.v1.AudioChunk.audio_ref:type_name -> tts.v1.AudioRef
+	10, // 6: tts.v1.AudioRef.expires_at:type_name -> google.protobuf.Timestamp
+	8,  // 7: tts.v1.ListVoicesResponse.voices:type_name -> tts.v1.Voice
+	0,  // 8: tts.v1.TextToSpeechService.Synthesize:input_type -> tts.v1.SynthesizeRequest
+	0,  // 9: tts.v1.TextToSpeechService.SynthesizeStream:input_type -> tts.v1.SynthesizeRequest
+	6,  // 10: tts.v1.TextToSpeechService.ListVoices:input_type -> tts.v1.ListVoicesRequest
+	1,  // 11: tts.v1.TextToSpeechService.Synthesize:output_type -> tts.v1.SynthesizeResponse
+	2,  // 12: tts.v1.TextToSpeechService.SynthesizeStream:output_type -> tts.v1.AudioChunk
+	7,  // 13: tts.v1.TextToSpeechService.ListVoices:output_type -> tts.v1.ListVoicesResponse
+	11, // [11:14] is the sub-list for method output_type
+	8,  // [8:11] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_tts_v1_tts_proto_init() }
+func file_tts_v1_tts_proto_init() {
+	if File_tts_v1_tts_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_tts_v1_tts_proto_rawDesc), len(file_tts_v1_tts_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_tts_v1_tts_proto_goTypes,
+		DependencyIndexes: file_tts_v1_tts_proto_depIdxs,
+		MessageInfos:      file_tts_v1_tts_proto_msgTypes,
+	}.Build()
+	File_tts_v1_tts_proto = out.File
+	file_tts_v1_tts_proto_goTypes = nil
+	file_tts_v1_tts_proto_depIdxs = nil
+}
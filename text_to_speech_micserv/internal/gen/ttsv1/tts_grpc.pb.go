@@ -0,0 +1,216 @@
+// Файл tts.proto описывает gRPC API микросервиса Text-to-Speech.
+// Go-код генерируется командой `buf generate` в директорию internal/gen/ttsv1.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: tts/v1/tts.proto
+
+package ttsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TextToSpeechService_Synthesize_FullMethodName       = "/tts.v1.TextToSpeechService/Synthesize"
+	TextToSpeechService_SynthesizeStream_FullMethodName = "/tts.v1.TextToSpeechService/SynthesizeStream"
+	TextToSpeechService_ListVoices_FullMethodName       = "/tts.v1.TextToSpeechService/ListVoices"
+)
+
+// TextToSpeechServiceClient is the client API for TextToSpeechService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// TextToSpeechService синтезирует аудиодайджесты постов Telegram-каналов
+type TextToSpeechServiceClient interface {
+	// Synthesize синтезирует дайджест целиком и возвращает аудио, ссылку на него, главы и расшифровку
+	Synthesize(ctx context.Context, in *SynthesizeRequest, opts ...grpc.CallOption) (*SynthesizeResponse, error)
+	// SynthesizeStream отдаёт аудио частями по мере синтеза сегментов;
+	// последнее сообщение потока содержит ссылку на сохранённый файл
+	SynthesizeStream(ctx context.Context, in *SynthesizeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AudioChunk], error)
+	// ListVoices возвращает голоса движка синтеза
+	ListVoices(ctx context.Context, in *ListVoicesRequest, opts ...grpc.CallOption) (*ListVoicesResponse, error)
+}
+
+type textToSpeechServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTextToSpeechServiceClient(cc grpc.ClientConnInterface) TextToSpeechServiceClient {
+	return &textToSpeechServiceClient{cc}
+}
+
+func (c *textToSpeechServiceClient) Synthesize(ctx context.Context, in *SynthesizeRequest, opts ...grpc.CallOption) (*SynthesizeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SynthesizeResponse)
+	err := c.cc.Invoke(ctx, TextToSpeechService_Synthesize_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *textToSpeechServiceClient) SynthesizeStream(ctx context.Context, in *SynthesizeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AudioChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TextToSpeechService_ServiceDesc.Streams[0], TextToSpeechService_SynthesizeStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SynthesizeRequest, AudioChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TextToSpeechService_SynthesizeStreamClient = grpc.ServerStreamingClient[AudioChunk]
+
+func (c *textToSpeechServiceClient) ListVoices(ctx context.Context, in *ListVoicesRequest, opts ...grpc.CallOption) (*ListVoicesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListVoicesResponse)
+	err := c.cc.Invoke(ctx, TextToSpeechService_ListVoices_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TextToSpeechServiceServer is the server API for TextToSpeechService service.
+// All implementations must embed UnimplementedTextToSpeechServiceServer
+// for forward compatibility.
+//
+// TextToSpeechService синтезирует аудиодайджесты постов Telegram-каналов
+type TextToSpeechServiceServer interface {
+	// Synthesize синтезирует дайджест целиком и возвращает аудио, ссылку на него, главы и расшифровку
+	Synthesize(context.Context, *SynthesizeRequest) (*SynthesizeResponse, error)
+	// SynthesizeStream отдаёт аудио частями по мере синтеза сегментов;
+	// последнее сообщение потока содержит ссылку на сохранённый файл
+	SynthesizeStream(*SynthesizeRequest, grpc.ServerStreamingServer[AudioChunk]) error
+	// ListVoices возвращает голоса движка синтеза
+	ListVoices(context.Context, *ListVoicesRequest) (*ListVoicesResponse, error)
+	mustEmbedUnimplementedTextToSpeechServiceServer()
+}
+
+// UnimplementedTextToSpeechServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTextToSpeechServiceServer struct{}
+
+func (UnimplementedTextToSpeechServiceServer) Synthesize(context.Context, *SynthesizeRequest) (*SynthesizeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Synthesize not implemented")
+}
+func (UnimplementedTextToSpeechServiceServer) SynthesizeStream(*SynthesizeRequest, grpc.ServerStreamingServer[AudioChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method SynthesizeStream not implemented")
+}
+func (UnimplementedTextToSpeechServiceServer) ListVoices(context.Context, *ListVoicesRequest) (*ListVoicesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVoices not implemented")
+}
+func (UnimplementedTextToSpeechServiceServer) mustEmbedUnimplementedTextToSpeechServiceServer() {}
+func (UnimplementedTextToSpeechServiceServer) testEmbeddedByValue()                             {}
+
+// UnsafeTextToSpeechServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TextToSpeechServiceServer will
+// result in compilation errors.
+type UnsafeTextToSpeechServiceServer interface {
+	mustEmbedUnimplementedTextToSpeechServiceServer()
+}
+
+func RegisterTextToSpeechServiceServer(s grpc.ServiceRegistrar, srv TextToSpeechServiceServer) {
+	// If the following call pancis, it indicates UnimplementedTextToSpeechServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TextToSpeechService_ServiceDesc, srv)
+}
+
+func _TextToSpeechService_Synthesize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SynthesizeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TextToSpeechServiceServer).Synthesize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TextToSpeechService_Synthesize_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TextToSpeechServiceServer).Synthesize(ctx, req.(*SynthesizeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TextToSpeechService_SynthesizeStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SynthesizeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TextToSpeechServiceServer).SynthesizeStream(m, &grpc.GenericServerStream[SynthesizeRequest, AudioChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TextToSpeechService_SynthesizeStreamServer = grpc.ServerStreamingServer[AudioChunk]
+
+func _TextToSpeechService_ListVoices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListVoicesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TextToSpeechServiceServer).ListVoices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TextToSpeechService_ListVoices_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TextToSpeechServiceServer).ListVoices(ctx, req.(*ListVoicesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TextToSpeechService_ServiceDesc is the grpc.ServiceDesc for TextToSpeechService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TextToSpeechService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "tts.v1.TextToSpeechService",
+	HandlerType: (*TextToSpeechServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Synthesize",
+			Handler:    _TextToSpeechService_Synthesize_Handler,
+		},
+		{
+			MethodName: "ListVoices",
+			Handler:    _TextToSpeechService_ListVoices_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SynthesizeStream",
+			Handler:       _TextToSpeechService_SynthesizeStream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "tts/v1/tts.proto",
+}
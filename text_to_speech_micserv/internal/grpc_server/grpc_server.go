@@ -0,0 +1,241 @@
+// Файл grpc_server.go реализует gRPC-сервер микросервиса Text-to-Speech.
+// Сервер работает рядом с HTTP-сервером на отдельном порту, преобразует сообщения protobuf
+// в модели сервиса, ограничивает время обработки дедлайнами и переводит ошибки сервиса в коды gRPC.
+
+package grpc_server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"text_to_speech_app/internal/app_text_to_speech"
+	"text_to_speech_app/internal/blob_storage"
+	"text_to_speech_app/internal/gen/ttsv1"
+	"text_to_speech_app/internal/model/model_text_to_speech"
+	"text_to_speech_app/tools/logger"
+)
+
+// chunkSize — максимальный размер части аудио в одном сообщении потока
+const chunkSize = 64 << 10
+
+// Server представляет gRPC-сервер
+type Server struct {
+	ttsv1.UnimplementedTextToSpeechServiceServer
+
+	port       string                      // Адрес, на котором слушает сервер
+	srv        *grpc.Server                // Объект gRPC-сервера
+	ttsService *app_text_to_speech.Service // Указатель на сервис Text-to-Speech
+}
+
+// NewServer создаёт gRPC-сервер. Если клиент не указал дедлайн или указал больший,
+// время обработки запроса ограничивается значением timeout.
+func NewServer(port string, timeout time.Duration, ttsService *app_text_to_speech.Service) *Server {
+	s := &Server{
+		port:       port,
+		ttsService: ttsService,
+	}
+	s.srv = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryDeadline(timeout)),
+		grpc.ChainStreamInterceptor(streamDeadline(timeout)),
+	)
+	ttsv1.RegisterTextToSpeechServiceServer(s.srv, s)
+	// Reflection позволяет отлаживать API через grpcurl и аналогичные инструменты
+	reflection.Register(s.srv)
+	return s
+}
+
+// ListenAndServe запускает gRPC-сервер
+func (s *Server) ListenAndServe() error {
+	listener, err := net.Listen("tcp", s.port)
+	if err != nil {
+		return err
+	}
+	return s.srv.Serve(listener)
+}
+
+// Shutdown останавливает сервер, дожидаясь завершения активных запросов до отмены контекста
+func (s *Server) Shutdown(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.srv.Stop()
+	}
+}
+
+// Synthesize синтезирует дайджест целиком
+func (s *Server) Synthesize(ctx context.Context, in *ttsv1.SynthesizeRequest) (*ttsv1.SynthesizeResponse, error) {
+	const lblSynthesize = "text_to_speech_micserv/internal/grpc_server/grpc_server.go → Synthesize()"
+	myLogger := logger.NewColorLogger(lblSynthesize)
+
+	resp, err := s.ttsService.SynthesizeDigest(ctx, fromProtoRequest(in))
+	if err != nil {
+		myLogger.Error("Ошибка синтеза речи", slog.Any("error", err))
+		return nil, statusFromError(err)
+	}
+
+	out := &ttsv1.SynthesizeResponse{
+		AudioData: resp.AudioData,
+		AudioRef:  toProtoRef(resp.AudioRef),
+	}
+	for _, chapter := range resp.Chapters {
+		out.Chapters = append(out.Chapters, &ttsv1.Chapter{Id: chapter.ID, Title: chapter.Title, StartMs: chapter.StartMs, EndMs: chapter.EndMs})
+	}
+	for _, cue := range resp.Transcript {
+		out.Transcript = append(out.Transcript, &ttsv1.Cue{StartMs: cue.StartMs, EndMs: cue.EndMs, Text: cue.Text})
+	}
+	for _, ref := range resp.Subtitles {
+		out.Subtitles = append(out.Subtitles, toProtoRef(ref))
+	}
+	myLogger.Info("Успешно синтезировали дайджест", slog.Int("size", len(resp.AudioData)))
+	return out, nil
+}
+
+// SynthesizeStream отдаёт аудио частями по мере синтеза сегментов
+func (s *Server) SynthesizeStream(in *ttsv1.SynthesizeRequest, stream grpc.ServerStreamingServer[ttsv1.AudioChunk]) error {
+	const lblSynthesizeStream = "text_to_speech_micserv/internal/grpc_server/grpc_server.go → SynthesizeStream()"
+	myLogger := logger.NewColorLogger(lblSynthesizeStream)
+
+	req := fromProtoRequest(in)
+	format, err := app_text_to_speech.ResolveAudioFormat(req.AudioEncoding)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ref, err := s.ttsService.SynthesizeStream(stream.Context(), req, func(seg app_text_to_speech.Segment) error {
+		for offset := 0; offset < len(seg.Audio); offset += chunkSize {
+			chunk := &ttsv1.AudioChunk{
+				SegmentId:   seg.ID,
+				Ordinal:     int32(seg.Ordinal),
+				Title:       seg.Title,
+				Audio:       seg.Audio[offset:min(offset+chunkSize, len(seg.Audio))],
+				ContentType: format.ContentType,
+			}
+			if err := stream.Send(chunk); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		myLogger.Error("Ошибка потокового синтеза", slog.Any("error", err))
+		return statusFromError(err)
+	}
+
+	// Последнее сообщение несёт ссылку на сохранённый файл — те же байты, что переданы частями
+	if err := stream.Send(&ttsv1.AudioChunk{ContentType: format.ContentType, AudioRef: toProtoRef(ref)}); err != nil {
+		return err
+	}
+	myLogger.Info("Успешно завершили потоковый синтез", slog.String("key", ref.Key))
+	return nil
+}
+
+// ListVoices возвращает голоса движка синтеза
+func (s *Server) ListVoices(ctx context.Context, in *ttsv1.ListVoicesRequest) (*ttsv1.ListVoicesResponse, error) {
+	voices, err := s.ttsService.ListVoices(ctx, in.GetLanguageCode())
+	if err != nil {
+		return nil, statusFromError(err)
+	}
+	out := &ttsv1.ListVoicesResponse{}
+	for _, v := range voices {
+		out.Voices = append(out.Voices, &ttsv1.Voice{
+			Name:                   v.Name,
+			LanguageCodes:          v.LanguageCodes,
+			SsmlGender:             v.SsmlGender,
+			NaturalSampleRateHertz: v.NaturalSampleRateHertz,
+		})
+	}
+	return out, nil
+}
+
+// statusFromError переводит ошибку сервиса в статус gRPC
+func statusFromError(err error) error {
+	switch {
+	case errors.Is(err, app_text_to_speech.ErrInvalidRequest):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, blob_storage.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// unaryDeadline ограничивает время обработки унарного запроса
+func unaryDeadline(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+// streamDeadline ограничивает время обработки потокового запроса
+func streamDeadline(timeout time.Duration) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := context.WithTimeout(ss.Context(), timeout)
+		defer cancel()
+		return handler(srv, &deadlineStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// deadlineStream подменяет контекст потока контекстом с дедлайном
+type deadlineStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context возвращает контекст потока с дедлайном
+func (s *deadlineStream) Context() context.Context {
+	return s.ctx
+}
+
+// fromProtoRequest преобразует запрос protobuf в модель сервиса
+func fromProtoRequest(in *ttsv1.SynthesizeRequest) *model_text_to_speech.TextToSpeechRequest {
+	return &model_text_to_speech.TextToSpeechRequest{
+		Text:           in.GetText(),
+		SpeakingRate:   in.GetSpeakingRate(),
+		AudioEncoding:  in.GetAudioEncoding(),
+		VoiceName:      in.GetVoiceName(),
+		LanguageCode:   in.GetLanguageCode(),
+		SsmlGender:     in.GetSsmlGender(),
+		Pitch:          in.GetPitch(),
+		VolumeGainDb:   in.GetVolumeGainDb(),
+		EffectsProfile: in.GetEffectsProfile(),
+		ChannelName:    in.GetChannelName(),
+		PeriodHours:    int(in.GetPeriodHours()),
+		PlainText:      in.GetPlainText(),
+		ChatID:         in.GetChatId(),
+	}
+}
+
+// toProtoRef преобразует ссылку на файл в сообщение protobuf
+func toProtoRef(ref *model_text_to_speech.AudioRef) *ttsv1.AudioRef {
+	if ref == nil {
+		return nil
+	}
+	return &ttsv1.AudioRef{
+		Key:         ref.Key,
+		Url:         ref.URL,
+		Size:        ref.Size,
+		Sha256:      ref.SHA256,
+		ContentType: ref.ContentType,
+		ExpiresAt:   timestamppb.New(ref.ExpiresAt),
+	}
+}
@@ -65,8 +65,9 @@ func (c *Consumer) Consume(ctx context.Context, ttsService *app_text_to_speech.S
 		}
 		myLogger.Info("Успешно обработали запрос")
 
-		// Сериализуем ответ
-		respData, err := json.Marshal(resp)
+		// Сериализуем ответ: аудио передаётся ссылкой на объект в хранилище. Ответы на запросы из Kafka
+		// публикуются только здесь — синтез через HTTP и gRPC в топик ответов не пишет
+		respData, err := app_text_to_speech.MarshalForKafka(resp)
 		if err != nil {
 			myLogger.Error("Ошибка сериализации ответа", slog.Any("error", err))
 			continue
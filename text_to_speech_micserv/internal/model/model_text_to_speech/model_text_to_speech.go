@@ -1,13 +1,76 @@
 package model_text_to_speech
 
+import "time"
+
 // TextToSpeechRequest представляет запрос на преобразование текста в речь
 type TextToSpeechRequest struct {
-	Text         map[int64]string `json:"text"`          // текст для синтеза речи
-	SpeakingRate float64          `json:"speaking_rate"` // скорость речи (например, 1.0 — стандартная)
+	Text           map[int64]string `json:"text"`                      // текст для синтеза речи по unix-времени публикации
+	Posts          []Post           `json:"posts,omitempty"`           // посты дайджеста по порядку озвучивания; если заданы, Text не используется
+	SpeakingRate   float64          `json:"speaking_rate"`             // скорость речи (например, 1.0 — стандартная)
+	AudioEncoding  string           `json:"audio_encoding,omitempty"`  // формат аудио: MP3 (по умолчанию) или OGG_OPUS
+	VoiceName      string           `json:"voice_name,omitempty"`      // имя голоса, например ru-RU-Wavenet-A
+	LanguageCode   string           `json:"language_code,omitempty"`   // код языка, например ru-RU
+	SsmlGender     string           `json:"ssml_gender,omitempty"`     // пол голоса: MALE, FEMALE или NEUTRAL
+	Pitch          float64          `json:"pitch,omitempty"`           // высота голоса в полутонах, от -20 до 20
+	VolumeGainDb   float64          `json:"volume_gain_db,omitempty"`  // усиление громкости в дБ, от -96 до 16
+	EffectsProfile []string         `json:"effects_profile,omitempty"` // профили аудиоэффектов, например headphone-class-device
+	ChannelName    string           `json:"channel_name,omitempty"`    // имя канала; если указано, дайджест начинается со вступления
+	PeriodHours    int              `json:"period_hours,omitempty"`    // период дайджеста в часах (для вступления)
+	PlainText      bool             `json:"plain_text,omitempty"`      // озвучивать простым текстом, без SSML
+	ChatID         int64            `json:"chat_id,omitempty"`         // чат пользователя Telegram (для его подкаст-ленты)
+	RequestID      string           `json:"request_id,omitempty"`      // идентификатор запроса бота, возвращается в ответе
+}
+
+// Post пост дайджеста от сервиса парсинга Telegram; пост однозначно определяют канал и идентификатор
+type Post struct {
+	Channel   string `json:"channel,omitempty"` // канал, из которого получен пост
+	ID        int    `json:"id"`                // идентификатор поста в канале
+	Timestamp int64  `json:"timestamp"`         // unix-время публикации поста
+	Text      string `json:"text"`              // очищенный текст поста для озвучивания
+}
+
+// Voice описывает голос, доступный в движке синтеза речи
+type Voice struct {
+	Name                   string   `json:"name"`                      // имя голоса
+	LanguageCodes          []string `json:"language_codes"`            // поддерживаемые языки
+	SsmlGender             string   `json:"ssml_gender"`               // пол голоса
+	NaturalSampleRateHertz int64    `json:"natural_sample_rate_hertz"` // естественная частота дискретизации
 }
 
 // TextToSpeechResponse представляет ответ с синтезированным аудио
 type TextToSpeechResponse struct {
-	AudioData []byte `json:"audio_data"` // аудиоданные в формате MP3
-	Error     string `json:"error,omitempty"`
+	ChatID     int64       `json:"chat_id,omitempty"`    // чат пользователя Telegram из запроса — кому доставить аудио
+	RequestID  string      `json:"request_id,omitempty"` // идентификатор запроса бота из запроса
+	AudioData  []byte      `json:"audio_data,omitempty"` // аудиоданные в формате MP3 или Ogg Opus (не передаются через Kafka)
+	AudioRef   *AudioRef   `json:"audio_ref,omitempty"`  // ссылка на аудиофайл в хранилище
+	Chapters   []Chapter   `json:"chapters,omitempty"`   // главы аудиофайла: вступление и посты
+	Transcript []Cue       `json:"transcript,omitempty"` // расшифровка, выровненная по аудио
+	Subtitles  []*AudioRef `json:"subtitles,omitempty"`  // ссылки на файлы субтитров (SRT и WebVTT) в хранилище
+	Error      string      `json:"error,omitempty"`
+}
+
+// Cue описывает фразу расшифровки с временем её звучания
+type Cue struct {
+	StartMs int64  `json:"start_ms"` // начало фразы в миллисекундах от начала файла
+	EndMs   int64  `json:"end_ms"`   // конец фразы в миллисекундах от начала файла
+	Text    string `json:"text"`     // текст фразы
+}
+
+// Chapter описывает главу итогового аудиофайла
+type Chapter struct {
+	ID      int64  `json:"id"`       // идентификатор поста (0 — вступление)
+	Title   string `json:"title"`    // название главы, например «12:30 — первые слова поста»
+	StartMs int64  `json:"start_ms"` // начало главы в миллисекундах от начала файла
+	EndMs   int64  `json:"end_ms"`   // конец главы в миллисекундах от начала файла
+}
+
+// AudioRef представляет ссылку на аудиофайл (или сопутствующий файл, например субтитры),
+// загруженный в хранилище объектов
+type AudioRef struct {
+	Key         string    `json:"key"`          // ключ объекта в хранилище
+	URL         string    `json:"url"`          // адрес для скачивания объекта
+	Size        int64     `json:"size"`         // размер объекта в байтах
+	SHA256      string    `json:"sha256"`       // контрольная сумма SHA-256 в шестнадцатеричном виде
+	ContentType string    `json:"content_type"` // MIME-тип объекта
+	ExpiresAt   time.Time `json:"expires_at"`   // момент, после которого ссылка недействительна
 }
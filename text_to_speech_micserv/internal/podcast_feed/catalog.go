@@ -0,0 +1,143 @@
+// Файл catalog.go реализует каталог сгенерированных дайджестов для подкаст-лент.
+// Каталог хранится в JSON-файле; записи старше срока хранения аудиофайлов удаляются,
+// чтобы лента не ссылалась на уже удалённое аудио.
+
+package podcast_feed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Episode описывает выпуск подкаста — один сгенерированный дайджест
+type Episode struct {
+	GUID        string        `json:"guid"`         // Уникальный идентификатор выпуска (см. GUID)
+	ChatID      int64         `json:"chat_id"`      // Чат пользователя, запросившего дайджест
+	Channel     string        `json:"channel"`      // Канал, по которому составлен дайджест
+	Title       string        `json:"title"`        // Название выпуска
+	Description string        `json:"description"`  // Описание выпуска (список глав)
+	AudioKey    string        `json:"audio_key"`    // Ключ аудиофайла в хранилище
+	ContentType string        `json:"content_type"` // MIME-тип аудиофайла
+	Size        int64         `json:"size"`         // Размер аудиофайла в байтах
+	Duration    time.Duration `json:"duration"`     // Длительность аудио
+	PublishedAt time.Time     `json:"published_at"` // Время публикации выпуска
+}
+
+// Catalog хранит выпуски подкастов в JSON-файле
+type Catalog struct {
+	file      string        // Путь к файлу каталога
+	retention time.Duration // Срок хранения выпусков (0 — без ограничения)
+	mutex     sync.Mutex    // Защищает episodes и файл каталога
+	episodes  []Episode     // Выпуски в порядке добавления
+}
+
+// NewCatalog открывает каталог в указанном файле; если файла нет, каталог создаётся пустым
+func NewCatalog(file string, retention time.Duration) (*Catalog, error) {
+	c := &Catalog{file: file, retention: retention}
+
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать каталог подкастов %s: %w", file, err)
+	}
+	if err := json.Unmarshal(data, &c.episodes); err != nil {
+		return nil, fmt.Errorf("некорректный каталог подкастов %s: %w", file, err)
+	}
+	return c, nil
+}
+
+// GUID возвращает идентификатор выпуска. Ключ аудио зависит только от содержимого, и одинаковое аудио
+// может достаться разным пользователям и каналам, поэтому в идентификатор входят также чат и канал
+func GUID(chatID int64, channel, audioKey string) string {
+	return strconv.FormatInt(chatID, 10) + "/" + NormalizeChannel(channel) + "/" + audioKey
+}
+
+// Add добавляет выпуск в каталог; выпуск с тем же GUID заменяется
+func (c *Catalog) Add(episode Episode) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.episodes = slices.DeleteFunc(c.episodes, func(e Episode) bool { return e.GUID == episode.GUID })
+	c.episodes = append(c.episodes, episode)
+	c.prune()
+	return c.save()
+}
+
+// ByChat возвращает выпуски пользователя, новые первыми
+func (c *Catalog) ByChat(chatID int64) []Episode {
+	return c.filter(func(e Episode) bool { return e.ChatID == chatID })
+}
+
+// ByChannel возвращает выпуски канала, новые первыми; одинаковое аудио, запрошенное
+// несколькими пользователями, попадает в ленту канала один раз
+func (c *Catalog) ByChannel(channel string) []Episode {
+	channel = NormalizeChannel(channel)
+	seen := make(map[string]bool)
+	return slices.DeleteFunc(c.filter(func(e Episode) bool { return NormalizeChannel(e.Channel) == channel }), func(e Episode) bool {
+		duplicate := seen[e.AudioKey]
+		seen[e.AudioKey] = true
+		return duplicate
+	})
+}
+
+// NormalizeChannel приводит имя канала к единому виду: без @ и в нижнем регистре
+func NormalizeChannel(channel string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(channel), "@"))
+}
+
+// filter возвращает актуальные выпуски, удовлетворяющие условию, в порядке убывания даты
+func (c *Catalog) filter(match func(e Episode) bool) []Episode {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var result []Episode
+	for _, e := range c.episodes {
+		if match(e) && !c.expired(e) {
+			result = append(result, e)
+		}
+	}
+	slices.SortFunc(result, func(a, b Episode) int { return b.PublishedAt.Compare(a.PublishedAt) })
+	return result
+}
+
+// prune удаляет из каталога выпуски с истёкшим сроком хранения
+func (c *Catalog) prune() {
+	c.episodes = slices.DeleteFunc(c.episodes, c.expired)
+}
+
+// expired сообщает, истёк ли срок хранения выпуска
+func (c *Catalog) expired(e Episode) bool {
+	return c.retention > 0 && time.Since(e.PublishedAt) > c.retention
+}
+
+// save атомарно записывает каталог в файл: сначала во временный файл, затем переименование
+func (c *Catalog) save() error {
+	data, err := json.MarshalIndent(c.episodes, "", "  ")
+	if err != nil {
+		return fmt.Errorf("не удалось сериализовать каталог подкастов: %w", err)
+	}
+	if dir := filepath.Dir(c.file); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("не удалось создать директорию каталога подкастов: %w", err)
+		}
+	}
+	tmp := c.file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("не удалось записать каталог подкастов: %w", err)
+	}
+	if err := os.Rename(tmp, c.file); err != nil {
+		return fmt.Errorf("не удалось сохранить каталог подкастов: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package podcast_feed
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// episode возвращает выпуск чата и канала с аудио по ключу key
+func episode(chatID int64, channel, key string, published time.Time) Episode {
+	return Episode{
+		GUID:        GUID(chatID, channel, key),
+		ChatID:      chatID,
+		Channel:     channel,
+		AudioKey:    key,
+		PublishedAt: published,
+	}
+}
+
+func TestCatalogAdd(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "catalog.json")
+	catalog, err := NewCatalog(file, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	// Одинаковое аудио у двух пользователей не должно вытеснять выпуск другого пользователя
+	for _, e := range []Episode{
+		episode(1, "@news", "digests/a.mp3", now.Add(-2*time.Hour)),
+		episode(2, "news", "digests/a.mp3", now.Add(-time.Hour)),
+		episode(1, "", "digests/b.mp3", now),
+	} {
+		if err := catalog.Add(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := catalog.ByChat(1); len(got) != 2 || got[0].AudioKey != "digests/b.mp3" {
+		t.Fatalf("ожидали 2 выпуска чата 1, новый первым, получили %+v", got)
+	}
+	if got := catalog.ByChat(2); len(got) != 1 {
+		t.Fatalf("ожидали 1 выпуск чата 2, получили %+v", got)
+	}
+	if got := catalog.ByChannel("@News"); len(got) != 1 || got[0].ChatID != 2 {
+		t.Fatalf("ожидали одно самое новое аудио в ленте канала, получили %+v", got)
+	}
+
+	// Повторный выпуск того же чата с тем же аудио заменяет прежний
+	if err := catalog.Add(episode(1, "", "digests/b.mp3", now)); err != nil {
+		t.Fatal(err)
+	}
+	if got := catalog.ByChat(1); len(got) != 2 {
+		t.Fatalf("ожидали замену выпуска, получили %+v", got)
+	}
+
+	// Каталог восстанавливается из файла
+	reopened, err := NewCatalog(file, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.ByChat(1); len(got) != 2 {
+		t.Fatalf("ожидали 2 выпуска после повторного открытия, получили %+v", got)
+	}
+}
+
+func TestCatalogRetention(t *testing.T) {
+	catalog, err := NewCatalog(filepath.Join(t.TempDir(), "catalog.json"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := catalog.Add(episode(1, "", "digests/old.mp3", time.Now().Add(-2*time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+	if got := catalog.ByChat(1); len(got) != 0 {
+		t.Fatalf("выпуск с истёкшим сроком хранения остался в ленте: %+v", got)
+	}
+}
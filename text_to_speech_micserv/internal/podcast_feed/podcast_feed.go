@@ -0,0 +1,192 @@
+// Файл podcast_feed.go формирует приватные подкаст-ленты RSS 2.0 с тегами iTunes:
+// ленту пользователя (все его дайджесты) и ленту канала. Доступ к ленте защищён токеном —
+// HMAC-SHA256 от области ленты, поэтому ссылку с токеном нельзя подобрать для чужой ленты.
+
+package podcast_feed
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Области лент
+const (
+	ScopeUser    = "user"    // Лента пользователя по идентификатору чата
+	ScopeChannel = "channel" // Лента канала по его имени
+)
+
+// tokenLength — длина токена ленты в шестнадцатеричных символах
+const tokenLength = 32
+
+// Feed формирует подкаст-ленты по каталогу выпусков
+type Feed struct {
+	catalog  *Catalog // Каталог выпусков
+	baseURL  string   // Публичный адрес сервиса для ссылок на ленты и аудио
+	secret   []byte   // Секрет для подписи токенов лент
+	coverURL string   // Адрес обложки подкаста (пусто — без обложки)
+}
+
+// NewFeed создаёт генератор подкаст-лент
+func NewFeed(catalog *Catalog, baseURL, secret, coverURL string) *Feed {
+	return &Feed{
+		catalog:  catalog,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		secret:   []byte(secret),
+		coverURL: coverURL,
+	}
+}
+
+// Catalog возвращает каталог выпусков
+func (f *Feed) Catalog() *Catalog {
+	return f.catalog
+}
+
+// Token возвращает токен доступа к ленте области scope с идентификатором id
+func (f *Feed) Token(scope, id string) string {
+	if scope == ScopeChannel {
+		id = NormalizeChannel(id)
+	}
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write([]byte(scope + ":" + id))
+	return hex.EncodeToString(mac.Sum(nil))[:tokenLength]
+}
+
+// Verify проверяет токен доступа к ленте
+func (f *Feed) Verify(scope, id, token string) bool {
+	return hmac.Equal([]byte(f.Token(scope, id)), []byte(token))
+}
+
+// FeedURL возвращает приватную ссылку на ленту с токеном доступа
+func (f *Feed) FeedURL(scope, id string) string {
+	if scope == ScopeChannel {
+		id = NormalizeChannel(id)
+	}
+	return f.baseURL + "/podcast/" + scope + "/" + url.PathEscape(id) + ".rss?token=" + f.Token(scope, id)
+}
+
+// RSS возвращает ленту области scope с идентификатором id
+func (f *Feed) RSS(scope, id string) ([]byte, error) {
+	var episodes []Episode
+	var title, description string
+	switch scope {
+	case ScopeUser:
+		chatID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный идентификатор чата: %q", id)
+		}
+		episodes = f.catalog.ByChat(chatID)
+		title = "Только Talk — мои сводки"
+		description = "Аудиосводки Telegram-каналов, запрошенные в боте"
+	case ScopeChannel:
+		episodes = f.catalog.ByChannel(id)
+		title = "Только Talk — @" + NormalizeChannel(id)
+		description = "Аудиосводки канала @" + NormalizeChannel(id)
+	default:
+		return nil, fmt.Errorf("неизвестная область ленты: %q", scope)
+	}
+
+	channel := rssChannel{
+		Title:       title,
+		Link:        f.baseURL,
+		Description: description,
+		Language:    "ru",
+		Author:      "Только Talk",
+		Explicit:    "false",
+		Type:        "episodic",
+	}
+	if f.coverURL != "" {
+		channel.Image = &itunesImage{Href: f.coverURL}
+	}
+	if len(episodes) > 0 {
+		channel.LastBuildDate = episodes[0].PublishedAt.Format(time.RFC1123Z)
+	}
+	for _, e := range episodes {
+		channel.Items = append(channel.Items, rssItem{
+			Title:       e.Title,
+			Description: e.Description,
+			GUID:        rssGUID{IsPermaLink: "false", Value: e.GUID},
+			PubDate:     e.PublishedAt.Format(time.RFC1123Z),
+			Enclosure:   rssEnclosure{URL: f.audioURL(e.AudioKey), Length: e.Size, Type: e.ContentType},
+			Duration:    formatDuration(e.Duration),
+			Explicit:    "false",
+		})
+	}
+
+	data, err := xml.MarshalIndent(rss{Version: "2.0", ITunes: itunesNamespace, Channel: channel}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("не удалось сформировать RSS: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// audioURL возвращает постоянную ссылку на аудиофайл через HTTP-сервер сервиса
+func (f *Feed) audioURL(key string) string {
+	return f.baseURL + "/audio/" + (&url.URL{Path: key}).EscapedPath()
+}
+
+// formatDuration форматирует длительность для itunes:duration как ЧЧ:ММ:СС
+func formatDuration(d time.Duration) string {
+	s := int64(d.Round(time.Second) / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+// itunesNamespace — пространство имён тегов iTunes
+const itunesNamespace = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+
+// rss — корневой элемент ленты
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	ITunes  string     `xml:"xmlns:itunes,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+// rssChannel — описание подкаста
+type rssChannel struct {
+	Title         string       `xml:"title"`
+	Link          string       `xml:"link"`
+	Description   string       `xml:"description"`
+	Language      string       `xml:"language"`
+	LastBuildDate string       `xml:"lastBuildDate,omitempty"`
+	Author        string       `xml:"itunes:author"`
+	Explicit      string       `xml:"itunes:explicit"`
+	Type          string       `xml:"itunes:type"`
+	Image         *itunesImage `xml:"itunes:image,omitempty"`
+	Items         []rssItem    `xml:"item"`
+}
+
+// itunesImage — обложка подкаста
+type itunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+// rssItem — выпуск подкаста
+type rssItem struct {
+	Title       string       `xml:"title"`
+	Description string       `xml:"description"`
+	GUID        rssGUID      `xml:"guid"`
+	PubDate     string       `xml:"pubDate"`
+	Enclosure   rssEnclosure `xml:"enclosure"`
+	Duration    string       `xml:"itunes:duration"`
+	Explicit    string       `xml:"itunes:explicit"`
+}
+
+// rssGUID — идентификатор выпуска
+type rssGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// rssEnclosure — аудиофайл выпуска
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
@@ -0,0 +1,79 @@
+package podcast_feed
+
+import (
+	"encoding/xml"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToken(t *testing.T) {
+	feed := NewFeed(nil, "https://tts.example/", "secret", "")
+
+	token := feed.Token(ScopeChannel, "@News")
+	if !feed.Verify(ScopeChannel, "news", token) {
+		t.Fatalf("токен канала не принят для того же канала в другом написании")
+	}
+	if feed.Verify(ScopeChannel, "other", token) || feed.Verify(ScopeUser, "news", token) {
+		t.Fatalf("токен принят для чужой ленты")
+	}
+	if NewFeed(nil, "", "другой секрет", "").Verify(ScopeChannel, "news", token) {
+		t.Fatalf("токен принят при другом секрете")
+	}
+
+	link, err := url.Parse(feed.FeedURL(ScopeUser, "42"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Path != "/podcast/user/42.rss" || !feed.Verify(ScopeUser, "42", link.Query().Get("token")) {
+		t.Fatalf("некорректная ссылка на ленту: %s", link)
+	}
+}
+
+func TestRSS(t *testing.T) {
+	catalog, err := NewCatalog(filepath.Join(t.TempDir(), "catalog.json"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	published := time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC)
+	e := episode(42, "@news", "digests/a b.mp3", published)
+	e.Title = "Сводка & новости"
+	e.ContentType = "audio/mpeg"
+	e.Size = 1024
+	e.Duration = 3725 * time.Second
+	if err := catalog.Add(e); err != nil {
+		t.Fatal(err)
+	}
+	feed := NewFeed(catalog, "https://tts.example", "secret", "https://tts.example/podcast/cover")
+
+	data, err := feed.RSS(ScopeUser, "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc rss
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("лента не разбирается как XML: %v\n%s", err, data)
+	}
+	if len(doc.Channel.Items) != 1 {
+		t.Fatalf("ожидали 1 выпуск, получили %d", len(doc.Channel.Items))
+	}
+	item := doc.Channel.Items[0]
+	if item.Title != "Сводка & новости" || item.GUID.Value != e.GUID || item.PubDate != published.Format(time.RFC1123Z) {
+		t.Fatalf("неожиданный выпуск: %+v", item)
+	}
+	if item.Enclosure.URL != "https://tts.example/audio/digests/a%20b.mp3" || item.Enclosure.Length != 1024 || item.Enclosure.Type != "audio/mpeg" {
+		t.Fatalf("неожиданное вложение: %+v", item.Enclosure)
+	}
+	if !strings.Contains(string(data), "<itunes:duration>01:02:05</itunes:duration>") {
+		t.Fatalf("нет длительности выпуска в ленте:\n%s", data)
+	}
+
+	if _, err := feed.RSS(ScopeUser, "не число"); err == nil {
+		t.Fatalf("ожидали ошибку для некорректного идентификатора чата")
+	}
+	if _, err := feed.RSS("unknown", "42"); err == nil {
+		t.Fatalf("ожидали ошибку для неизвестной области ленты")
+	}
+}
@@ -4,13 +4,25 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"mime"
 	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
 
 	"text_to_speech_app/internal/app_text_to_speech"
+	"text_to_speech_app/internal/blob_storage"
 	"text_to_speech_app/internal/model/model_text_to_speech"
+	"text_to_speech_app/internal/podcast_feed"
 	"text_to_speech_app/tools/logger"
 )
 
@@ -18,10 +30,11 @@ import (
 type Server struct {
 	srv        *http.Server                // объект HTTP-сервера
 	ttsService *app_text_to_speech.Service // указатель на сервис Text-to-Speech
+	adminToken string                      // токен служебных эндпоинтов (пусто — служебные эндпоинты выключены)
 }
 
 // NewServer создаёт новый HTTP-сервер
-func NewServer(port string, ttsService *app_text_to_speech.Service) *Server {
+func NewServer(port string, ttsService *app_text_to_speech.Service, adminToken string) *Server {
 	// Создаём мультиплексор для маршрутизации
 	mux := http.NewServeMux()
 	srv := &Server{
@@ -30,8 +43,15 @@ func NewServer(port string, ttsService *app_text_to_speech.Service) *Server {
 			Handler: mux,  // Устанавливаем мультиплексор
 		},
 		ttsService: ttsService, // Внедряем сервис
+		adminToken: adminToken,
 	}
 	mux.HandleFunc("/synthesize", srv.handleSynthesize)
+	mux.HandleFunc("/cache_stats", srv.handleCacheStats)
+	mux.HandleFunc("/synthesize_stream", srv.handleSynthesizeStream)
+	mux.HandleFunc("/audio/", srv.handleAudio)
+	mux.HandleFunc("/voices", srv.handleVoices)
+	mux.HandleFunc("/podcast/", srv.handlePodcast)
+	mux.HandleFunc("/podcast_link", srv.handlePodcastLink)
 
 	return srv
 }
@@ -58,28 +78,343 @@ func (s *Server) handleSynthesize(w http.ResponseWriter, r *http.Request) {
 	myLogger.Info("Успешно декодировали JSON", slog.Any("request", req))
 
 	// Вызываем бизнес-логику для синтеза речи
-	resp, err := s.ttsService.Synthesize(context.Background(), &req)
+	resp, err := s.ttsService.SynthesizeDigest(r.Context(), &req)
 	if err != nil {
 		myLogger.Error("Ошибка синтеза речи", slog.Any("error", err))
+		status := http.StatusBadGateway
+		if errors.Is(err, app_text_to_speech.ErrInvalidRequest) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	// Устанавливаем заголовок Content-Type
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		myLogger.Error("Ошибка кодирования JSON", slog.Any("error", err))
 		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
 		return
 	}
+	myLogger.Info("Успешно отправили ответ клиенту")
+}
+
+// handleVoices обрабатывает GET-запросы на /voices и возвращает список голосов движка синтеза.
+// Параметр language_code (например, ru-RU) ограничивает список голосами для указанного языка.
+func (s *Server) handleVoices(w http.ResponseWriter, r *http.Request) {
+	const loghandleVoices = "internal/infrastructure/server/server.go"
+	myLogger := logger.NewColorLogger(loghandleVoices)
+
+	// Проверяем метод запроса
+	if r.Method != http.MethodGet {
+		myLogger.Error("Метод не поддерживается", slog.String("method", r.Method))
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
 
-	// Проверяем, есть ли ошибка в ответе
-	if resp.Error != "" {
-		myLogger.Error("Ошибка в ответе сервиса", slog.String("error", resp.Error))
-		http.Error(w, resp.Error, http.StatusBadRequest)
+	voices, err := s.ttsService.ListVoices(r.Context(), r.URL.Query().Get("language_code"))
+	if err != nil {
+		myLogger.Error("Ошибка получения списка голосов", slog.Any("error", err))
+		http.Error(w, "Ошибка сервера", http.StatusBadGateway)
 		return
 	}
 
-	// Устанавливаем заголовок Content-Type
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
+	if err := json.NewEncoder(w).Encode(map[string]any{"voices": voices}); err != nil {
+		myLogger.Error("Ошибка кодирования JSON", slog.Any("error", err))
+		return
+	}
+	myLogger.Info("Успешно отдали список голосов", slog.Int("count", len(voices)))
+}
+
+// handleCacheStats обрабатывает GET-запросы на /cache_stats и возвращает счётчики кэша аудиосегментов
+func (s *Server) handleCacheStats(w http.ResponseWriter, r *http.Request) {
+	const loghandleCacheStats = "internal/infrastructure/server/server.go"
+	myLogger := logger.NewColorLogger(loghandleCacheStats)
+
+	// Проверяем метод запроса
+	if r.Method != http.MethodGet {
+		myLogger.Error("Метод не поддерживается", slog.String("method", r.Method))
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.ttsService.CacheStats()); err != nil {
 		myLogger.Error("Ошибка кодирования JSON", slog.Any("error", err))
 		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
 		return
 	}
-	myLogger.Info("Успешно отправили ответ клиенту")
+}
+
+// handleSynthesizeStream обрабатывает POST-запросы на /synthesize_stream.
+// Аудио (audio/mpeg или audio/ogg) отдаётся потоком по мере готовности сегментов,
+// поэтому плеер может начать воспроизведение до окончания синтеза всего дайджеста.
+func (s *Server) handleSynthesizeStream(w http.ResponseWriter, r *http.Request) {
+	const loghandleSynthesizeStream = "internal/infrastructure/server/server.go"
+	myLogger := logger.NewColorLogger(loghandleSynthesizeStream)
+
+	// Проверяем метод запроса
+	if r.Method != http.MethodPost {
+		myLogger.Error("Метод не поддерживается", slog.String("method", r.Method))
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Создаём структуру для запроса
+	var req model_text_to_speech.TextToSpeechRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		myLogger.Error("Ошибка декодирования JSON", slog.Any("error", err))
+		http.Error(w, "Неверный формат данных", http.StatusBadRequest)
+		return
+	}
+	// Формат можно переопределить параметром строки запроса format=mp3; Ogg Opus потоком не отдаётся
+	switch strings.ToLower(r.URL.Query().Get("format")) {
+	case "mp3":
+		req.AudioEncoding = "MP3"
+	case "opus", "ogg":
+		http.Error(w, app_text_to_speech.ErrStreamFormat.Error(), http.StatusBadRequest)
+		return
+	}
+	myLogger.Info("Успешно декодировали JSON", slog.Any("request", req))
+
+	format, err := app_text_to_speech.ResolveAudioFormat(req.AudioEncoding)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if format.Encoding != "MP3" {
+		http.Error(w, app_text_to_speech.ErrStreamFormat.Error(), http.StatusBadRequest)
+		return
+	}
+	key, err := app_text_to_speech.StreamKey(&req)
+	if err != nil {
+		myLogger.Error("Ошибка вычисления ключа результата", slog.Any("error", err))
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	started := false
+	_, err = s.ttsService.SynthesizeStream(r.Context(), &req, func(seg app_text_to_speech.Segment) error {
+		// Заголовки отправляем вместе с первым готовым сегментом: до этого ещё можно вернуть код ошибки.
+		// Длина итогового файла заранее неизвестна, поэтому Content-Length не указываем (chunked).
+		if !started {
+			w.Header().Set("Content-Type", format.ContentType)
+			w.Header().Set("Content-Disposition", contentDisposition("inline", key))
+			// После окончания синтеза те же байты доступны по этому адресу с поддержкой Range
+			w.Header().Set("Content-Location", "/audio/"+key)
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		if _, err := w.Write(seg.Audio); err != nil {
+			return fmt.Errorf("ошибка отправки сегмента %d: %w", seg.ID, err)
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		myLogger.Error("Ошибка потокового синтеза речи", slog.Any("error", err))
+		if !started {
+			status := http.StatusBadGateway
+			if errors.Is(err, app_text_to_speech.ErrInvalidRequest) {
+				status = http.StatusBadRequest
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		// Часть аудио уже отправлена — обрываем соединение, чтобы клиент увидел незавершённый ответ
+		panic(http.ErrAbortHandler)
+	}
+	myLogger.Info("Успешно завершили потоковую отдачу аудио", slog.String("key", key))
+}
+
+// handleAudio обрабатывает GET- и HEAD-запросы на /audio/{key} и отдаёт аудиофайл из хранилища по ключу.
+// Поддерживаются HTTP Range и условные запросы, поэтому плеер может перематывать уже готовый файл.
+func (s *Server) handleAudio(w http.ResponseWriter, r *http.Request) {
+	const loghandleAudio = "internal/infrastructure/server/server.go"
+	myLogger := logger.NewColorLogger(loghandleAudio)
+
+	// Проверяем метод запроса
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		myLogger.Error("Метод не поддерживается", slog.String("method", r.Method))
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Некорректный ключ не может указывать на объект, поэтому для него, как и для отсутствующего, — 404
+	key := strings.TrimPrefix(r.URL.Path, "/audio/")
+	if !blob_storage.ValidKey(key) {
+		http.Error(w, "Аудиофайл не найден", http.StatusNotFound)
+		return
+	}
+	audio, err := s.ttsService.BlobStorage().Get(r.Context(), key)
+	if errors.Is(err, blob_storage.ErrNotFound) || errors.Is(err, blob_storage.ErrInvalidKey) {
+		http.Error(w, "Аудиофайл не найден", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		myLogger.Error("Ошибка чтения аудиофайла из хранилища", slog.String("key", key), slog.Any("error", err))
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+	defer audio.Close()
+
+	// Для Range нужен произвольный доступ: файлы локального хранилища его поддерживают,
+	// объекты из S3 читаем в память целиком
+	var content io.ReadSeeker
+	var modTime time.Time
+	if file, ok := audio.(*os.File); ok {
+		content = file
+		if info, err := file.Stat(); err == nil {
+			modTime = info.ModTime()
+		}
+	} else {
+		data, err := io.ReadAll(audio)
+		if err != nil {
+			myLogger.Error("Ошибка чтения аудиофайла из хранилища", slog.String("key", key), slog.Any("error", err))
+			http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+
+	// Ключ объекта определяется содержимым, поэтому годится в качестве ETag
+	// (расширение оставляем: аудио и субтитры одного дайджеста имеют общую основу ключа)
+	w.Header().Set("ETag", `"`+path.Base(key)+`"`)
+	w.Header().Set("Content-Type", app_text_to_speech.ContentTypeByKey(key))
+	w.Header().Set("Content-Disposition", contentDisposition("inline", key))
+	w.Header().Set("Accept-Ranges", "bytes")
+
+	// ServeContent выставляет Content-Length и обрабатывает Range / If-Range / If-None-Match
+	http.ServeContent(w, r, path.Base(key), modTime, content)
+	myLogger.Info("Успешно отдали аудиофайл", slog.String("key", key))
+}
+
+// handlePodcast обрабатывает GET-запросы на /podcast/{user|channel}/{id}.rss?token=... и отдаёт
+// приватную подкаст-ленту, а также обложку подкаста на /podcast/cover
+func (s *Server) handlePodcast(w http.ResponseWriter, r *http.Request) {
+	const loghandlePodcast = "internal/infrastructure/server/server.go"
+	myLogger := logger.NewColorLogger(loghandlePodcast)
+
+	// Проверяем метод запроса
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		myLogger.Error("Метод не поддерживается", slog.String("method", r.Method))
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	feed := s.ttsService.PodcastFeed()
+	if feed == nil {
+		http.Error(w, "Подкаст-ленты отключены", http.StatusNotFound)
+		return
+	}
+
+	// Обложка подкаста
+	if r.URL.Path == "/podcast/cover" {
+		cover := s.ttsService.CoverArt()
+		if cover == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", http.DetectContentType(cover))
+		http.ServeContent(w, r, "cover", time.Time{}, bytes.NewReader(cover))
+		return
+	}
+
+	scope, id, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/podcast/"), "/")
+	id, found := strings.CutSuffix(id, ".rss")
+	if !ok || !found || id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if !feed.Verify(scope, id, r.URL.Query().Get("token")) {
+		myLogger.Error("Неверный токен подкаст-ленты", slog.String("scope", scope), slog.String("id", id))
+		http.Error(w, "Доступ запрещён", http.StatusForbidden)
+		return
+	}
+
+	data, err := feed.RSS(scope, id)
+	if err != nil {
+		myLogger.Error("Ошибка формирования подкаст-ленты", slog.Any("error", err))
+		http.Error(w, "Лента не найдена", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	if _, err := w.Write(data); err != nil {
+		myLogger.Error("Ошибка отправки подкаст-ленты", slog.Any("error", err))
+		return
+	}
+	myLogger.Info("Успешно отдали подкаст-ленту", slog.String("scope", scope), slog.String("id", id))
+}
+
+// handlePodcastLink обрабатывает GET-запросы на /podcast_link?chat_id=... или ?channel=...
+// и возвращает приватную ссылку на подкаст-ленту. Ссылка даёт доступ к ленте любого чата или канала,
+// поэтому эндпоинт требует заголовок Authorization: Bearer <ADMIN_TOKEN> и выключен без токена.
+func (s *Server) handlePodcastLink(w http.ResponseWriter, r *http.Request) {
+	const loghandlePodcastLink = "internal/infrastructure/server/server.go"
+	myLogger := logger.NewColorLogger(loghandlePodcastLink)
+
+	if !s.authorize(w, r) {
+		myLogger.Error("Запрос ссылки на подкаст-ленту без токена", slog.String("remote", r.RemoteAddr))
+		return
+	}
+
+	// Проверяем метод запроса
+	if r.Method != http.MethodGet {
+		myLogger.Error("Метод не поддерживается", slog.String("method", r.Method))
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	feed := s.ttsService.PodcastFeed()
+	if feed == nil {
+		http.Error(w, "Подкаст-ленты отключены", http.StatusNotFound)
+		return
+	}
+
+	var link string
+	query := r.URL.Query()
+	switch {
+	case query.Get("chat_id") != "":
+		link = feed.FeedURL(podcast_feed.ScopeUser, query.Get("chat_id"))
+	case query.Get("channel") != "":
+		link = feed.FeedURL(podcast_feed.ScopeChannel, query.Get("channel"))
+	default:
+		http.Error(w, "Укажите chat_id или channel", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"url": link}); err != nil {
+		myLogger.Error("Ошибка кодирования JSON", slog.Any("error", err))
+		return
+	}
+}
+
+// authorize проверяет токен служебного эндпоинта в заголовке Authorization: Bearer <токен>.
+// Без настроенного токена служебные эндпоинты не существуют.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) bool {
+	if s.adminToken == "" {
+		http.NotFound(w, r)
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "Доступ запрещён", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// contentDisposition формирует заголовок Content-Disposition с именем файла по ключу объекта
+func contentDisposition(disposition, key string) string {
+	return mime.FormatMediaType(disposition, map[string]string{"filename": "digest" + path.Ext(key)})
 }
 
 // ListenAndServe запускает HTTP-сервер
@@ -0,0 +1,214 @@
+// Файл ssml_digest.go формирует озвучиваемый текст дайджеста канала.
+// Для движков с поддержкой SSML строятся документы <speak> со вступлением, временем публикации
+// каждого поста, паузами <break> между постами и выделением заголовков через <emphasis>.
+// Для движков без SSML формируется эквивалентный простой текст.
+
+package ssml_digest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxInputBytes — ограничение Google Text-to-Speech на размер входного текста одного запроса
+const MaxInputBytes = 5000
+
+// Параметры оформления дайджеста
+const (
+	introBreak       = "1s"     // Пауза после вступления
+	headerBreak      = "400ms"  // Пауза после объявления поста
+	postBreak        = "1200ms" // Пауза между постами
+	maxHeadlineRunes = 200      // Максимальная длина первой строки, которая считается заголовком
+	minTimestamp     = 1e9      // Идентификаторы больше этого значения считаются unix-временем публикации
+	titleWords       = 6        // Количество первых слов поста в названии главы
+)
+
+// IntroTitle — название главы со вступлением
+const IntroTitle = "Вступление"
+
+// Post описывает один пост дайджеста
+type Post struct {
+	ID      int64  // Идентификатор поста (unix-время публикации или порядковый номер)
+	Ordinal int    // Порядковый номер поста в дайджесте, начиная с 1
+	Text    string // Текст поста
+}
+
+// Builder формирует вступление и тексты постов дайджеста
+type Builder struct {
+	location *time.Location // Часовой пояс, в котором озвучивается время публикации
+}
+
+// NewBuilder создаёт построитель дайджеста для указанного часового пояса
+func NewBuilder(location *time.Location) *Builder {
+	if location == nil {
+		location = time.UTC
+	}
+	return &Builder{location: location}
+}
+
+// IntroSSML возвращает SSML-документ вступления: «Сводка канала … за последние N часов, K постов»
+func (b *Builder) IntroSSML(channel string, hours, count int) string {
+	return "<speak><p><s>" + escape(introText(channel, hours, count)) + `</s></p><break time="` + introBreak + `"/></speak>`
+}
+
+// IntroText возвращает вступление простым текстом
+func (b *Builder) IntroText(channel string, hours, count int) string {
+	return introText(channel, hours, count) + "."
+}
+
+// PostSSML возвращает SSML-документ поста: объявление с временем публикации, заголовок с выделением
+// и остальной текст. Текст поста обрезается так, чтобы документ уместился в MaxInputBytes.
+func (b *Builder) PostSSML(post Post) string {
+	header := `<speak><p><s>` + escape(b.announce(post)) + `</s></p><break time="` + headerBreak + `"/><p>`
+	footer := `</p><break time="` + postBreak + `"/></speak>`
+
+	headline, body := splitHeadline(post.Text)
+	budget := MaxInputBytes - len(header) - len(footer)
+
+	var content strings.Builder
+	if headline != "" {
+		const open, close = `<emphasis level="moderate">`, `</emphasis><break time="300ms"/>`
+		headline = truncateEscaped(headline, budget-len(open)-len(close))
+		content.WriteString(open + headline + close)
+		budget -= content.Len()
+	}
+	content.WriteString(truncateEscaped(body, budget))
+
+	return header + content.String() + footer
+}
+
+// PostText возвращает пост простым текстом с объявлением времени публикации
+func (b *Builder) PostText(post Post) string {
+	text := b.announce(post) + ".\n" + post.Text
+	return truncateBytes(text, MaxInputBytes)
+}
+
+// Title возвращает название главы поста: время публикации (или номер поста) и первые слова текста,
+// например «12:30 — первые слова поста»
+func (b *Builder) Title(post Post) string {
+	prefix := fmt.Sprintf("Пост %d", post.Ordinal)
+	if post.ID > minTimestamp {
+		prefix = time.Unix(post.ID, 0).In(b.location).Format("15:04")
+	}
+
+	words := strings.Fields(post.Text)
+	if len(words) == 0 {
+		return prefix
+	}
+	title := prefix + " — " + strings.Join(words[:min(len(words), titleWords)], " ")
+	if len(words) > titleWords {
+		title += "…"
+	}
+	return title
+}
+
+// DigestTitle возвращает название дайджеста для метаданных аудиофайла
+func (b *Builder) DigestTitle(channel string, date time.Time) string {
+	title := "Сводка"
+	if channel != "" {
+		title += " канала «" + strings.TrimPrefix(channel, "@") + "»"
+	}
+	return title + " от " + date.In(b.location).Format("02.01.2006 15:04")
+}
+
+// Location возвращает часовой пояс построителя
+func (b *Builder) Location() *time.Location {
+	return b.location
+}
+
+// announce возвращает объявление поста: время публикации, если оно известно, иначе порядковый номер
+func (b *Builder) announce(post Post) string {
+	if post.ID > minTimestamp {
+		return "Пост от " + time.Unix(post.ID, 0).In(b.location).Format("15:04")
+	}
+	return fmt.Sprintf("Пост %d", post.Ordinal)
+}
+
+// introText формирует текст вступления
+func introText(channel string, hours, count int) string {
+	var b strings.Builder
+	b.WriteString("Сводка")
+	if channel != "" {
+		b.WriteString(" канала «" + strings.TrimPrefix(channel, "@") + "»")
+	}
+	if hours > 0 {
+		if hours == 1 {
+			b.WriteString(" за последний час")
+		} else {
+			fmt.Fprintf(&b, " за последние %d %s", hours, plural(hours, "час", "часа", "часов"))
+		}
+	}
+	fmt.Fprintf(&b, ", %d %s", count, plural(count, "пост", "поста", "постов"))
+	return b.String()
+}
+
+// splitHeadline отделяет заголовок поста: первую строку, если за ней есть продолжение и она короткая
+func splitHeadline(text string) (headline, body string) {
+	text = strings.TrimSpace(text)
+	first, rest, found := strings.Cut(text, "\n")
+	if !found || utf8.RuneCountInString(first) > maxHeadlineRunes || strings.TrimSpace(rest) == "" {
+		return "", text
+	}
+	return strings.TrimSpace(first), strings.TrimSpace(rest)
+}
+
+// plural выбирает форму слова для числа по правилам русского языка
+func plural(n int, one, few, many string) string {
+	n %= 100
+	if n >= 11 && n <= 14 {
+		return many
+	}
+	switch n % 10 {
+	case 1:
+		return one
+	case 2, 3, 4:
+		return few
+	default:
+		return many
+	}
+}
+
+// xmlEscaper экранирует специальные символы XML в пользовательском тексте
+var xmlEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+	"'", "&apos;",
+)
+
+// escape экранирует текст для вставки в SSML
+func escape(text string) string {
+	return xmlEscaper.Replace(text)
+}
+
+// truncateEscaped экранирует текст и обрезает его по границе символа так, чтобы результат
+// не превышал limit байт и не заканчивался посередине XML-сущности
+func truncateEscaped(text string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, r := range text {
+		piece := escape(string(r))
+		if b.Len()+len(piece) > limit {
+			break
+		}
+		b.WriteString(piece)
+	}
+	return b.String()
+}
+
+// truncateBytes обрезает текст до limit байт по границе символа
+func truncateBytes(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	text = text[:limit]
+	for len(text) > 0 && !utf8.ValidString(text) {
+		text = text[:len(text)-1]
+	}
+	return text
+}
@@ -0,0 +1,123 @@
+// Файл transcript.go формирует расшифровку дайджеста, выровненную по синтезированному аудио,
+// в форматах SRT и WebVTT. Google Text-to-Speech v1 не возвращает временные метки (timepoints),
+// поэтому время каждой фразы вычисляется по длительности декодированного сегмента:
+// она распределяется между предложениями сегмента пропорционально их длине.
+
+package transcript
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Форматы расшифровки
+const (
+	FormatSRT    = "srt" // SubRip
+	FormatWebVTT = "vtt" // WebVTT
+)
+
+// Segment описывает озвученный фрагмент и его положение в итоговом аудиофайле
+type Segment struct {
+	Text  string        // Озвученный текст без разметки
+	Start time.Duration // Начало фрагмента от начала файла
+	End   time.Duration // Конец фрагмента от начала файла
+}
+
+// Cue описывает одну фразу расшифровки
+type Cue struct {
+	Start time.Duration // Начало фразы
+	End   time.Duration // Конец фразы
+	Text  string        // Текст фразы
+}
+
+// Build разбивает сегменты на предложения и вычисляет время каждого из них
+func Build(segments []Segment) []Cue {
+	var cues []Cue
+	for _, seg := range segments {
+		sentences := SplitSentences(seg.Text)
+		if len(sentences) == 0 {
+			continue
+		}
+
+		total := 0
+		for _, sentence := range sentences {
+			total += weight(sentence)
+		}
+
+		duration := seg.End - seg.Start
+		offset, done := seg.Start, 0
+		for i, sentence := range sentences {
+			done += weight(sentence)
+			end := seg.Start + duration*time.Duration(done)/time.Duration(total)
+			if i == len(sentences)-1 {
+				end = seg.End
+			}
+			cues = append(cues, Cue{Start: offset, End: end, Text: sentence})
+			offset = end
+		}
+	}
+	return cues
+}
+
+// SplitSentences разбивает текст на предложения по концу строки и знакам . ! ? …,
+// за которыми следует пробел
+func SplitSentences(text string) []string {
+	var sentences []string
+	var current strings.Builder
+	flush := func() {
+		if s := strings.Join(strings.Fields(current.String()), " "); s != "" {
+			sentences = append(sentences, s)
+		}
+		current.Reset()
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		if r == '\n' {
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+		if strings.ContainsRune(".!?…", r) && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])) {
+			flush()
+		}
+	}
+	flush()
+	return sentences
+}
+
+// weight возвращает вес предложения для распределения времени — количество символов без пробелов
+func weight(sentence string) int {
+	n := utf8.RuneCountInString(sentence) - strings.Count(sentence, " ")
+	return max(n, 1)
+}
+
+// SRT возвращает расшифровку в формате SubRip
+func SRT(cues []Cue) []byte {
+	var b strings.Builder
+	for i, cue := range cues {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, timestamp(cue.Start, ","), timestamp(cue.End, ","), cue.Text)
+	}
+	return []byte(b.String())
+}
+
+// WebVTT возвращает расшифровку в формате WebVTT
+func WebVTT(cues []Cue) []byte {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, cue := range cues {
+		// Последовательность «-->» в тексте фразы недопустима
+		text := strings.ReplaceAll(cue.Text, "-->", "->")
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n", timestamp(cue.Start, "."), timestamp(cue.End, "."), text)
+	}
+	return []byte(b.String())
+}
+
+// timestamp форматирует время как ЧЧ:ММ:СС<sep>ммм
+func timestamp(d time.Duration, sep string) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
@@ -0,0 +1,34 @@
+package transcript
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildDistributesSegmentDuration(t *testing.T) {
+	cues := Build([]Segment{
+		{Text: "Сводка канала «news», 2 поста.", End: 2 * time.Second},
+		{Text: "Пост от 12:30.\nПервое предложение. Второе, чуть длиннее!", Start: 2 * time.Second, End: 8 * time.Second},
+	})
+	if len(cues) != 4 {
+		t.Fatalf("ожидали 4 фразы, получили %d: %+v", len(cues), cues)
+	}
+	if cues[0].End != 2*time.Second || cues[1].Start != 2*time.Second || cues[3].End != 8*time.Second {
+		t.Fatalf("границы фраз не совпадают с сегментами: %+v", cues)
+	}
+	for i := 1; i < len(cues); i++ {
+		if cues[i].Start != cues[i-1].End || cues[i].End <= cues[i].Start {
+			t.Fatalf("фразы должны идти подряд: %+v", cues)
+		}
+	}
+
+	srt := string(SRT(cues))
+	if !strings.HasPrefix(srt, "1\n00:00:00,000 --> 00:00:02,000\nСводка канала «news», 2 поста.\n\n") {
+		t.Fatalf("неожиданный SRT:\n%s", srt)
+	}
+	vtt := string(WebVTT(cues))
+	if !strings.HasPrefix(vtt, "WEBVTT\n\n00:00:00.000 --> 00:00:02.000\n") || !strings.Contains(vtt, "--> 00:00:08.000\nВторое, чуть длиннее!") {
+		t.Fatalf("неожиданный WebVTT:\n%s", vtt)
+	}
+}
@@ -12,12 +12,23 @@ import (
 	"tg_app_micserv/internal/kafka"
 	"time"
 
+	"github.com/gotd/td/telegram"
 	"github.com/joho/godotenv"
 
 	"tg_app_micserv/config"
+	"tg_app_micserv/internal/account_pool"
+	"tg_app_micserv/internal/channel_watcher"
 	"tg_app_micserv/internal/handlers"
+	"tg_app_micserv/internal/jobs"
+	"tg_app_micserv/internal/kafka/consumer"
+	"tg_app_micserv/internal/model/interfaces"
+	"tg_app_micserv/internal/peer_cache"
+	"tg_app_micserv/internal/post_archive"
 	"tg_app_micserv/internal/server"
 	"tg_app_micserv/internal/service_parser"
+	"tg_app_micserv/internal/speech_budget"
+	"tg_app_micserv/internal/tg_login"
+	"tg_app_micserv/internal/tg_middleware"
 	"tg_app_micserv/internal/tg_parser"
 	"tg_app_micserv/internal/tg_session_storage"
 	"tg_app_micserv/tools/logger"
@@ -49,22 +60,52 @@ func main() {
 	}
 	slog.Info("Успешно создали объект конфига")
 
-	// Инициализация зависимостей
-	// Инициализация хранилища сессии Telegram-клиента (локальный файл session.json)
-	sessionStorage := tg_session_storage.NewFileSessionStorage("session.json")
-	slog.Info("Успешно создали объект хранилища сессии")
-
-	// Создание нового Telegram клиента с передачей API ID, Hash, телефона и 2FA пароля
-	tgClient := tg_parser.NewClient(cfg.API_ID, cfg.API_Hash, cfg.Phone, cfg.Two_F_Password, sessionStorage)
-	slog.Info("Успешно создали объект Telegram клиента")
-
-	// Создание сервиса для получения и очистки сообщений, использующего Telegram клиента
-	serviceParser := service_parser.NewServiceParser(tgClient)
-	slog.Info("Успешно создали Сервис для парсинга постов")
+	// Параметры оценки длительности озвучивания из конфига (SPEECH_CHARS_PER_SECOND, SPEECH_POST_OVERHEAD_MS)
+	speech_budget.Calibrate(cfg.SpeechChars, cfg.SpeechOverhead)
 
-	// Создание обработчика HTTP-запросов, передающего в него сервис парсер постов
-	messageHandler := handlers.NewMessageHandler(serviceParser)
-	slog.Info("Успешно создали объект обработчика HTTP-запросов")
+	// Инициализация зависимостей
+	// Открытие кеша найденных каналов, чтобы не разрешать имена при каждом парсинге
+	peerCache, err := peer_cache.Open(cfg.PeerCacheFile, cfg.PeerCacheTTL)
+	if err != nil {
+		slog.Error("Не удалось открыть кеш каналов", "error", err)
+		log.Fatal(err)
+	}
+	defer func() {
+		if err := peerCache.Close(); err != nil {
+			slog.Error("Ошибка при закрытии кеша каналов", "error", err)
+		}
+	}()
+	slog.Info("Успешно открыли кеш каналов")
+
+	// Ограничитель частоты запросов общий для всех аккаунтов: лимиты Telegram действуют и на IP сервиса
+	rateLimit := tg_middleware.NewRateLimit(cfg.RateLimitRPS, cfg.RateLimitBurst)
+
+	// Создание пула Telegram-клиентов: у каждого аккаунта своя сессия, свой кеш access hash и своё ожидание FLOOD_WAIT
+	accountPool := account_pool.NewPool(cfg.QuarantineTime)
+	loginAccounts := make([]tg_login.Account, 0, len(cfg.Accounts))
+	var watchSession telegram.SessionStorage
+	var watchMiddlewares []telegram.Middleware
+	for i, account := range cfg.Accounts {
+		sessionStorage := tg_session_storage.NewFileSessionStorage(account.SessionFile)
+		tgMiddlewares := []telegram.Middleware{
+			tg_middleware.NewFloodWait(cfg.FloodWaitBudget),
+			rateLimit,
+		}
+		tgClient := tg_parser.NewClient(account.APIID, account.APIHash, account.Phone, account.Password, cfg.JoinInvites, peerCache.Account(account.Name), sessionStorage, tgMiddlewares...)
+		accountPool.Add(account.Name, tgClient)
+		loginAccounts = append(loginAccounts, tg_login.Account{
+			Name:        account.Name,
+			APIID:       account.APIID,
+			APIHash:     account.APIHash,
+			SessionFile: account.SessionFile,
+			Middlewares: tgMiddlewares,
+		})
+		// Каналы отслеживает первый аккаунт, удерживая его в пуле на время подключения
+		if i == 0 {
+			watchSession, watchMiddlewares = sessionStorage, tgMiddlewares
+		}
+	}
+	slog.Info("Успешно создали пул Telegram клиентов", "accounts", len(cfg.Accounts))
 
 	// Создание kafka Producer-а
 	kafkaProducer, err := kafka.NewProducer(cfg)
@@ -79,8 +120,87 @@ func main() {
 		}
 	}()
 
+	// Открытие архива постов: уже загруженные периоды отдаются без запросов к Telegram.
+	// Без архива сервис работает как раньше, только поиск по архиву недоступен
+	var postParser interfaces.ServiceParser = accountPool
+	var archive *post_archive.Archive
+	if cfg.ArchiveFile != "" {
+		archive, err = post_archive.Open(cfg.ArchiveFile, cfg.ArchiveFresh)
+		if err != nil {
+			slog.Error("Не удалось открыть архив постов, работаем без архива", "error", err)
+		} else {
+			defer func() {
+				if err := archive.Close(); err != nil {
+					slog.Error("Ошибка при закрытии архива постов", "error", err)
+				}
+			}()
+			postParser = post_archive.NewParser(accountPool, archive)
+			slog.Info("Успешно открыли архив постов")
+		}
+	}
+
+	// Создание сервиса для получения и очистки сообщений, использующего Telegram клиента
+	serviceParser := service_parser.NewServiceParser(postParser, kafkaProducer)
+	slog.Info("Успешно создали Сервис для парсинга постов")
+
+	// Создание менеджера асинхронных задач парсинга: одновременно выполняется по задаче на аккаунт
+	jobManager := jobs.NewManager(serviceParser, jobs.NewStore(cfg.JobTTL), cfg.JobQueueSize, len(cfg.Accounts), cfg.JobTimeout, cfg.CallbackHosts)
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go jobManager.Run(jobsCtx)
+	slog.Info("Успешно запустили менеджер задач парсинга")
+
+	// Запуск отслеживания каналов в реальном времени, если заданы каналы
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	if len(cfg.WatchChannels) > 0 {
+		stateStorage, err := channel_watcher.NewFileStateStorage(cfg.WatchStateFile)
+		if err != nil {
+			slog.Error("Не удалось открыть состояние обновлений", "error", err)
+			log.Fatal(err)
+		}
+		// Очередь публикации хранится рядом с состоянием обновлений
+		outbox, err := channel_watcher.NewFileOutbox(cfg.WatchStateFile + ".outbox")
+		if err != nil {
+			slog.Error("Не удалось открыть очередь публикации постов", "error", err)
+			log.Fatal(err)
+		}
+		postProducer := kafka.NewPostProducer(cfg.KafkaPort, cfg.WatchTopic)
+		defer func() {
+			if err := postProducer.Close(); err != nil {
+				slog.Error("Ошибка при закрытии Kafka-продюсера событий", "error", err)
+			}
+		}()
+		watcher := channel_watcher.NewWatcher(accountPool, cfg.Accounts[0].Name, cfg.Accounts[0].APIID, cfg.Accounts[0].APIHash, watchSession, stateStorage, outbox, peerCache.Account(cfg.Accounts[0].Name), cfg.WatchChannels, postProducer, watchMiddlewares...)
+		go watcher.Run(watchCtx)
+		slog.Info("Успешно запустили отслеживание каналов")
+	}
+
+	// Создание обработчика HTTP-запросов, передающего в него сервис парсер постов
+	messageHandler := handlers.NewMessageHandler(serviceParser, jobManager, archive)
+
+	// Чтение запросов дайджеста от Telegram-бота: дайджест уходит сервису Text-to-Speech с чатом пользователя
+	if cfg.RequestTopic != "" {
+		requestConsumer := consumer.NewConsumer(cfg.KafkaPort, cfg.RequestTopic, cfg.KafkaGroupID)
+		defer func() {
+			if err := requestConsumer.Close(); err != nil {
+				slog.Error("Ошибка при закрытии Kafka-консьюмера запросов бота", "error", err)
+			}
+		}()
+		go func() {
+			if err := requestConsumer.Consume(jobsCtx, messageHandler.HandleBotRequest); err != nil {
+				slog.Error("Чтение запросов бота остановлено", "error", err)
+			}
+		}()
+		slog.Info("Успешно запустили чтение запросов бота", "topic", cfg.RequestTopic)
+	}
+	// Сессиями аккаунтов управляем через клиентов пула; вход по QR-коду возвращает аккаунт в пул
+	loginManager := tg_login.NewManager(loginAccounts, accountPool)
+	adminHandler := handlers.NewAdminHandler(cfg.AdminToken, accountPool, loginManager)
+	slog.Info("Успешно создали объект обработчика HTTP-запросов")
+
 	// Создание сервера
-	srv := server.NewServer(cfg.Port, messageHandler)
+	srv := server.NewServer(cfg.Port, cfg.JobTimeout, messageHandler, adminHandler)
 	slog.Info("Успешно создали объект сервер")
 
 	// Канал для сигналов прерывания
@@ -108,10 +228,14 @@ func main() {
 		slog.Error("Ошибка при остановке сервера", "error", err)
 	}
 
-	// Закрытие Telegram-клиента
-	slog.Info("Завершение Telegram-клиента")
-	if err := tgClient.Close(ctx); err != nil {
-		slog.Error("Ошибка при закрытии Telegram-клиента", "error", err)
+	// Остановка выполнения задач парсинга и отслеживания каналов
+	stopJobs()
+	stopWatch()
+
+	// Закрытие Telegram-клиентов
+	slog.Info("Завершение Telegram-клиентов")
+	if err := accountPool.Close(ctx); err != nil {
+		slog.Error("Ошибка при закрытии Telegram-клиентов", "error", err)
 	}
 
 	slog.Info("Сервер успешно остановлен")
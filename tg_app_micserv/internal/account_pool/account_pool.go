@@ -0,0 +1,401 @@
+// Пул Telegram-аккаунтов для распределения нагрузки парсинга
+
+package account_pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/tgerr"
+	"tg_app_micserv/internal/channel_ref"
+	"tg_app_micserv/internal/model"
+	"tg_app_micserv/internal/tg_middleware"
+	"tg_app_micserv/tools/logger"
+)
+
+// ErrNoAccounts возвращается, если в пуле не осталось рабочих аккаунтов
+var ErrNoAccounts = errors.New("нет доступных Telegram-аккаунтов")
+
+// ErrUnknownAccount возвращается, если аккаунта с таким именем нет в пуле
+var ErrUnknownAccount = errors.New("аккаунт не найден в пуле")
+
+// ErrUnavailable возвращается, если аккаунт нельзя удерживать: он на карантине, заблокирован или из него вышли
+var ErrUnavailable = errors.New("аккаунт недоступен")
+
+// maxFailures количество ошибок подряд, после которого аккаунт уходит на карантин
+const maxFailures = 3
+
+// holdRetry пауза, с которой Hold ждёт окончания уже выбранных для аккаунта запросов
+const holdRetry = 100 * time.Millisecond
+
+// Status состояние аккаунта
+type Status string
+
+const (
+	StatusHealthy     Status = "healthy"     // Аккаунт принимает задачи
+	StatusQuarantined Status = "quarantined" // Аккаунт временно исключён после FLOOD_WAIT или ошибок
+	StatusBanned      Status = "banned"      // Аккаунт заблокирован или сессия отозвана
+	StatusLoggedOut   Status = "logged_out"  // Из аккаунта вышли через админский API, нужен повторный вход
+)
+
+// Client клиент Telegram одного аккаунта
+type Client interface {
+	PostParser(ctx context.Context, channel string, period time.Duration) ([]tg_post_model.Message, error)
+	SearchPosts(ctx context.Context, channel string, query tg_post_model.SearchQuery) ([]tg_post_model.Message, error)
+	WithClient(ctx context.Context, request func(ctx context.Context, client *telegram.Client) error) error
+	Close(ctx context.Context) error
+}
+
+// Health состояние аккаунта для админского API
+type Health struct {
+	Name             string     `json:"name"`                        // Имя аккаунта
+	Status           Status     `json:"status"`                      // Состояние
+	Active           int        `json:"active"`                      // Запросы, выполняющиеся или ждущие аккаунт
+	Requests         int        `json:"requests"`                    // Всего запросов
+	Failures         int        `json:"failures"`                    // Ошибок подряд
+	QuarantinedUntil *time.Time `json:"quarantined_until,omitempty"` // Окончание карантина
+	LastError        string     `json:"last_error,omitempty"`        // Последняя ошибка
+	LastUsed         *time.Time `json:"last_used,omitempty"`         // Время последнего запроса
+	Held             bool       `json:"held,omitempty"`              // Аккаунт удерживается постоянным подключением (отслеживание каналов)
+}
+
+// account аккаунт пула; client не допускает параллельных запусков, поэтому запросы к одному аккаунту идут по очереди
+type account struct {
+	client  Client
+	run     sync.Mutex
+	health  Health
+	release context.CancelFunc // Отменяет удержание аккаунта (Hold); nil, если аккаунт не удерживается
+}
+
+// Pool распределяет запросы парсинга между аккаунтами: выбирает наименее загруженный рабочий аккаунт,
+// при FLOOD_WAIT и блокировках переключается на следующий
+type Pool struct {
+	mutex      sync.Mutex
+	accounts   []*account
+	quarantine time.Duration // Длительность карантина после ошибок подряд
+}
+
+// NewPool создает пустой пул аккаунтов
+func NewPool(quarantine time.Duration) *Pool {
+	return &Pool{quarantine: quarantine}
+}
+
+// Add добавляет аккаунт в пул
+func (p *Pool) Add(name string, client Client) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.accounts = append(p.accounts, &account{
+		client: client,
+		health: Health{Name: name, Status: StatusHealthy},
+	})
+}
+
+// PostParser парсит канал через наименее загруженный рабочий аккаунт
+func (p *Pool) PostParser(ctx context.Context, channel string, period time.Duration) ([]tg_post_model.Message, error) {
+	return p.do(ctx, func(ctx context.Context, client Client) ([]tg_post_model.Message, error) {
+		return client.PostParser(ctx, channel, period)
+	})
+}
+
+// SearchPosts ищет посты канала через наименее загруженный рабочий аккаунт
+func (p *Pool) SearchPosts(ctx context.Context, channel string, query tg_post_model.SearchQuery) ([]tg_post_model.Message, error) {
+	return p.do(ctx, func(ctx context.Context, client Client) ([]tg_post_model.Message, error) {
+		return client.SearchPosts(ctx, channel, query)
+	})
+}
+
+// do выполняет запрос на наименее загруженном рабочем аккаунте, при FLOOD_WAIT и блокировках — на следующем
+func (p *Pool) do(ctx context.Context, request func(ctx context.Context, client Client) ([]tg_post_model.Message, error)) ([]tg_post_model.Message, error) {
+	const lbl = "tg_app_micserv/internal/account_pool/account_pool.go/do()"
+	myLogger := logger.NewColorLogger(lbl)
+
+	tried := make(map[*account]bool)
+	for {
+		acc, err := p.pick(tried)
+		if err != nil {
+			return nil, err
+		}
+		tried[acc] = true
+
+		messages, err := p.run(ctx, acc, request)
+		if !p.report(acc, err) {
+			return messages, err
+		}
+		myLogger.Error("Аккаунт исключён, пробуем следующий", slog.String("account", acc.health.Name), slog.Any("error", err))
+	}
+}
+
+// WithClient выполняет request с клиентом Telegram аккаунта name в очереди с запросами парсинга,
+// чтобы с файлом сессии аккаунта не работали два клиента одновременно
+func (p *Pool) WithClient(ctx context.Context, name string, request func(ctx context.Context, client *telegram.Client) error) error {
+	acc, err := p.find(name)
+	if err != nil {
+		return err
+	}
+
+	// Удерживающее аккаунт подключение уступает сессию запросу и переподключится после него
+	p.mutex.Lock()
+	acc.releaseHold()
+	p.mutex.Unlock()
+
+	acc.run.Lock()
+	defer acc.run.Unlock()
+	return acc.client.WithClient(ctx, request)
+}
+
+// Hold удерживает аккаунт name на время run, например для постоянного подключения отслеживания каналов:
+// пока run выполняется, с сессией аккаунта не работает никто другой, а запросы парсинга идут на другие аккаунты.
+// Контекст run отменяется, когда аккаунт нужен другому запросу, уходит на карантин, блокируется или из него выходят;
+// на недоступном аккаунте run не запускается. Ошибка run учитывается в состоянии аккаунта, как ошибка запроса.
+func (p *Pool) Hold(ctx context.Context, name string, run func(ctx context.Context) error) error {
+	acc, err := p.find(name)
+	if err != nil {
+		return err
+	}
+
+	// Ждём, пока выполнятся запросы, которые уже выбрали этот аккаунт
+	for {
+		acc.run.Lock()
+		p.mutex.Lock()
+		p.refresh(acc)
+		if acc.health.Status != StatusHealthy {
+			status := acc.health.Status
+			p.mutex.Unlock()
+			acc.run.Unlock()
+			return fmt.Errorf("%w: %s (%s)", ErrUnavailable, name, status)
+		}
+		if acc.health.Active == 0 {
+			break
+		}
+		p.mutex.Unlock()
+		acc.run.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(holdRetry):
+		}
+	}
+	defer acc.run.Unlock()
+
+	holdCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	acc.release = cancel
+	acc.health.Held = true
+	p.mutex.Unlock()
+
+	err = run(holdCtx)
+
+	p.mutex.Lock()
+	acc.release = nil
+	acc.health.Held = false
+	p.mutex.Unlock()
+	// Отмена удержания не говорит о состоянии аккаунта
+	if holdCtx.Err() == nil {
+		p.report(acc, err)
+	}
+	return err
+}
+
+// LoggedOut исключает аккаунт из работы после выхода из него; вернуть аккаунт можно повторным входом (Restore)
+func (p *Pool) LoggedOut(name string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, acc := range p.accounts {
+		if acc.health.Name == name {
+			acc.releaseHold()
+			acc.health.Status = StatusLoggedOut
+			acc.health.QuarantinedUntil = nil
+			acc.health.LastError = "вышли из аккаунта"
+		}
+	}
+}
+
+// Restore возвращает аккаунт в работу, например после повторного входа в заблокированный аккаунт
+func (p *Pool) Restore(name string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, acc := range p.accounts {
+		if acc.health.Name == name {
+			acc.health.Status = StatusHealthy
+			acc.health.QuarantinedUntil = nil
+			acc.health.Failures = 0
+			acc.health.LastError = ""
+		}
+	}
+}
+
+// Health возвращает состояние всех аккаунтов
+func (p *Pool) Health() []Health {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	result := make([]Health, 0, len(p.accounts))
+	for _, acc := range p.accounts {
+		p.refresh(acc)
+		result = append(result, acc.health)
+	}
+	return result
+}
+
+// Close закрывает клиенты всех аккаунтов
+func (p *Pool) Close(ctx context.Context) error {
+	p.mutex.Lock()
+	accounts := p.accounts
+	p.mutex.Unlock()
+
+	var errs []error
+	for _, acc := range accounts {
+		if err := acc.client.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("аккаунт %s: %w", acc.health.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// find возвращает аккаунт по имени
+func (p *Pool) find(name string) (*account, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, acc := range p.accounts {
+		if acc.health.Name == name {
+			return acc, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownAccount, name)
+}
+
+// pick выбирает рабочий аккаунт с наименьшей загрузкой, при равенстве — дольше всех простаивавший.
+// Удерживаемый аккаунт выбирается, только если других нет: тогда удержание уступает ему сессию.
+// Если рабочих аккаунтов нет, но есть на карантине, возвращает RetryAfterError до окончания ближайшего карантина.
+func (p *Pool) pick(tried map[*account]bool) (*account, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var candidates []*account
+	var soonest time.Time
+	for _, acc := range p.accounts {
+		p.refresh(acc)
+		switch {
+		case acc.health.Status == StatusBanned, acc.health.Status == StatusLoggedOut:
+		case acc.health.Status == StatusQuarantined:
+			if soonest.IsZero() || acc.health.QuarantinedUntil.Before(soonest) {
+				soonest = *acc.health.QuarantinedUntil
+			}
+		case !tried[acc]:
+			candidates = append(candidates, acc)
+		}
+	}
+
+	if len(candidates) == 0 {
+		if !soonest.IsZero() {
+			return nil, &tg_middleware.RetryAfterError{Method: "PostParser", RetryAfter: time.Until(soonest)}
+		}
+		return nil, ErrNoAccounts
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i].health, candidates[j].health
+		if a.Held != b.Held {
+			return !a.Held
+		}
+		if a.Active != b.Active {
+			return a.Active < b.Active
+		}
+		return lastUsed(a).Before(lastUsed(b))
+	})
+	acc := candidates[0]
+	acc.releaseHold()
+	now := time.Now()
+	acc.health.Active++
+	acc.health.Requests++
+	acc.health.LastUsed = &now
+	return acc, nil
+}
+
+// run выполняет запрос на аккаунте, дожидаясь окончания предыдущих запросов к нему
+func (p *Pool) run(ctx context.Context, acc *account, request func(ctx context.Context, client Client) ([]tg_post_model.Message, error)) ([]tg_post_model.Message, error) {
+	defer func() {
+		p.mutex.Lock()
+		acc.health.Active--
+		p.mutex.Unlock()
+	}()
+
+	acc.run.Lock()
+	defer acc.run.Unlock()
+	return request(ctx, acc.client)
+}
+
+// report учитывает результат запроса и сообщает, нужно ли повторить запрос на другом аккаунте
+func (p *Pool) report(acc *account, err error) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	// Успешный запрос обнуляет счётчик ошибок подряд; ошибки ссылки и отмена запроса
+	// не говорят о состоянии аккаунта
+	if err == nil || channel_ref.IsRefError(err) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		acc.health.Failures = 0
+		return false
+	}
+
+	acc.health.LastError = err.Error()
+	var retryErr *tg_middleware.RetryAfterError
+	switch {
+	case errors.As(err, &retryErr):
+		p.quarantineFor(acc, retryErr.RetryAfter)
+		return true
+	case isBanned(err):
+		acc.releaseHold()
+		acc.health.Status = StatusBanned
+		return true
+	}
+
+	acc.health.Failures++
+	if acc.health.Failures >= maxFailures {
+		p.quarantineFor(acc, p.quarantine)
+		return true
+	}
+	return false
+}
+
+// quarantineFor отправляет аккаунт на карантин
+func (p *Pool) quarantineFor(acc *account, d time.Duration) {
+	acc.releaseHold()
+	until := time.Now().Add(d)
+	acc.health.Status = StatusQuarantined
+	acc.health.QuarantinedUntil = &until
+}
+
+// refresh возвращает аккаунт в работу по окончании карантина
+func (p *Pool) refresh(acc *account) {
+	if acc.health.Status == StatusQuarantined && time.Now().After(*acc.health.QuarantinedUntil) {
+		acc.health.Status = StatusHealthy
+		acc.health.QuarantinedUntil = nil
+		acc.health.Failures = 0
+	}
+}
+
+// releaseHold отменяет удержание аккаунта, если он удерживается; вызывается под блокировкой пула
+func (acc *account) releaseHold() {
+	if acc.release != nil {
+		acc.release()
+	}
+}
+
+// isBanned сообщает, что аккаунт заблокирован или его сессия больше не действует
+func isBanned(err error) bool {
+	return tgerr.Is(err, "USER_DEACTIVATED", "USER_DEACTIVATED_BAN", "PHONE_NUMBER_BANNED", "AUTH_KEY_UNREGISTERED", "SESSION_REVOKED", "SESSION_EXPIRED")
+}
+
+// lastUsed возвращает время последнего запроса аккаунта (нулевое, если запросов не было)
+func lastUsed(h Health) time.Time {
+	if h.LastUsed == nil {
+		return time.Time{}
+	}
+	return *h.LastUsed
+}
@@ -0,0 +1,210 @@
+package account_pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/tgerr"
+	"tg_app_micserv/internal/model"
+	"tg_app_micserv/internal/tg_middleware"
+)
+
+// fakeClient клиент, возвращающий заданную ошибку и считающий вызовы
+type fakeClient struct {
+	err   error
+	calls int
+}
+
+func (c *fakeClient) PostParser(context.Context, string, time.Duration) ([]tg_post_model.Message, error) {
+	c.calls++
+	return nil, c.err
+}
+
+func (c *fakeClient) SearchPosts(ctx context.Context, _ string, _ tg_post_model.SearchQuery) ([]tg_post_model.Message, error) {
+	return c.PostParser(ctx, "", 0)
+}
+
+func (c *fakeClient) WithClient(ctx context.Context, request func(ctx context.Context, client *telegram.Client) error) error {
+	return request(ctx, nil)
+}
+
+func (c *fakeClient) Close(context.Context) error { return nil }
+
+func TestPoolSwitchesAccountOnFloodWait(t *testing.T) {
+	flooded := &fakeClient{err: &tg_middleware.RetryAfterError{Method: "messages.getHistory", RetryAfter: time.Minute}}
+	healthy := &fakeClient{}
+	pool := NewPool(time.Minute)
+	pool.Add("flooded", flooded)
+	pool.Add("healthy", healthy)
+
+	if _, err := pool.PostParser(context.Background(), "@channel", time.Hour); err != nil {
+		t.Fatalf("неожиданная ошибка: %v", err)
+	}
+	if flooded.calls != 1 || healthy.calls != 1 {
+		t.Fatalf("ожидали по одному вызову на аккаунт, получили %d и %d", flooded.calls, healthy.calls)
+	}
+
+	health := pool.Health()
+	if health[0].Status != StatusQuarantined || health[1].Status != StatusHealthy {
+		t.Fatalf("неожиданные состояния аккаунтов: %s, %s", health[0].Status, health[1].Status)
+	}
+
+	// Второй запрос идёт сразу на рабочий аккаунт
+	if _, err := pool.PostParser(context.Background(), "@channel", time.Hour); err != nil {
+		t.Fatalf("неожиданная ошибка: %v", err)
+	}
+	if flooded.calls != 1 || healthy.calls != 2 {
+		t.Fatalf("ожидали вызовы 1 и 2, получили %d и %d", flooded.calls, healthy.calls)
+	}
+}
+
+func TestPoolAllAccountsUnavailable(t *testing.T) {
+	pool := NewPool(time.Minute)
+	pool.Add("banned", &fakeClient{err: tgerr.New(401, "USER_DEACTIVATED_BAN")})
+	pool.Add("flooded", &fakeClient{err: &tg_middleware.RetryAfterError{Method: "messages.getHistory", RetryAfter: time.Minute}})
+
+	_, err := pool.PostParser(context.Background(), "@channel", time.Hour)
+	var retryErr *tg_middleware.RetryAfterError
+	if !errors.As(err, &retryErr) || retryErr.RetryAfter <= 0 {
+		t.Fatalf("ожидали RetryAfterError, получили %v", err)
+	}
+
+	banned := NewPool(time.Minute)
+	banned.Add("banned", &fakeClient{err: tgerr.New(401, "USER_DEACTIVATED_BAN")})
+	if _, err := banned.PostParser(context.Background(), "@channel", time.Hour); !errors.Is(err, ErrNoAccounts) {
+		t.Fatalf("ожидали ErrNoAccounts, получили %v", err)
+	}
+}
+
+func TestPoolSuccessResetsFailures(t *testing.T) {
+	client := &fakeClient{}
+	pool := NewPool(time.Minute)
+	pool.Add("account", client)
+
+	// Ошибка, успех, две ошибки: подряд ошибок меньше maxFailures, карантина нет
+	for _, err := range []error{errors.New("сбой"), nil, errors.New("сбой"), errors.New("сбой")} {
+		client.err = err
+		if _, got := pool.PostParser(context.Background(), "@channel", time.Hour); !errors.Is(got, err) {
+			t.Fatalf("ожидали ошибку %v, получили %v", err, got)
+		}
+	}
+
+	health := pool.Health()
+	if health[0].Status != StatusHealthy || health[0].Failures != 2 {
+		t.Fatalf("ожидали рабочий аккаунт с двумя ошибками подряд, получили %s и %d", health[0].Status, health[0].Failures)
+	}
+}
+
+func TestPoolLoggedOut(t *testing.T) {
+	pool := NewPool(time.Minute)
+	pool.Add("account", &fakeClient{})
+
+	if err := pool.WithClient(context.Background(), "other", func(context.Context, *telegram.Client) error { return nil }); !errors.Is(err, ErrUnknownAccount) {
+		t.Fatalf("ожидали ErrUnknownAccount, получили %v", err)
+	}
+
+	// После выхода аккаунт не получает запросов, пока в него снова не войдут
+	pool.LoggedOut("account")
+	if _, err := pool.PostParser(context.Background(), "@channel", time.Hour); !errors.Is(err, ErrNoAccounts) {
+		t.Fatalf("ожидали ErrNoAccounts, получили %v", err)
+	}
+	pool.Restore("account")
+	if _, err := pool.PostParser(context.Background(), "@channel", time.Hour); err != nil {
+		t.Fatalf("неожиданная ошибка после повторного входа: %v", err)
+	}
+}
+
+// hold удерживает аккаунт в фоне до отмены; возвращает канал с результатом Hold после начала удержания
+func hold(t *testing.T, pool *Pool, name string) <-chan error {
+	t.Helper()
+	started := make(chan struct{})
+	result := make(chan error, 1)
+	go func() {
+		result <- pool.Hold(context.Background(), name, func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}()
+	select {
+	case <-started:
+	case err := <-result:
+		t.Fatalf("удержание не началось: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("удержание не началось")
+	}
+	return result
+}
+
+// released ждёт окончания удержания
+func released(t *testing.T, result <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("удержание не отменилось")
+		return nil
+	}
+}
+
+func TestPoolHold(t *testing.T) {
+	watch, parse := &fakeClient{}, &fakeClient{}
+	pool := NewPool(time.Minute)
+	pool.Add("watch", watch)
+	pool.Add("parse", parse)
+
+	// Пока аккаунт удерживается, парсинг идёт на другой аккаунт
+	result := hold(t, pool, "watch")
+	if health := pool.Health(); !health[0].Held || health[1].Held {
+		t.Fatalf("неожиданные состояния аккаунтов: %+v", health)
+	}
+	if _, err := pool.PostParser(context.Background(), "@channel", time.Hour); err != nil {
+		t.Fatalf("неожиданная ошибка: %v", err)
+	}
+	if watch.calls != 0 || parse.calls != 1 {
+		t.Fatalf("ожидали вызовы 0 и 1, получили %d и %d", watch.calls, parse.calls)
+	}
+
+	// Запрос к сессии удерживаемого аккаунта отменяет удержание и выполняется после него
+	if err := pool.WithClient(context.Background(), "watch", func(context.Context, *telegram.Client) error { return nil }); err != nil {
+		t.Fatalf("неожиданная ошибка: %v", err)
+	}
+	if err := released(t, result); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ожидали отмену удержания, получили %v", err)
+	}
+	if pool.Health()[0].Held {
+		t.Fatal("аккаунт остался удерживаемым")
+	}
+
+	// Выход из аккаунта отменяет удержание, и до повторного входа аккаунт не удерживается
+	result = hold(t, pool, "watch")
+	pool.LoggedOut("watch")
+	if err := released(t, result); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ожидали отмену удержания, получили %v", err)
+	}
+	if err := pool.Hold(context.Background(), "watch", func(context.Context) error { return nil }); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("ожидали ErrUnavailable, получили %v", err)
+	}
+}
+
+func TestPoolHoldYieldsToOnlyAccount(t *testing.T) {
+	client := &fakeClient{}
+	pool := NewPool(time.Minute)
+	pool.Add("account", client)
+
+	// Единственный аккаунт уступает сессию парсингу
+	result := hold(t, pool, "account")
+	if _, err := pool.PostParser(context.Background(), "@channel", time.Hour); err != nil {
+		t.Fatalf("неожиданная ошибка: %v", err)
+	}
+	if client.calls != 1 {
+		t.Fatalf("ожидали один вызов, получили %d", client.calls)
+	}
+	if err := released(t, result); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ожидали отмену удержания, получили %v", err)
+	}
+}
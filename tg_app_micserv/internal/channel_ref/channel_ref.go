@@ -0,0 +1,164 @@
+// Разбор ссылок на каналы: имена, ссылки t.me, числовые идентификаторы и пригласительные ссылки
+
+package channel_ref
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gotd/td/telegram/deeplink"
+)
+
+// Ошибки разбора ссылки на канал
+var (
+	ErrEmptyRef   = errors.New("ссылка на канал не указана")
+	ErrInvalidRef = errors.New("некорректная ссылка на канал")
+)
+
+// Kind вид ссылки на канал
+type Kind string
+
+const (
+	KindUsername Kind = "username" // Публичный канал по имени: @name, t.me/name, t.me/s/name
+	KindID       Kind = "id"       // Канал по числовому идентификатору: -100123, t.me/c/123/45
+	KindInvite   Kind = "invite"   // Пригласительная ссылка: t.me/+hash, t.me/joinchat/hash
+)
+
+// channelIDPrefix префикс идентификаторов каналов в Bot API и клиентах (-100…)
+const channelIDPrefix = "-100"
+
+// Ref разобранная ссылка на канал
+type Ref struct {
+	Kind       Kind   // Вид ссылки
+	Username   string // Имя канала в нижнем регистре без @ (KindUsername)
+	ChannelID  int64  // Идентификатор канала без префикса -100 (KindID)
+	InviteHash string // Хеш приглашения (KindInvite)
+	PostID     int    // Номер поста, если ссылка вела на пост (0 — не указан)
+}
+
+// String возвращает ссылку в каноническом виде
+func (r Ref) String() string {
+	switch r.Kind {
+	case KindUsername:
+		return "@" + r.Username
+	case KindID:
+		return "t.me/c/" + strconv.FormatInt(r.ChannelID, 10)
+	case KindInvite:
+		return "t.me/+" + r.InviteHash
+	}
+	return ""
+}
+
+// Parse разбирает ввод пользователя: имя канала, @имя, ссылку t.me (в том числе t.me/s/…, t.me/c/…
+// и пригласительные ссылки), ссылку tg:// или числовой идентификатор канала
+func Parse(input string) (Ref, error) {
+	s := strings.TrimSpace(input)
+	if s == "" {
+		return Ref{}, ErrEmptyRef
+	}
+
+	if id, ok := parseChannelID(s); ok {
+		return Ref{Kind: KindID, ChannelID: id}, nil
+	}
+	if name, ok := strings.CutPrefix(s, "@"); ok {
+		return usernameRef(name, 0, input)
+	}
+
+	link := strings.TrimPrefix(strings.TrimPrefix(s, "http://"), "https://")
+	link = strings.TrimPrefix(link, "www.")
+	if !deeplink.IsDeeplinkLike(link) {
+		return usernameRef(s, 0, input)
+	}
+	if strings.HasPrefix(link, "tg:") {
+		return parseTg(link, input)
+	}
+
+	u, err := url.Parse("https://" + link)
+	if err != nil {
+		return Ref{}, fmt.Errorf("%w: %q", ErrInvalidRef, input)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	// t.me/s/name — веб-превью канала
+	case parts[0] == "s" && len(parts) > 1:
+		return usernameRef(parts[1], postID(parts[2:]), input)
+	// t.me/c/123/45 — ссылка на пост приватного канала по идентификатору
+	case parts[0] == "c":
+		if len(parts) < 2 {
+			return Ref{}, fmt.Errorf("%w: %q", ErrInvalidRef, input)
+		}
+		id, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || id <= 0 {
+			return Ref{}, fmt.Errorf("%w: %q", ErrInvalidRef, input)
+		}
+		return Ref{Kind: KindID, ChannelID: id, PostID: postID(parts[2:])}, nil
+	// t.me/joinchat/hash — пригласительная ссылка старого вида
+	case parts[0] == "joinchat" && len(parts) > 1:
+		return inviteRef(parts[1], input)
+	// t.me/+hash — пригласительная ссылка
+	case strings.HasPrefix(parts[0], "+"):
+		return inviteRef(strings.TrimPrefix(parts[0], "+"), input)
+	}
+	return usernameRef(parts[0], postID(parts[1:]), input)
+}
+
+// parseTg разбирает ссылки tg://resolve?domain=name и tg://join?invite=hash
+func parseTg(link, input string) (Ref, error) {
+	u, err := url.Parse("tg://" + strings.TrimPrefix(strings.TrimPrefix(link, "tg:"), "//"))
+	if err != nil {
+		return Ref{}, fmt.Errorf("%w: %q", ErrInvalidRef, input)
+	}
+	switch deeplink.Type(u.Host) {
+	case deeplink.Resolve:
+		post, _ := strconv.Atoi(u.Query().Get("post"))
+		return usernameRef(u.Query().Get("domain"), max(post, 0), input)
+	case deeplink.Join:
+		return inviteRef(u.Query().Get("invite"), input)
+	}
+	return Ref{}, fmt.Errorf("%w: %q", ErrInvalidRef, input)
+}
+
+// inviteRef проверяет хеш пригласительной ссылки; t.me/+79991234567 — ссылка на номер телефона, а не приглашение
+func inviteRef(hash, input string) (Ref, error) {
+	if hash == "" || strings.TrimLeft(hash, "0123456789") == "" {
+		return Ref{}, fmt.Errorf("%w: %q", ErrInvalidRef, input)
+	}
+	return Ref{Kind: KindInvite, InviteHash: hash}, nil
+}
+
+// usernameRef проверяет имя канала; имена в Telegram не зависят от регистра
+func usernameRef(name string, post int, input string) (Ref, error) {
+	name = strings.ToLower(name)
+	if err := deeplink.ValidateDomain(name); err != nil {
+		return Ref{}, fmt.Errorf("%w: %q", ErrInvalidRef, input)
+	}
+	return Ref{Kind: KindUsername, Username: name, PostID: post}, nil
+}
+
+// parseChannelID разбирает числовой идентификатор канала: -1001234567890 или 1234567890
+func parseChannelID(s string) (int64, bool) {
+	digits := strings.TrimPrefix(s, channelIDPrefix)
+	if digits == "" || strings.TrimLeft(digits, "0123456789") != "" {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// postID возвращает номер поста из остатка пути ссылки
+func postID(rest []string) int {
+	if len(rest) == 0 {
+		return 0
+	}
+	id, err := strconv.Atoi(rest[0])
+	if err != nil || id <= 0 {
+		return 0
+	}
+	return id
+}
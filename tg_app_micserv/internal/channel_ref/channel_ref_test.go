@@ -0,0 +1,55 @@
+package channel_ref
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Ref
+	}{
+		{"durov", Ref{Kind: KindUsername, Username: "durov"}},
+		{"@Durov", Ref{Kind: KindUsername, Username: "durov"}},
+		{"https://t.me/durov", Ref{Kind: KindUsername, Username: "durov"}},
+		{"t.me/s/durov", Ref{Kind: KindUsername, Username: "durov"}},
+		{"https://t.me/durov/123?single", Ref{Kind: KindUsername, Username: "durov", PostID: 123}},
+		{"http://www.telegram.me/Durov/", Ref{Kind: KindUsername, Username: "durov"}},
+		{"tg://resolve?domain=durov&post=7", Ref{Kind: KindUsername, Username: "durov", PostID: 7}},
+		{"https://t.me/c/1234567890/45", Ref{Kind: KindID, ChannelID: 1234567890, PostID: 45}},
+		{"-1001234567890", Ref{Kind: KindID, ChannelID: 1234567890}},
+		{"https://t.me/+AbCdEf_123", Ref{Kind: KindInvite, InviteHash: "AbCdEf_123"}},
+		{"t.me/joinchat/AbCdEf", Ref{Kind: KindInvite, InviteHash: "AbCdEf"}},
+		{"tg://join?invite=AbCdEf", Ref{Kind: KindInvite, InviteHash: "AbCdEf"}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q): неожиданная ошибка %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %+v, ожидали %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  error
+	}{
+		{"  ", ErrEmptyRef},
+		{"имя канала", ErrInvalidRef},
+		{"https://example.com/durov", ErrInvalidRef},
+		{"https://t.me/c/abc", ErrInvalidRef},
+		{"https://t.me/+79991234567", ErrInvalidRef},
+		{"https://t.me/", ErrInvalidRef},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.input); !errors.Is(err, tt.want) {
+			t.Errorf("Parse(%q): ошибка %v, ожидали %v", tt.input, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+// Поиск канала в Telegram по разобранной ссылке
+
+package channel_ref
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/gotd/td/telegram/query/dialogs"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+	"tg_app_micserv/internal/peer_cache"
+)
+
+// Ошибки поиска канала
+var (
+	ErrNotFound        = errors.New("канал не найден")
+	ErrNotChannel      = errors.New("ссылка ведёт не на канал")
+	ErrPrivate         = errors.New("канал приватный или аккаунт заблокирован в нём")
+	ErrNotMember       = errors.New("аккаунт не состоит в канале")
+	ErrInviteInvalid   = errors.New("пригласительная ссылка недействительна")
+	ErrInviteExpired   = errors.New("срок действия пригласительной ссылки истёк")
+	ErrJoinRequestSent = errors.New("отправлена заявка на вступление, канал станет доступен после одобрения")
+	ErrAuthorNotFound  = errors.New("автор не найден")
+)
+
+// IsRefError сообщает, что ошибка связана с самой ссылкой или каналом, а не с аккаунтом
+func IsRefError(err error) bool {
+	for _, target := range []error{ErrEmptyRef, ErrInvalidRef, ErrNotFound, ErrNotChannel, ErrPrivate, ErrNotMember, ErrInviteInvalid, ErrInviteExpired, ErrJoinRequestSent, ErrAuthorNotFound} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Channel найденный канал
+type Channel struct {
+	ID         int64  // Идентификатор канала
+	AccessHash int64  // Access hash для запросов от имени аккаунта
+	Username   string // Имя канала (пусто у приватных каналов)
+	Title      string // Название канала
+	Left       bool   // Аккаунт не подписан на канал
+	Cached     bool   // Канал взят из кеша, а не получен от Telegram
+}
+
+// Name возвращает имя канала для логов и ответов: @имя или название приватного канала
+func (c Channel) Name() string {
+	if c.Username != "" {
+		return "@" + c.Username
+	}
+	return c.Title
+}
+
+// InputPeer возвращает канал как собеседника для запросов истории
+func (c Channel) InputPeer() *tg.InputPeerChannel {
+	return &tg.InputPeerChannel{ChannelID: c.ID, AccessHash: c.AccessHash}
+}
+
+// Resolver ищет каналы по ссылкам от имени аккаунта
+type Resolver struct {
+	api   *tg.Client
+	join  bool              // Вступать в канал по пригласительной ссылке, если аккаунт в нём не состоит
+	peers *peer_cache.Store // Кеш найденных собеседников (nil — без кеша)
+}
+
+// NewResolver создает Resolver; peers может быть nil
+func NewResolver(api *tg.Client, join bool, peers *peer_cache.Store) *Resolver {
+	return &Resolver{
+		api:   api,
+		join:  join,
+		peers: peers,
+	}
+}
+
+// Invalidate удаляет канал из кеша, если Telegram отверг его access hash
+func (r *Resolver) Invalidate(channel Channel) {
+	if r.peers == nil {
+		return
+	}
+	if err := r.peers.Invalidate(peer_cache.KindChannel, channel.ID); err != nil {
+		slog.Error("Не удалось удалить канал из кеша", slog.Int64("channel_id", channel.ID), slog.Any("error", err))
+	}
+}
+
+// IsInvalidPeer сообщает, что Telegram отверг канал: access hash устарел или канал недоступен
+func IsInvalidPeer(err error) bool {
+	return tgerr.Is(err, "CHANNEL_INVALID", "CHANNEL_PRIVATE", "PEER_ID_INVALID")
+}
+
+// Resolve находит канал по ссылке
+func (r *Resolver) Resolve(ctx context.Context, ref Ref) (Channel, error) {
+	switch ref.Kind {
+	case KindUsername:
+		return r.resolveUsername(ctx, ref.Username)
+	case KindID:
+		return r.resolveID(ctx, ref.ChannelID)
+	case KindInvite:
+		return r.resolveInvite(ctx, ref.InviteHash)
+	}
+	return Channel{}, ErrInvalidRef
+}
+
+// resolveUsername находит публичный канал по имени, сначала в кеше
+func (r *Resolver) resolveUsername(ctx context.Context, username string) (Channel, error) {
+	if r.peers != nil {
+		if peer, ok := r.peers.ByUsername(username); ok {
+			if peer.Kind != peer_cache.KindChannel {
+				return Channel{}, fmt.Errorf("%w: @%s", ErrNotChannel, username)
+			}
+			return fromPeer(peer), nil
+		}
+	}
+
+	resolved, err := r.api.ContactsResolveUsername(ctx, &tg.ContactsResolveUsernameRequest{Username: username})
+	if tgerr.Is(err, "USERNAME_NOT_OCCUPIED", "USERNAME_INVALID") {
+		return Channel{}, fmt.Errorf("%w: @%s", ErrNotFound, username)
+	}
+	if err != nil {
+		return Channel{}, fmt.Errorf("не удалось разрешить имя @%s: %w", username, err)
+	}
+	peer, ok := resolved.Peer.(*tg.PeerChannel)
+	if !ok {
+		// Запоминаем и пользователей, чтобы не разрешать их имя повторно
+		for _, u := range resolved.Users {
+			if user, ok := u.(*tg.User); ok {
+				r.remember(peer_cache.Peer{Kind: peer_cache.KindUser, ID: user.ID, AccessHash: user.AccessHash, Username: username, Title: user.FirstName})
+			}
+		}
+		return Channel{}, fmt.Errorf("%w: @%s", ErrNotChannel, username)
+	}
+	for _, chat := range resolved.Chats {
+		if chat.GetID() == peer.ChannelID {
+			return r.fromChat(chat)
+		}
+	}
+	return Channel{}, fmt.Errorf("%w: @%s", ErrNotFound, username)
+}
+
+// ResolveAuthor находит автора постов по имени (@имя или ссылке t.me), сначала в кеше.
+// Автором может быть пользователь или канал, от имени которого пишут в группе
+func (r *Resolver) ResolveAuthor(ctx context.Context, author string) (tg.InputPeerClass, error) {
+	ref, err := Parse(author)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Kind != KindUsername {
+		return nil, fmt.Errorf("%w: автора нужно указать по имени: %s", ErrInvalidRef, author)
+	}
+	if r.peers != nil {
+		if peer, ok := r.peers.ByUsername(ref.Username); ok {
+			if peer.Kind == peer_cache.KindUser {
+				return &tg.InputPeerUser{UserID: peer.ID, AccessHash: peer.AccessHash}, nil
+			}
+			return fromPeer(peer).InputPeer(), nil
+		}
+	}
+
+	resolved, err := r.api.ContactsResolveUsername(ctx, &tg.ContactsResolveUsernameRequest{Username: ref.Username})
+	if tgerr.Is(err, "USERNAME_NOT_OCCUPIED", "USERNAME_INVALID") {
+		return nil, fmt.Errorf("%w: @%s", ErrAuthorNotFound, ref.Username)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось разрешить имя @%s: %w", ref.Username, err)
+	}
+	switch peer := resolved.Peer.(type) {
+	case *tg.PeerUser:
+		for _, u := range resolved.Users {
+			if user, ok := u.(*tg.User); ok && user.ID == peer.UserID {
+				r.remember(peer_cache.Peer{Kind: peer_cache.KindUser, ID: user.ID, AccessHash: user.AccessHash, Username: ref.Username, Title: user.FirstName})
+				return &tg.InputPeerUser{UserID: user.ID, AccessHash: user.AccessHash}, nil
+			}
+		}
+	case *tg.PeerChannel:
+		for _, chat := range resolved.Chats {
+			if chat.GetID() == peer.ChannelID {
+				channel, err := r.fromChat(chat)
+				if err != nil {
+					return nil, err
+				}
+				return channel.InputPeer(), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: @%s", ErrAuthorNotFound, ref.Username)
+}
+
+// resolveID находит канал по идентификатору среди диалогов аккаунта: без access hash
+// запросить канал напрямую нельзя, поэтому такие ссылки работают только для каналов, где аккаунт состоит
+func (r *Resolver) resolveID(ctx context.Context, id int64) (Channel, error) {
+	if r.peers != nil {
+		if peer, ok := r.peers.ByID(peer_cache.KindChannel, id); ok {
+			return fromPeer(peer), nil
+		}
+	}
+
+	var channel *tg.Channel
+	errFound := errors.New("found")
+	err := dialogs.NewQueryBuilder(r.api).GetDialogs().BatchSize(100).ForEach(ctx, func(_ context.Context, elem dialogs.Elem) error {
+		if ch, ok := elem.Entities.Channel(id); ok {
+			channel = ch
+			return errFound
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errFound) {
+		return Channel{}, fmt.Errorf("не удалось получить диалоги аккаунта: %w", err)
+	}
+	if channel == nil {
+		return Channel{}, fmt.Errorf("%w: канал %d не найден среди диалогов", ErrNotMember, id)
+	}
+	return r.fromChat(channel)
+}
+
+// resolveInvite проверяет пригласительную ссылку и при необходимости вступает в канал
+func (r *Resolver) resolveInvite(ctx context.Context, hash string) (Channel, error) {
+	invite, err := r.api.MessagesCheckChatInvite(ctx, hash)
+	if err != nil {
+		return Channel{}, inviteError(err)
+	}
+
+	switch invite := invite.(type) {
+	case *tg.ChatInviteAlready:
+		return r.fromChat(invite.Chat)
+	case *tg.ChatInvitePeek:
+		return r.fromChat(invite.Chat)
+	case *tg.ChatInvite:
+		if !invite.Channel {
+			return Channel{}, fmt.Errorf("%w: %s", ErrNotChannel, invite.Title)
+		}
+		if !r.join {
+			return Channel{}, fmt.Errorf("%w: %s", ErrNotMember, invite.Title)
+		}
+	default:
+		return Channel{}, fmt.Errorf("неожиданный ответ на проверку приглашения: %T", invite)
+	}
+
+	upd, err := r.api.MessagesImportChatInvite(ctx, hash)
+	if err != nil {
+		return Channel{}, inviteError(err)
+	}
+	var chats []tg.ChatClass
+	switch upd := upd.(type) {
+	case *tg.Updates:
+		chats = upd.Chats
+	case *tg.UpdatesCombined:
+		chats = upd.Chats
+	}
+	if len(chats) == 0 {
+		return Channel{}, fmt.Errorf("%w: канал не вернулся после вступления", ErrNotFound)
+	}
+	return r.fromChat(chats[0])
+}
+
+// inviteError переводит ошибки Telegram по приглашениям в ошибки пакета
+func inviteError(err error) error {
+	switch {
+	case tgerr.Is(err, "INVITE_HASH_INVALID", "INVITE_HASH_EMPTY"):
+		return ErrInviteInvalid
+	case tgerr.Is(err, "INVITE_HASH_EXPIRED"):
+		return ErrInviteExpired
+	case tgerr.Is(err, "INVITE_REQUEST_SENT"):
+		return ErrJoinRequestSent
+	case tgerr.Is(err, "USER_ALREADY_PARTICIPANT"):
+		return fmt.Errorf("%w: аккаунт уже в канале, но приглашение не вернуло его", ErrNotFound)
+	}
+	return fmt.Errorf("не удалось проверить приглашение: %w", err)
+}
+
+// fromChat преобразует чат Telegram в канал и запоминает его в кеше
+func (r *Resolver) fromChat(chat tg.ChatClass) (Channel, error) {
+	switch chat := chat.(type) {
+	case *tg.Channel:
+		username, _ := chat.GetUsername()
+		r.remember(peer_cache.Peer{
+			Kind:       peer_cache.KindChannel,
+			ID:         chat.ID,
+			AccessHash: chat.AccessHash,
+			Username:   username,
+			Title:      chat.Title,
+			Left:       chat.GetLeft(),
+		})
+		return Channel{
+			ID:         chat.ID,
+			AccessHash: chat.AccessHash,
+			Username:   username,
+			Title:      chat.Title,
+			Left:       chat.GetLeft(),
+		}, nil
+	case *tg.ChannelForbidden:
+		return Channel{}, fmt.Errorf("%w: %s", ErrPrivate, chat.Title)
+	}
+	return Channel{}, ErrNotChannel
+}
+
+// fromPeer преобразует запись кеша в канал
+func fromPeer(peer peer_cache.Peer) Channel {
+	return Channel{
+		ID:         peer.ID,
+		AccessHash: peer.AccessHash,
+		Username:   peer.Username,
+		Title:      peer.Title,
+		Left:       peer.Left,
+		Cached:     true,
+	}
+}
+
+// remember сохраняет собеседника в кеш; ошибка кеша не мешает поиску канала
+func (r *Resolver) remember(peer peer_cache.Peer) {
+	if r.peers == nil {
+		return
+	}
+	if err := r.peers.Put(peer); err != nil {
+		slog.Error("Не удалось сохранить собеседника в кеш", slog.Int64("id", peer.ID), slog.Any("error", err))
+	}
+}
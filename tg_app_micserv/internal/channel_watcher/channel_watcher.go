@@ -0,0 +1,365 @@
+// Отслеживание каналов в реальном времени через менеджер обновлений Telegram
+
+package channel_watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/telegram/updates"
+	"github.com/gotd/td/tg"
+	"tg_app_micserv/internal/channel_ref"
+	"tg_app_micserv/internal/kafka"
+	"tg_app_micserv/internal/model"
+	"tg_app_micserv/internal/peer_cache"
+	"tg_app_micserv/internal/service_parser"
+	"tg_app_micserv/internal/tg_message"
+	"tg_app_micserv/tools/logger"
+)
+
+// Пауза перед повторным подключением и повторной публикацией растёт от minRetryDelay до maxRetryDelay
+const (
+	minRetryDelay = 1 * time.Second
+	maxRetryDelay = 2 * time.Minute
+)
+
+// albumWait сколько ждать остальные вложения альбома после первого: Telegram присылает их отдельными сообщениями
+const albumWait = 3 * time.Second
+
+// Holder удерживает аккаунт пула на время подключения, чтобы с его сессией не работал другой клиент
+type Holder interface {
+	Hold(ctx context.Context, name string, run func(ctx context.Context) error) error
+}
+
+// Watcher держит постоянное подключение к Telegram, получает обновления отслеживаемых каналов
+// и публикует новые и отредактированные посты в Kafka. Состояние обновлений сохраняется в файле,
+// поэтому после переподключения или перезапуска пропущенные посты дозапрашиваются через getDifference.
+// Посты публикуются через очередь в файле: pts канала сохраняется только после постановки поста в очередь,
+// а очередь публикуется по порядку с повтором при недоступности Kafka.
+// Подключение выполняется, пока аккаунт удерживается в пуле: запросы к его сессии уступают подключению очередь,
+// а при выходе из аккаунта, карантине или блокировке подключение разрывается до возвращения аккаунта в работу.
+type Watcher struct {
+	pool      Holder
+	account   string // Имя аккаунта пула, от которого отслеживаются каналы
+	apiID     int
+	apiHash   string
+	session   telegram.SessionStorage
+	state     *FileStateStorage
+	outbox    *FileOutbox
+	gaps      *updates.Manager
+	publisher kafka.PostPublisher
+	peers     *peer_cache.Store
+	mws       []telegram.Middleware // Middleware запросов к API, общие с основным клиентом
+	refs      []string              // Ссылки на отслеживаемые каналы из конфига
+
+	mutex    sync.RWMutex
+	channels map[int64]string // Разрешённые каналы: идентификатор → имя
+}
+
+// NewWatcher создает наблюдатель за каналами от имени аккаунта пула account; сессия должна быть уже авторизована
+func NewWatcher(pool Holder, account string, apiID int, apiHash string, session telegram.SessionStorage, state *FileStateStorage, outbox *FileOutbox, peers *peer_cache.Store, refs []string, publisher kafka.PostPublisher, middlewares ...telegram.Middleware) *Watcher {
+	w := &Watcher{
+		pool:      pool,
+		account:   account,
+		apiID:     apiID,
+		apiHash:   apiHash,
+		session:   session,
+		state:     state,
+		outbox:    outbox,
+		publisher: publisher,
+		peers:     peers,
+		mws:       middlewares,
+		refs:      refs,
+		channels:  make(map[int64]string),
+	}
+
+	dispatcher := tg.NewUpdateDispatcher()
+	dispatcher.OnNewChannelMessage(func(ctx context.Context, entities tg.Entities, update *tg.UpdateNewChannelMessage) error {
+		return w.handle(ctx, tg_post_model.PostEventNew, update.Message, entities)
+	})
+	dispatcher.OnEditChannelMessage(func(ctx context.Context, entities tg.Entities, update *tg.UpdateEditChannelMessage) error {
+		return w.handle(ctx, tg_post_model.PostEventEdited, update.Message, entities)
+	})
+
+	w.gaps = updates.New(updates.Config{
+		Handler:      dispatcher,
+		Storage:      state,
+		AccessHasher: state,
+		OnChannelTooLong: func(channelID int64) {
+			slog.Error("Разрыв в обновлениях канала слишком велик, часть постов не восстановлена", slog.Int64("channel_id", channelID))
+		},
+	})
+	return w
+}
+
+// Run отслеживает каналы до отмены ctx, переподключаясь при обрывах связи; очередь публикуется
+// и пока подключения нет
+func (w *Watcher) Run(ctx context.Context) {
+	const lbl = "tg_app_micserv/internal/channel_watcher/channel_watcher.go/Run()"
+	myLogger := logger.NewColorLogger(lbl)
+
+	go w.publish(ctx)
+
+	delay := minRetryDelay
+	for {
+		started := time.Now()
+		err := w.pool.Hold(ctx, w.account, w.run)
+		// Сбрасываем менеджер, чтобы при следующем подключении он заново загрузил сохранённое состояние
+		w.gaps.Reset()
+		if ctx.Err() != nil {
+			return
+		}
+		// Если соединение продержалось долго или уступило сессию запросу к аккаунту, начинаем отсчёт паузы заново
+		if time.Since(started) > maxRetryDelay || errors.Is(err, context.Canceled) {
+			delay = minRetryDelay
+		}
+		myLogger.Error("Отслеживание каналов прервано, переподключаемся", slog.Any("error", err), slog.Duration("delay", delay))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+// run подключается к Telegram и обрабатывает обновления до разрыва соединения
+func (w *Watcher) run(ctx context.Context) error {
+	const lbl = "tg_app_micserv/internal/channel_watcher/channel_watcher.go/run()"
+	myLogger := logger.NewColorLogger(lbl)
+
+	client := telegram.NewClient(w.apiID, w.apiHash, telegram.Options{
+		SessionStorage: w.session,
+		UpdateHandler:  w.gaps,
+		Middlewares:    w.mws,
+	})
+	return client.Run(ctx, func(ctx context.Context) error {
+		status, err := client.Auth().Status(ctx)
+		if err != nil {
+			return fmt.Errorf("не удалось проверить статус аутентификации: %w", err)
+		}
+		if !status.Authorized {
+			return errors.New("сессия Telegram не авторизована")
+		}
+
+		count, err := w.resolve(ctx, client.API(), status.User.ID)
+		if err != nil {
+			return err
+		}
+
+		return w.gaps.Run(ctx, client.API(), status.User.ID, updates.AuthOptions{
+			IsBot: status.User.Bot,
+			OnStart: func(ctx context.Context) {
+				myLogger.Info("Начали отслеживать каналы", slog.Int("channels", count))
+			},
+		})
+	})
+}
+
+// resolve находит отслеживаемые каналы по ссылкам и запоминает их access hash для восстановления разрывов;
+// возвращает количество найденных каналов
+func (w *Watcher) resolve(ctx context.Context, api *tg.Client, userID int64) (int, error) {
+	const lbl = "tg_app_micserv/internal/channel_watcher/channel_watcher.go/resolve()"
+	myLogger := logger.NewColorLogger(lbl)
+
+	resolver := channel_ref.NewResolver(api, false, w.peers)
+	channels := make(map[int64]string)
+	for _, input := range w.refs {
+		ref, err := channel_ref.Parse(input)
+		if err != nil {
+			myLogger.Error("Некорректная ссылка на канал", slog.String("channel", input), slog.Any("error", err))
+			continue
+		}
+		channel, err := resolver.Resolve(ctx, ref)
+		if err != nil {
+			myLogger.Error("Не удалось найти канал", slog.String("channel", input), slog.Any("error", err))
+			continue
+		}
+		// Обновления приходят только по каналам, на которые подписан аккаунт
+		if channel.Left {
+			myLogger.Error("Аккаунт не подписан на канал, пропускаем", slog.String("channel", input))
+			continue
+		}
+
+		if err := w.state.SetChannelAccessHash(ctx, userID, channel.ID, channel.AccessHash); err != nil {
+			return 0, err
+		}
+		channels[channel.ID] = strings.TrimPrefix(channel.Name(), "@")
+	}
+	if len(channels) == 0 {
+		return 0, errors.New("нет доступных каналов для отслеживания")
+	}
+
+	w.mutex.Lock()
+	w.channels = channels
+	w.mutex.Unlock()
+	return len(channels), nil
+}
+
+// handle ставит пост отслеживаемого канала в очередь публикации; ошибка возвращается, только если пост
+// не удалось сохранить в очереди
+func (w *Watcher) handle(_ context.Context, eventType string, msgClass tg.MessageClass, entities tg.Entities) error {
+	const lbl = "tg_app_micserv/internal/channel_watcher/channel_watcher.go/handle()"
+	myLogger := logger.NewColorLogger(lbl)
+
+	message, ok := msgClass.(*tg.Message)
+	if !ok {
+		return nil
+	}
+	peer, ok := message.PeerID.(*tg.PeerChannel)
+	if !ok {
+		return nil
+	}
+	w.mutex.RLock()
+	channel, ok := w.channels[peer.ChannelID]
+	w.mutex.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	post := tg_message.Convert(message, entities)
+	switch {
+	case post.GroupedID != 0 && eventType == tg_post_model.PostEventNew:
+		// Вложения нового альбома приходят отдельными сообщениями и объединяются при публикации,
+		// поэтому в очередь ставятся все, в том числе без подписи
+	case post.GroupedID != 0 && post.Text == "":
+		// Правка вложения альбома без подписи не меняет текст поста
+		return nil
+	case service_parser.PostText(post) == "":
+		// Посты, которые нечего озвучивать, не публикуются, как и при обычном парсинге
+		return nil
+	}
+
+	event := tg_post_model.PostEvent{
+		Type:      eventType,
+		Channel:   channel,
+		ChannelID: peer.ChannelID,
+		Message:   post,
+	}
+	if editDate, ok := message.GetEditDate(); ok && eventType == tg_post_model.PostEventEdited {
+		editedAt := time.Unix(int64(editDate), 0)
+		event.EditedAt = &editedAt
+	}
+
+	if err := w.outbox.Add(event); err != nil {
+		myLogger.Error("Не удалось поставить пост в очередь публикации", slog.String("channel", channel), slog.Int("id", post.ID), slog.Any("error", err))
+		return err
+	}
+	return nil
+}
+
+// publish публикует очередь до отмены ctx: сразу после новых событий, по готовности альбома
+// и с растущей паузой, пока Kafka недоступна
+func (w *Watcher) publish(ctx context.Context) {
+	const lbl = "tg_app_micserv/internal/channel_watcher/channel_watcher.go/publish()"
+	myLogger := logger.NewColorLogger(lbl)
+
+	delay := minRetryDelay
+	for {
+		wait, err := w.flush(ctx)
+		added := w.outbox.Added()
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			myLogger.Error("Не удалось опубликовать очередь постов, повторим", slog.Any("error", err), slog.Duration("delay", delay))
+			// Новые события не ускоряют повтор, пока Kafka недоступна
+			wait, added = delay, nil
+			delay = min(delay*2, maxRetryDelay)
+		} else {
+			delay = minRetryDelay
+		}
+
+		var timer <-chan time.Time
+		if wait > 0 {
+			timer = time.After(wait)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-added:
+		case <-timer:
+		}
+	}
+}
+
+// flush публикует готовые события очереди по порядку и удаляет опубликованные; возвращает,
+// через сколько будет готов ещё не собранный альбом (0 — ждать нечего)
+func (w *Watcher) flush(ctx context.Context) (time.Duration, error) {
+	const lbl = "tg_app_micserv/internal/channel_watcher/channel_watcher.go/flush()"
+	myLogger := logger.NewColorLogger(lbl)
+
+	entries := w.outbox.Pending()
+	batches, wait := readyBatches(entries, time.Now())
+	var done []int
+	var err error
+	for _, batch := range batches {
+		event, ok := mergeBatch(entries, batch)
+		if ok {
+			if err = w.publisher.PublishPost(ctx, event); err != nil {
+				break
+			}
+			myLogger.Info("Опубликовали пост канала", slog.String("type", event.Type), slog.String("channel", event.Channel), slog.Int("id", event.Message.ID))
+		}
+		done = append(done, batch...)
+	}
+	if removeErr := w.outbox.Remove(done); removeErr != nil {
+		err = errors.Join(err, removeErr)
+	}
+	return wait, err
+}
+
+// readyBatches делит очередь на посты, готовые к публикации: каждое событие — отдельный пост,
+// а вложения нового альбома — один пост, когда с первого вложения прошло albumWait. Незаконченный
+// альбом останавливает разбор, чтобы посты публиковались в порядке поступления; вторым значением
+// возвращается, через сколько альбом будет готов.
+func readyBatches(entries []outboxEntry, now time.Time) ([][]int, time.Duration) {
+	var batches [][]int
+	taken := make(map[int]bool)
+	for i, entry := range entries {
+		if taken[i] {
+			continue
+		}
+		event := entry.Event
+		if event.Type != tg_post_model.PostEventNew || event.Message.GroupedID == 0 {
+			batches = append(batches, []int{i})
+			continue
+		}
+		if wait := entry.QueuedAt.Add(albumWait).Sub(now); wait > 0 {
+			return batches, wait
+		}
+
+		batch := []int{i}
+		for j := i + 1; j < len(entries); j++ {
+			other := entries[j].Event
+			if other.Type == tg_post_model.PostEventNew && other.ChannelID == event.ChannelID && other.Message.GroupedID == event.Message.GroupedID {
+				batch = append(batch, j)
+				taken[j] = true
+			}
+		}
+		batches = append(batches, batch)
+	}
+	return batches, 0
+}
+
+// mergeBatch собирает событие для публикации: вложения альбома объединяются в один пост, а альбом без подписи
+// описывается по вложениям; второе значение ложно, если в посте нечего озвучивать
+func mergeBatch(entries []outboxEntry, batch []int) (tg_post_model.PostEvent, bool) {
+	event := entries[batch[0]].Event
+	if event.Message.GroupedID != 0 {
+		messages := make([]tg_post_model.Message, 0, len(batch))
+		for _, i := range batch {
+			messages = append(messages, entries[i].Event.Message)
+		}
+		event.Message = service_parser.MergeAlbums(messages)[0]
+	}
+	event.Message.CleanText = service_parser.PostText(event.Message)
+	return event, event.Message.CleanText != ""
+}
@@ -0,0 +1,101 @@
+package channel_watcher
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tg_app_micserv/internal/model"
+)
+
+// fakePublisher публикатор, который отказывает, пока задана ошибка
+type fakePublisher struct {
+	err    error
+	events []tg_post_model.PostEvent
+}
+
+func (p *fakePublisher) PublishPost(_ context.Context, event tg_post_model.PostEvent) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+func newEvent(id int, groupedID int64, text string) tg_post_model.PostEvent {
+	return tg_post_model.PostEvent{
+		Type:      tg_post_model.PostEventNew,
+		Channel:   "news",
+		ChannelID: 1,
+		Message:   tg_post_model.Message{ID: id, Text: text, MediaType: tg_post_model.MediaPhoto, GroupedID: groupedID},
+	}
+}
+
+func TestFlushKeepsPostsUntilPublished(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json.outbox")
+	outbox, err := NewFileOutbox(path)
+	if err != nil {
+		t.Fatalf("не удалось открыть очередь: %v", err)
+	}
+	publisher := &fakePublisher{err: errors.New("kafka недоступна")}
+	w := &Watcher{outbox: outbox, publisher: publisher}
+
+	for _, event := range []tg_post_model.PostEvent{newEvent(1, 0, "первый пост"), newEvent(2, 0, "второй пост")} {
+		if err := outbox.Add(event); err != nil {
+			t.Fatalf("не удалось поставить пост в очередь: %v", err)
+		}
+	}
+
+	// Пока Kafka недоступна, посты остаются в очереди и переживают перезапуск
+	if _, err := w.flush(context.Background()); err == nil {
+		t.Fatal("ожидали ошибку публикации")
+	}
+	reopened, err := NewFileOutbox(path)
+	if err != nil {
+		t.Fatalf("не удалось открыть очередь заново: %v", err)
+	}
+	if len(reopened.Pending()) != 2 {
+		t.Fatalf("ожидали 2 поста в очереди, получили %d", len(reopened.Pending()))
+	}
+
+	publisher.err = nil
+	w.outbox = reopened
+	if _, err := w.flush(context.Background()); err != nil {
+		t.Fatalf("неожиданная ошибка: %v", err)
+	}
+	if len(publisher.events) != 2 || publisher.events[0].Message.ID != 1 || publisher.events[1].Message.ID != 2 {
+		t.Fatalf("неожиданные события: %+v", publisher.events)
+	}
+	if len(reopened.Pending()) != 0 {
+		t.Fatalf("очередь не опустела: %+v", reopened.Pending())
+	}
+}
+
+func TestReadyBatchesMergesAlbum(t *testing.T) {
+	now := time.Now()
+	entries := []outboxEntry{
+		{Event: newEvent(10, 7, ""), QueuedAt: now.Add(-time.Minute)},
+		{Event: newEvent(11, 0, "пост между вложениями"), QueuedAt: now.Add(-time.Minute)},
+		{Event: newEvent(12, 7, ""), QueuedAt: now.Add(-time.Minute)},
+		{Event: newEvent(13, 8, ""), QueuedAt: now},
+		{Event: newEvent(14, 0, "после незаконченного альбома"), QueuedAt: now},
+	}
+
+	// Вложения альбома собираются в один пост, а незаконченный альбом задерживает следующие посты
+	batches, wait := readyBatches(entries, now)
+	if len(batches) != 2 || len(batches[0]) != 2 || batches[0][1] != 2 || batches[1][0] != 1 {
+		t.Fatalf("неожиданные пакеты: %v", batches)
+	}
+	if wait <= 0 || wait > albumWait {
+		t.Fatalf("неожиданное ожидание альбома: %v", wait)
+	}
+
+	// Альбом без подписи описывается по вложениям
+	event, ok := mergeBatch(entries, batches[0])
+	if !ok || event.Message.ID != 10 || event.Message.AlbumSize != 2 || !strings.Contains(event.Message.CleanText, "Альбом") {
+		t.Fatalf("неожиданный пост альбома: %+v", event.Message)
+	}
+}
@@ -0,0 +1,113 @@
+// Очередь событий о постах, ещё не опубликованных в Kafka, в файле
+
+package channel_watcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"tg_app_micserv/internal/model"
+)
+
+// outboxEntry событие в очереди вместе со временем постановки
+type outboxEntry struct {
+	Event    tg_post_model.PostEvent `json:"event"`
+	QueuedAt time.Time               `json:"queued_at"`
+}
+
+// FileOutbox хранит события о постах до их публикации в Kafka. Событие попадает в файл раньше,
+// чем менеджер обновлений сохранит pts канала, поэтому ни недоступность Kafka, ни перезапуск сервиса
+// пост не теряют; после сбоя между публикацией и удалением из очереди событие может уйти повторно.
+type FileOutbox struct {
+	path    string
+	mutex   sync.Mutex
+	entries []outboxEntry
+	added   chan struct{} // Сигнал о новом событии в очереди
+}
+
+// NewFileOutbox открывает очередь в указанном файле; если файла нет, очередь создаётся пустой
+func NewFileOutbox(path string) (*FileOutbox, error) {
+	o := &FileOutbox{
+		path:  path,
+		added: make(chan struct{}, 1),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать очередь публикации %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &o.entries); err != nil {
+		return nil, fmt.Errorf("некорректная очередь публикации %s: %w", path, err)
+	}
+	return o, nil
+}
+
+// Add ставит событие в конец очереди и сохраняет файл
+func (o *FileOutbox) Add(event tg_post_model.PostEvent) error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.entries = append(o.entries, outboxEntry{Event: event, QueuedAt: time.Now()})
+	if err := o.save(); err != nil {
+		o.entries = o.entries[:len(o.entries)-1]
+		return err
+	}
+	select {
+	case o.added <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Pending возвращает копию очереди; индексы в ней действительны до следующего Remove
+func (o *FileOutbox) Pending() []outboxEntry {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return append([]outboxEntry(nil), o.entries...)
+}
+
+// Remove удаляет из очереди события с индексами из последнего Pending и сохраняет файл
+func (o *FileOutbox) Remove(indices []int) error {
+	if len(indices) == 0 {
+		return nil
+	}
+	removed := make(map[int]bool, len(indices))
+	for _, i := range indices {
+		removed[i] = true
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	kept := make([]outboxEntry, 0, len(o.entries))
+	for i, entry := range o.entries {
+		if !removed[i] {
+			kept = append(kept, entry)
+		}
+	}
+	o.entries = kept
+	return o.save()
+}
+
+// Added возвращает канал, в который приходит сигнал о новых событиях
+func (o *FileOutbox) Added() <-chan struct{} {
+	return o.added
+}
+
+// save записывает очередь в файл; вызывается под блокировкой
+func (o *FileOutbox) save() error {
+	data, err := json.Marshal(o.entries)
+	if err != nil {
+		return fmt.Errorf("не удалось сериализовать очередь публикации: %w", err)
+	}
+	if err := writeFileAtomic(o.path, data); err != nil {
+		return fmt.Errorf("не удалось сохранить очередь публикации: %w", err)
+	}
+	return nil
+}
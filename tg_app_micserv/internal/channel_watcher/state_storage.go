@@ -0,0 +1,226 @@
+// Хранилище состояния обновлений Telegram в файле
+
+package channel_watcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gotd/td/telegram/updates"
+)
+
+// userState состояние обновлений одного аккаунта
+type userState struct {
+	State        *updates.State  `json:"state"`         // Общие счётчики pts/qts/seq/date (nil — ещё не получены)
+	ChannelPts   map[int64]int   `json:"channel_pts"`   // pts каждого канала
+	AccessHashes map[int64]int64 `json:"access_hashes"` // access hash каналов для getChannelDifference
+}
+
+// FileStateStorage хранит состояние менеджера обновлений в JSON-файле,
+// чтобы после перезапуска дозапросить пропущенные обновления через getDifference
+type FileStateStorage struct {
+	path  string
+	mutex sync.Mutex
+	users map[int64]*userState
+}
+
+var (
+	_ updates.StateStorage        = (*FileStateStorage)(nil)
+	_ updates.ChannelAccessHasher = (*FileStateStorage)(nil)
+)
+
+// errStateNotFound возвращается при изменении состояния, которое ещё не создано
+var errStateNotFound = errors.New("состояние обновлений не найдено")
+
+// NewFileStateStorage открывает хранилище в указанном файле; если файла нет, хранилище создаётся пустым
+func NewFileStateStorage(path string) (*FileStateStorage, error) {
+	s := &FileStateStorage{
+		path:  path,
+		users: make(map[int64]*userState),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать состояние обновлений %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &s.users); err != nil {
+		return nil, fmt.Errorf("некорректное состояние обновлений %s: %w", path, err)
+	}
+	for _, u := range s.users {
+		if u.ChannelPts == nil {
+			u.ChannelPts = make(map[int64]int)
+		}
+		if u.AccessHashes == nil {
+			u.AccessHashes = make(map[int64]int64)
+		}
+	}
+	return s, nil
+}
+
+// GetState возвращает состояние аккаунта
+func (s *FileStateStorage) GetState(_ context.Context, userID int64) (updates.State, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	u, ok := s.users[userID]
+	if !ok || u.State == nil {
+		return updates.State{}, false, nil
+	}
+	return *u.State, true, nil
+}
+
+// SetState сохраняет состояние аккаунта и сбрасывает pts каналов
+func (s *FileStateStorage) SetState(_ context.Context, userID int64, state updates.State) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	u, ok := s.users[userID]
+	if !ok {
+		u = &userState{AccessHashes: make(map[int64]int64)}
+		s.users[userID] = u
+	}
+	u.State = &state
+	u.ChannelPts = make(map[int64]int)
+	return s.save()
+}
+
+// SetPts сохраняет pts аккаунта
+func (s *FileStateStorage) SetPts(_ context.Context, userID int64, pts int) error {
+	return s.update(userID, func(u *userState) { u.State.Pts = pts })
+}
+
+// SetQts сохраняет qts аккаунта
+func (s *FileStateStorage) SetQts(_ context.Context, userID int64, qts int) error {
+	return s.update(userID, func(u *userState) { u.State.Qts = qts })
+}
+
+// SetDate сохраняет date аккаунта
+func (s *FileStateStorage) SetDate(_ context.Context, userID int64, date int) error {
+	return s.update(userID, func(u *userState) { u.State.Date = date })
+}
+
+// SetSeq сохраняет seq аккаунта
+func (s *FileStateStorage) SetSeq(_ context.Context, userID int64, seq int) error {
+	return s.update(userID, func(u *userState) { u.State.Seq = seq })
+}
+
+// SetDateSeq сохраняет date и seq аккаунта
+func (s *FileStateStorage) SetDateSeq(_ context.Context, userID int64, date, seq int) error {
+	return s.update(userID, func(u *userState) {
+		u.State.Date = date
+		u.State.Seq = seq
+	})
+}
+
+// GetChannelPts возвращает pts канала
+func (s *FileStateStorage) GetChannelPts(_ context.Context, userID, channelID int64) (int, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	u, ok := s.users[userID]
+	if !ok {
+		return 0, false, nil
+	}
+	pts, found := u.ChannelPts[channelID]
+	return pts, found, nil
+}
+
+// SetChannelPts сохраняет pts канала
+func (s *FileStateStorage) SetChannelPts(_ context.Context, userID, channelID int64, pts int) error {
+	return s.update(userID, func(u *userState) { u.ChannelPts[channelID] = pts })
+}
+
+// ForEachChannels перебирает сохранённые pts каналов
+func (s *FileStateStorage) ForEachChannels(ctx context.Context, userID int64, f func(ctx context.Context, channelID int64, pts int) error) error {
+	s.mutex.Lock()
+	u, ok := s.users[userID]
+	if !ok || u.State == nil {
+		s.mutex.Unlock()
+		return errStateNotFound
+	}
+	channels := make(map[int64]int, len(u.ChannelPts))
+	for id, pts := range u.ChannelPts {
+		channels[id] = pts
+	}
+	s.mutex.Unlock()
+
+	for id, pts := range channels {
+		if err := f(ctx, id, pts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetChannelAccessHash возвращает access hash канала
+func (s *FileStateStorage) GetChannelAccessHash(_ context.Context, userID, channelID int64) (int64, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	u, ok := s.users[userID]
+	if !ok {
+		return 0, false, nil
+	}
+	hash, found := u.AccessHashes[channelID]
+	return hash, found, nil
+}
+
+// SetChannelAccessHash сохраняет access hash канала
+func (s *FileStateStorage) SetChannelAccessHash(_ context.Context, userID, channelID, accessHash int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	u, ok := s.users[userID]
+	if !ok {
+		// access hash может прийти раньше, чем состояние аккаунта будет загружено
+		u = &userState{ChannelPts: make(map[int64]int), AccessHashes: make(map[int64]int64)}
+		s.users[userID] = u
+	}
+	if u.AccessHashes[channelID] == accessHash {
+		return nil
+	}
+	u.AccessHashes[channelID] = accessHash
+	return s.save()
+}
+
+// update изменяет существующее состояние аккаунта и сохраняет файл
+func (s *FileStateStorage) update(userID int64, change func(u *userState)) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	u, ok := s.users[userID]
+	if !ok || u.State == nil {
+		return errStateNotFound
+	}
+	change(u)
+	return s.save()
+}
+
+// save атомарно записывает состояние в файл
+func (s *FileStateStorage) save() error {
+	data, err := json.Marshal(s.users)
+	if err != nil {
+		return fmt.Errorf("не удалось сериализовать состояние обновлений: %w", err)
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("не удалось сохранить состояние обновлений: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic записывает файл атомарно: сначала во временный файл, затем переименование
+func writeFileAtomic(path string, data []byte) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
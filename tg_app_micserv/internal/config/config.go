@@ -3,23 +3,55 @@
 package config
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	"tg_app_micserv/internal/speech_budget"
 	"tg_app_micserv/tools/logger"
 )
 
+// AccountConfig содержит параметры одного Telegram-аккаунта
+type AccountConfig struct {
+	Name        string `json:"name"`         // Имя аккаунта для логов и админского API
+	APIID       int    `json:"api_id"`       // API ID приложения Telegram
+	APIHash     string `json:"api_hash"`     // API Hash приложения Telegram
+	Phone       string `json:"phone"`        // Номер телефона аккаунта
+	Password    string `json:"password"`     // Пароль двухфакторной аутентификации
+	SessionFile string `json:"session_file"` // Файл сессии аккаунта
+}
+
 // Config содержит конфиг микросервиса
 type Config struct {
-	API_ID         int
-	API_Hash       string
-	Phone          string
-	Two_F_Password string
-	Port           string
-	KafkaPort      string
-	KafkaTopic     string
+	Accounts        []AccountConfig // Telegram-аккаунты для парсинга; первый используется для отслеживания каналов
+	Port            string
+	KafkaPort       string
+	KafkaTopic      string
+	RequestTopic    string        // Kafka-топик запросов дайджеста от Telegram-бота (пусто — запросы бота не читаются)
+	KafkaGroupID    string        // Группа консьюмеров Kafka для запросов бота
+	JobQueueSize    int           // Максимальное количество задач парсинга в очереди
+	JobTimeout      time.Duration // Максимальное время выполнения задачи парсинга и синхронного запроса /post_parser
+	JobTTL          time.Duration // Время хранения завершённой задачи
+	CallbackHosts   []string      // Хосты, на которые разрешены уведомления callback (пусто — любые с публичными адресами)
+	JoinInvites     bool          // Вступать в каналы по пригласительным ссылкам
+	PeerCacheFile   string        // Файл кеша найденных каналов
+	PeerCacheTTL    time.Duration // Время, после которого канал запрашивается у Telegram заново
+	FloodWaitBudget time.Duration // Максимальное ожидание FLOOD_WAIT в рамках одного запроса
+	RateLimitRPS    float64       // Допустимое количество запросов к Telegram в секунду
+	RateLimitBurst  int           // Запас запросов сверх RateLimitRPS
+	WatchChannels   []string      // Каналы для отслеживания в реальном времени (пусто — отслеживание выключено)
+	WatchTopic      string        // Kafka-топик для новых и отредактированных постов
+	WatchStateFile  string        // Файл состояния обновлений Telegram для восстановления пропусков
+	QuarantineTime  time.Duration // Время исключения аккаунта из пула после ошибок подряд
+	AdminToken      string        // Токен админского API (пусто — админский API выключен)
+	ArchiveFile     string        // Файл архива постов с полнотекстовым поиском (пусто — архив выключен; нужна сборка с тегом sqlite_fts5)
+	ArchiveFresh    time.Duration // Как долго загруженный период считается актуальным и отдаётся из архива
+	SpeechChars     float64       // Сколько символов текста голос произносит в секунду (для оценки длительности)
+	SpeechOverhead  time.Duration // Сколько добавляют к каждому посту объявление и паузы (для оценки длительности)
 }
 
 // Load загружает данные из переменных среды
@@ -27,34 +59,11 @@ func Load() (*Config, error) {
 	const lbl = "tg_app_micserv/config/config.go/Load()"
 	myLogger := logger.NewColorLogger(lbl)
 
-	apiIDStr := os.Getenv("TELEGRAM_API_ID")
-	if apiIDStr == "" {
-		return nil, errors.New("TELEGRAM_API_ID не указан")
-	}
-	apiID, err := strconv.Atoi(apiIDStr)
-
+	accounts, err := loadAccounts()
 	if err != nil {
-		return nil, fmt.Errorf("TELEGRAM_API_ID не указан: %w", err)
-	}
-	myLogger.Info("Успешно прочитали TELEGRAM_API_ID")
-
-	apiHash := os.Getenv("TELEGRAM_API_HASH")
-	if apiHash == "" {
-		return nil, errors.New("TELEGRAM_API_HASH не указан")
+		return nil, err
 	}
-	myLogger.Info("Успешно прочитали TELEGRAM_API_HASH")
-
-	phone := os.Getenv("PHONE")
-	if phone == "" {
-		return nil, errors.New("PHONE не указан")
-	}
-	myLogger.Info("Успешно прочитали PHONE")
-
-	password := os.Getenv("TELEGRAM_PASSWORD")
-	if password == "" {
-		return nil, errors.New("TELEGRAM_PASSWORD не указан")
-	}
-	myLogger.Info("Успешно прочитали TELEGRAM_PASSWORD")
+	myLogger.Info("Успешно прочитали Telegram-аккаунты", "accounts", len(accounts))
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -72,15 +81,232 @@ func Load() (*Config, error) {
 	if kafkaTopic == "" {
 		return nil, errors.New("KAFKA_TOPIC не указан")
 	}
+	requestTopic := os.Getenv("REQUEST_KAFKA_TOPIC")
+	kafkaGroupID := os.Getenv("KAFKA_GROUP_ID")
+	if kafkaGroupID == "" {
+		kafkaGroupID = "tg_app"
+	}
 	myLogger.Info("Успешно прочитали KAFKA_TOPIC")
 
+	jobQueueSize, err := intFromEnv("JOB_QUEUE_SIZE", 100)
+	if err != nil {
+		return nil, err
+	}
+	jobTimeoutSec, err := intFromEnv("JOB_TIMEOUT_SEC", 300)
+	if err != nil {
+		return nil, err
+	}
+	jobTTLMin, err := intFromEnv("JOB_TTL_MIN", 60)
+	if err != nil {
+		return nil, err
+	}
+	myLogger.Info("Успешно прочитали параметры задач парсинга")
+
+	joinInvites := false
+	if v := os.Getenv("JOIN_INVITE_LINKS"); v != "" {
+		joinInvites, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("JOIN_INVITE_LINKS указан неверно: %w", err)
+		}
+	}
+	myLogger.Info("Успешно прочитали JOIN_INVITE_LINKS")
+
+	peerCacheFile := os.Getenv("PEER_CACHE_FILE")
+	if peerCacheFile == "" {
+		peerCacheFile = "peers.db"
+	}
+	peerCacheTTLHours, err := intFromEnv("PEER_CACHE_TTL_HOURS", 24)
+	if err != nil {
+		return nil, err
+	}
+	myLogger.Info("Успешно прочитали параметры кеша каналов")
+
+	floodWaitBudgetSec, err := intFromEnv("FLOOD_WAIT_BUDGET_SEC", 60)
+	if err != nil {
+		return nil, err
+	}
+	rateLimitRPS := 5.0
+	if v := os.Getenv("TG_RATE_LIMIT_RPS"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("TG_RATE_LIMIT_RPS указан неверно: %q", v)
+		}
+		rateLimitRPS = parsed
+	}
+	rateLimitBurst, err := intFromEnv("TG_RATE_LIMIT_BURST", 5)
+	if err != nil {
+		return nil, err
+	}
+	myLogger.Info("Успешно прочитали параметры ограничения запросов к Telegram")
+
+	var callbackHosts []string
+	for _, host := range strings.Split(os.Getenv("CALLBACK_HOSTS"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			callbackHosts = append(callbackHosts, host)
+		}
+	}
+
+	var watchChannels []string
+	for _, channel := range strings.Split(os.Getenv("WATCH_CHANNELS"), ",") {
+		if channel = strings.TrimSpace(channel); channel != "" {
+			watchChannels = append(watchChannels, channel)
+		}
+	}
+	watchTopic := os.Getenv("WATCH_KAFKA_TOPIC")
+	if len(watchChannels) > 0 && watchTopic == "" {
+		return nil, errors.New("WATCH_KAFKA_TOPIC не указан")
+	}
+	watchStateFile := os.Getenv("WATCH_STATE_FILE")
+	if watchStateFile == "" {
+		watchStateFile = "updates_state.json"
+	}
+	myLogger.Info("Успешно прочитали параметры отслеживания каналов")
+
+	quarantineMin, err := intFromEnv("ACCOUNT_QUARANTINE_MIN", 10)
+	if err != nil {
+		return nil, err
+	}
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	myLogger.Info("Успешно прочитали параметры пула аккаунтов")
+
+	// Архив по умолчанию выключен: ему нужен SQLite с FTS5, то есть сборка с CGO_ENABLED=1 и -tags sqlite_fts5
+	archiveFile := os.Getenv("ARCHIVE_FILE")
+	archiveFreshMin, err := intFromEnv("ARCHIVE_FRESHNESS_MIN", 10)
+	if err != nil {
+		return nil, err
+	}
+	myLogger.Info("Успешно прочитали параметры архива постов")
+
+	speechChars := speech_budget.DefaultCharsPerSecond
+	if v := os.Getenv("SPEECH_CHARS_PER_SECOND"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("SPEECH_CHARS_PER_SECOND указан неверно: %q", v)
+		}
+		speechChars = parsed
+	}
+	speechOverheadMs, err := intFromEnv("SPEECH_POST_OVERHEAD_MS", int(speech_budget.DefaultPostOverhead/time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	myLogger.Info("Успешно прочитали параметры оценки длительности озвучивания")
+
 	return &Config{
-		API_ID:         apiID,
-		API_Hash:       apiHash,
-		Phone:          phone,
-		Two_F_Password: password,
-		Port:           port,
-		KafkaPort:      kafkaPort,
-		KafkaTopic:     kafkaTopic,
+		Accounts:        accounts,
+		Port:            port,
+		KafkaPort:       kafkaPort,
+		KafkaTopic:      kafkaTopic,
+		RequestTopic:    requestTopic,
+		KafkaGroupID:    kafkaGroupID,
+		JobQueueSize:    jobQueueSize,
+		JobTimeout:      time.Duration(jobTimeoutSec) * time.Second,
+		JobTTL:          time.Duration(jobTTLMin) * time.Minute,
+		CallbackHosts:   callbackHosts,
+		JoinInvites:     joinInvites,
+		PeerCacheFile:   peerCacheFile,
+		PeerCacheTTL:    time.Duration(peerCacheTTLHours) * time.Hour,
+		FloodWaitBudget: time.Duration(floodWaitBudgetSec) * time.Second,
+		RateLimitRPS:    rateLimitRPS,
+		RateLimitBurst:  rateLimitBurst,
+		WatchChannels:   watchChannels,
+		WatchTopic:      watchTopic,
+		WatchStateFile:  watchStateFile,
+		QuarantineTime:  time.Duration(quarantineMin) * time.Minute,
+		AdminToken:      adminToken,
+		ArchiveFile:     archiveFile,
+		ArchiveFresh:    time.Duration(archiveFreshMin) * time.Minute,
+		SpeechChars:     speechChars,
+		SpeechOverhead:  time.Duration(speechOverheadMs) * time.Millisecond,
 	}, nil
 }
+
+// loadAccounts читает аккаунты из JSON-файла TELEGRAM_ACCOUNTS_FILE,
+// а если он не задан — один аккаунт из переменных TELEGRAM_API_ID, TELEGRAM_API_HASH, PHONE и TELEGRAM_PASSWORD
+func loadAccounts() ([]AccountConfig, error) {
+	path := os.Getenv("TELEGRAM_ACCOUNTS_FILE")
+	if path == "" {
+		account, err := accountFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return []AccountConfig{account}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать TELEGRAM_ACCOUNTS_FILE: %w", err)
+	}
+	var accounts []AccountConfig
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return nil, fmt.Errorf("TELEGRAM_ACCOUNTS_FILE указан неверно: %w", err)
+	}
+	if len(accounts) == 0 {
+		return nil, errors.New("в TELEGRAM_ACCOUNTS_FILE нет аккаунтов")
+	}
+
+	names := make(map[string]bool)
+	for i, account := range accounts {
+		switch {
+		case account.Name == "":
+			return nil, fmt.Errorf("у аккаунта №%d не указан name", i+1)
+		case names[account.Name]:
+			return nil, fmt.Errorf("аккаунт %s указан дважды", account.Name)
+		case account.APIID == 0 || account.APIHash == "" || account.Phone == "":
+			return nil, fmt.Errorf("у аккаунта %s не указаны api_id, api_hash или phone", account.Name)
+		}
+		names[account.Name] = true
+		if account.SessionFile == "" {
+			accounts[i].SessionFile = "session_" + account.Name + ".json"
+		}
+	}
+	return accounts, nil
+}
+
+// accountFromEnv читает единственный аккаунт из переменных среды
+func accountFromEnv() (AccountConfig, error) {
+	apiIDStr := os.Getenv("TELEGRAM_API_ID")
+	if apiIDStr == "" {
+		return AccountConfig{}, errors.New("TELEGRAM_API_ID не указан")
+	}
+	apiID, err := strconv.Atoi(apiIDStr)
+	if err != nil {
+		return AccountConfig{}, fmt.Errorf("TELEGRAM_API_ID не указан: %w", err)
+	}
+
+	apiHash := os.Getenv("TELEGRAM_API_HASH")
+	if apiHash == "" {
+		return AccountConfig{}, errors.New("TELEGRAM_API_HASH не указан")
+	}
+
+	phone := os.Getenv("PHONE")
+	if phone == "" {
+		return AccountConfig{}, errors.New("PHONE не указан")
+	}
+
+	password := os.Getenv("TELEGRAM_PASSWORD")
+	if password == "" {
+		return AccountConfig{}, errors.New("TELEGRAM_PASSWORD не указан")
+	}
+
+	return AccountConfig{
+		Name:        "default",
+		APIID:       apiID,
+		APIHash:     apiHash,
+		Phone:       phone,
+		Password:    password,
+		SessionFile: "session.json",
+	}, nil
+}
+
+// intFromEnv читает положительное целое из переменной среды, если она не задана — возвращает значение по умолчанию
+func intFromEnv(name string, def int) (int, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s указан неверно: %q", name, v)
+	}
+	return n, nil
+}
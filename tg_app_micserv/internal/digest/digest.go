@@ -0,0 +1,127 @@
+// Режимы дайджеста: полный текст, заголовки и краткое содержание постов
+
+package digest
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"tg_app_micserv/internal/model"
+)
+
+// Режимы дайджеста
+const (
+	ModeFull      = "full"      // Пост целиком
+	ModeHeadlines = "headlines" // Только заголовок: выделенное жирным начало поста или первое предложение
+	ModeSummary   = "summary"   // Краткое содержание: самые важные предложения поста по TextRank
+)
+
+// DefaultSummaryLength длина краткого содержания поста в символах по умолчанию
+const DefaultSummaryLength = 300
+
+// ErrInvalidMode возвращается при неизвестном режиме дайджеста
+var ErrInvalidMode = errors.New("режим дайджеста должен быть full, headlines или summary, а summary_length — неотрицательным")
+
+// Validate проверяет режим дайджеста из запроса
+func Validate(options tg_post_model.ParseOptions) error {
+	switch options.Mode {
+	case "", ModeFull, ModeHeadlines, ModeSummary:
+	default:
+		return ErrInvalidMode
+	}
+	if options.SummaryLength < 0 {
+		return ErrInvalidMode
+	}
+	return nil
+}
+
+// Apply сокращает очищенный текст поста по режиму дайджеста; title — очищенный заголовок поста, если он есть
+func Apply(text, title string, options tg_post_model.ParseOptions) string {
+	switch options.Mode {
+	case ModeHeadlines:
+		return Headline(text, title)
+	case ModeSummary:
+		length := options.SummaryLength
+		if length == 0 {
+			length = DefaultSummaryLength
+		}
+		return Summarize(text, length)
+	}
+	return text
+}
+
+// Headline возвращает заголовок поста, а если его нет — первое предложение
+func Headline(text, title string) string {
+	if title = strings.TrimSpace(title); title != "" {
+		return title
+	}
+	sentences := Sentences(text)
+	if len(sentences) == 0 {
+		return ""
+	}
+	return sentences[0]
+}
+
+// Summarize выбирает самые важные предложения текста так, чтобы уложиться примерно в length символов.
+// Предложения ранжируются алгоритмом TextRank и выводятся в исходном порядке; короткий текст не меняется.
+func Summarize(text string, length int) string {
+	if utf8.RuneCountInString(text) <= length {
+		return text
+	}
+	sentences := Sentences(text)
+	if len(sentences) <= 1 {
+		return text
+	}
+
+	scores := textRank(sentences)
+	order := rankOrder(scores)
+
+	selected := make([]bool, len(sentences))
+	total := 0
+	for n, i := range order {
+		size := utf8.RuneCountInString(sentences[i])
+		// Самое важное предложение берём всегда, остальные — пока помещаются;
+		// предложения, не связанные с остальным текстом, в краткое содержание не попадают
+		if n > 0 && (total+size > length || scores[i] <= 1-damping) {
+			continue
+		}
+		selected[i] = true
+		total += size + 1
+	}
+
+	var result []string
+	for i, sentence := range sentences {
+		if selected[i] {
+			result = append(result, sentence)
+		}
+	}
+	return strings.Join(result, " ")
+}
+
+// Sentences делит текст на предложения по знакам конца предложения и переводам строк
+func Sentences(text string) []string {
+	var sentences []string
+	var current strings.Builder
+	flush := func() {
+		if sentence := strings.TrimSpace(current.String()); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		current.Reset()
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		if r == '\n' {
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+		// Конец предложения — знак препинания, за которым идёт пробел или конец текста
+		if strings.ContainsRune(".!?…", r) && (i+1 == len(runes) || runes[i+1] == ' ' || runes[i+1] == '\n') {
+			flush()
+		}
+	}
+	flush()
+	return sentences
+}
@@ -0,0 +1,40 @@
+package digest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHeadline(t *testing.T) {
+	text := "Минфин снизил ставку. Подробности в статье.\nЕщё строка."
+	if got := Headline(text, ""); got != "Минфин снизил ставку." {
+		t.Fatalf("получили %q", got)
+	}
+	if got := Headline(text, "Главное за день"); got != "Главное за день" {
+		t.Fatalf("заголовок должен иметь приоритет, получили %q", got)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	text := "Центральный банк снизил ключевую ставку до шестнадцати процентов. " +
+		"Погода в столице остаётся солнечной. " +
+		"Снижение ключевой ставки удешевит кредиты для бизнеса. " +
+		"Банк объяснил снижение ставки замедлением инфляции. " +
+		"Вечером ожидается концерт в парке."
+
+	summary := Summarize(text, 150)
+	if len([]rune(summary)) > 150+50 {
+		t.Fatalf("краткое содержание слишком длинное: %q", summary)
+	}
+	if !strings.Contains(summary, "ставк") {
+		t.Fatalf("ожидали предложения о ставке, получили %q", summary)
+	}
+	if strings.Contains(summary, "концерт") || strings.Contains(summary, "Погода") {
+		t.Fatalf("в краткое содержание попали посторонние предложения: %q", summary)
+	}
+
+	short := "Короткий пост."
+	if got := Summarize(short, 150); got != short {
+		t.Fatalf("короткий текст не должен меняться, получили %q", got)
+	}
+}
@@ -0,0 +1,142 @@
+// Ранжирование предложений алгоритмом TextRank
+
+package digest
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Параметры TextRank: коэффициент затухания PageRank, максимум итераций и точность сходимости
+const (
+	damping       = 0.85
+	maxIterations = 50
+	tolerance     = 1e-6
+)
+
+// stopWords частые служебные слова русского языка, не несущие смысла для сравнения предложений
+var stopWords = toSet(`и в во не что он на я с со как а то все она так его но да ты к у же вы за бы по только ее мне
+было вот от меня еще нет о из ему теперь когда даже ну вдруг ли если уже или ни быть был него до вас нибудь опять
+уж вам ведь там потом себя ничего ей может они тут где есть надо ней для мы тебя их чем была сам чтоб без будто
+чего раз тоже себе под будет ж тогда кто этот того потому этого какой совсем ним здесь этом один почти мой тем
+чтобы нее сейчас были куда зачем всех никогда можно при наконец два об другой хоть после над больше тот через
+эти нас про всего них какая много разве три эту моя впрочем хорошо свою этой перед иногда лучше чуть том нельзя
+такой им более всегда конечно всю между это также который которые которая которых`)
+
+// endings окончания, которые отбрасываются при упрощённом выделении основы слова, от длинных к коротким
+var endings = []string{
+	"иями", "ями", "ами", "ого", "его", "ому", "ему", "ыми", "ими", "ией", "иях", "ах", "ях",
+	"ый", "ий", "ой", "ая", "яя", "ую", "юю", "ое", "ее", "ые", "ие", "ых", "их", "ом", "ем", "ам", "ям",
+	"ов", "ев", "ей", "ию", "ия", "ью", "ья", "ть", "ет", "ит", "ут", "ют", "ат", "ят", "ла", "ло", "ли",
+	"а", "я", "о", "е", "ы", "и", "у", "ю", "ь",
+}
+
+// textRank оценивает важность предложений: предложения — вершины графа, вес ребра — сходство
+// по общим словам, оценка — PageRank по этому графу
+func textRank(sentences []string) []float64 {
+	words := make([]map[string]bool, len(sentences))
+	for i, sentence := range sentences {
+		words[i] = stems(sentence)
+	}
+
+	n := len(sentences)
+	weights := make([][]float64, n)
+	outSum := make([]float64, n)
+	for i := range weights {
+		weights[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			w := similarity(words[i], words[j])
+			weights[i][j], weights[j][i] = w, w
+			outSum[i] += w
+			outSum[j] += w
+		}
+	}
+
+	scores := make([]float64, n)
+	for i := range scores {
+		scores[i] = 1
+	}
+	for iter := 0; iter < maxIterations; iter++ {
+		next := make([]float64, n)
+		delta := 0.0
+		for i := 0; i < n; i++ {
+			sum := 0.0
+			for j := 0; j < n; j++ {
+				if weights[j][i] > 0 {
+					sum += weights[j][i] / outSum[j] * scores[j]
+				}
+			}
+			next[i] = 1 - damping + damping*sum
+			delta += math.Abs(next[i] - scores[i])
+		}
+		scores = next
+		if delta < tolerance {
+			break
+		}
+	}
+	return scores
+}
+
+// rankOrder возвращает индексы предложений по убыванию оценки; при равенстве раньше идёт более раннее предложение
+func rankOrder(scores []float64) []int {
+	order := make([]int, len(scores))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+	return order
+}
+
+// similarity сходство предложений из оригинальной статьи TextRank: число общих слов,
+// нормированное на логарифмы длин, чтобы длинные предложения не выигрывали только за счёт длины
+func similarity(a, b map[string]bool) float64 {
+	if len(a) < 2 || len(b) < 2 {
+		return 0
+	}
+	common := 0
+	for word := range a {
+		if b[word] {
+			common++
+		}
+	}
+	if common == 0 {
+		return 0
+	}
+	return float64(common) / (math.Log(float64(len(a))) + math.Log(float64(len(b))))
+}
+
+// stems выделяет основы значимых слов предложения
+func stems(sentence string) map[string]bool {
+	result := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(sentence), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		word = strings.ReplaceAll(word, "ё", "е")
+		if stopWords[word] || len([]rune(word)) < 3 {
+			continue
+		}
+		result[stem(word)] = true
+	}
+	return result
+}
+
+// stem отбрасывает окончание слова, оставляя основу не короче трёх букв
+func stem(word string) string {
+	for _, ending := range endings {
+		if base, ok := strings.CutSuffix(word, ending); ok && len([]rune(base)) >= 3 {
+			return base
+		}
+	}
+	return word
+}
+
+// toSet превращает список слов через пробел в множество
+func toSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
@@ -0,0 +1,194 @@
+// Админские HTTP обработчики
+
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"tg_app_micserv/internal/account_pool"
+	"tg_app_micserv/internal/tg_login"
+	"tg_app_micserv/tools/logger"
+)
+
+// AdminHandler обрабатывает админские запросы; все они требуют заголовок Authorization: Bearer <токен>
+type AdminHandler struct {
+	token string             // Токен админского API (пусто — админский API выключен)
+	pool  *account_pool.Pool // Пул Telegram-аккаунтов
+	login *tg_login.Manager  // Вход в аккаунты и управление сессиями
+}
+
+// NewAdminHandler создает новый AdminHandler
+func NewAdminHandler(token string, pool *account_pool.Pool, login *tg_login.Manager) *AdminHandler {
+	return &AdminHandler{
+		token: token,
+		pool:  pool,
+		login: login,
+	}
+}
+
+// HandlerAccounts обрабатывает GET /admin/accounts: состояние аккаунтов пула
+func (h *AdminHandler) HandlerAccounts(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, h.pool.Health())
+}
+
+// HandlerLoginQR обрабатывает POST /admin/login/qr (запуск входа по QR-коду) и GET /admin/login/qr (этап входа).
+// Аккаунт задаётся параметром account, по умолчанию — первый аккаунт.
+func (h *AdminHandler) HandlerLoginQR(w http.ResponseWriter, r *http.Request) {
+	const lbl = "tg_app_micserv/internal/handlers/admin.go/HandlerLoginQR()"
+	myLogger := logger.NewColorLogger(lbl)
+
+	if !h.authorize(w, r) {
+		return
+	}
+
+	account := r.URL.Query().Get("account")
+	switch r.Method {
+	case http.MethodPost:
+		state, err := h.login.Start(account)
+		if err != nil {
+			writeError(w, loginStatus(err), err.Error())
+			return
+		}
+		myLogger.Info("Запустили вход по QR-коду", slog.String("account", state.Account))
+		writeJSON(w, http.StatusAccepted, state)
+	case http.MethodGet:
+		state, err := h.login.Status(account)
+		if err != nil {
+			writeError(w, loginStatus(err), err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, state)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// HandlerLoginQRImage обрабатывает GET /admin/login/qr.png: текущий QR-код входа.
+// Код меняется примерно раз в 30 секунд, время смены — в поле expires_at состояния входа.
+func (h *AdminHandler) HandlerLoginQRImage(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	image, err := h.login.QRCode(r.URL.Query().Get("account"))
+	if err != nil {
+		writeError(w, loginStatus(err), err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(image)
+}
+
+// PasswordRequest тело запроса с паролем двухфакторной аутентификации
+type PasswordRequest struct {
+	Account  string `json:"account,omitempty"` // Имя аккаунта (по умолчанию — первый)
+	Password string `json:"password"`          // Пароль двухфакторной аутентификации
+}
+
+// HandlerLoginPassword обрабатывает POST /admin/login/password: передаёт пароль двухфакторной аутентификации.
+// Результат проверки пароля возвращает GET /admin/login/qr.
+func (h *AdminHandler) HandlerLoginPassword(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req PasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Password == "" {
+		writeError(w, http.StatusBadRequest, "password is required")
+		return
+	}
+	if err := h.login.Password(req.Account, req.Password); err != nil {
+		writeError(w, loginStatus(err), err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// HandlerSession обрабатывает GET /admin/session (пользователь и активные сессии аккаунта)
+// и DELETE /admin/session (выход из аккаунта; с параметром hash — отзыв другой сессии)
+func (h *AdminHandler) HandlerSession(w http.ResponseWriter, r *http.Request) {
+	const lbl = "tg_app_micserv/internal/handlers/admin.go/HandlerSession()"
+	myLogger := logger.NewColorLogger(lbl)
+
+	if !h.authorize(w, r) {
+		return
+	}
+
+	account := r.URL.Query().Get("account")
+	switch r.Method {
+	case http.MethodGet:
+		session, err := h.login.Session(r.Context(), account)
+		if err != nil {
+			myLogger.Error("Не удалось получить сессию", slog.String("account", account), slog.Any("error", err))
+			writeError(w, loginStatus(err), err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, session)
+	case http.MethodDelete:
+		var hash int64
+		if hashStr := r.URL.Query().Get("hash"); hashStr != "" {
+			var err error
+			if hash, err = strconv.ParseInt(hashStr, 10, 64); err != nil || hash == 0 {
+				writeError(w, http.StatusBadRequest, "invalid hash parameter")
+				return
+			}
+		}
+		if err := h.login.Logout(r.Context(), account, hash); err != nil {
+			myLogger.Error("Не удалось завершить сессию", slog.String("account", account), slog.Any("error", err))
+			writeError(w, loginStatus(err), err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// loginStatus подбирает HTTP-статус для ошибки входа и управления сессией
+func loginStatus(err error) int {
+	switch {
+	case errors.Is(err, tg_login.ErrUnknownAccount), errors.Is(err, tg_login.ErrNoLogin):
+		return http.StatusNotFound
+	case errors.Is(err, tg_login.ErrNoQRCode), errors.Is(err, tg_login.ErrPasswordUnneeded):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// authorize проверяет токен админского API и при ошибке сам отвечает клиенту
+func (h *AdminHandler) authorize(w http.ResponseWriter, r *http.Request) bool {
+	// Без токена админский API не существует
+	if h.token == "" {
+		http.NotFound(w, r)
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return false
+	}
+	return true
+}
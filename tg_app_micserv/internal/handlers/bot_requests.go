@@ -0,0 +1,41 @@
+// Обработка запросов дайджеста от Telegram-бота из Kafka
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"tg_app_micserv/internal/model"
+	"tg_app_micserv/tools/logger"
+)
+
+// HandleBotRequest ставит в очередь задачу по запросу дайджеста от Telegram-бота. Готовый дайджест уходит
+// в Kafka сервису Text-to-Speech вместе с чатом и идентификатором запроса, чтобы бот доставил аудио пользователю
+func (h *MessageHandler) HandleBotRequest(_ context.Context, data []byte) error {
+	const lbl = "tg_app_micserv/internal/handlers/bot_requests.go/HandleBotRequest()"
+	myLogger := logger.NewColorLogger(lbl)
+
+	var req tg_post_model.BotRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return fmt.Errorf("некорректный запрос бота: %w", err)
+	}
+	if req.Channel == "" || req.ChatID == 0 {
+		return errors.New("в запросе бота не указан канал или чат")
+	}
+	options := req.Options(time.Now())
+	if err := validateOptions(options); err != nil {
+		return fmt.Errorf("некорректные параметры запроса бота %s: %w", req.RequestID, err)
+	}
+
+	job, err := h.jobs.Submit(req.Channel, float64(max(req.Hours, 1)), options, "")
+	if err != nil {
+		return fmt.Errorf("не удалось поставить в очередь запрос бота %s: %w", req.RequestID, err)
+	}
+	myLogger.Info("Поставили в очередь запрос бота", slog.String("request_id", req.RequestID), slog.String("job", job.ID))
+	return nil
+}
@@ -3,72 +3,403 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"tg_app_micserv/internal/account_pool"
+	"tg_app_micserv/internal/channel_ref"
+	"tg_app_micserv/internal/digest"
+	"tg_app_micserv/internal/jobs"
+	"tg_app_micserv/internal/model"
+	"tg_app_micserv/internal/post_archive"
+	"tg_app_micserv/internal/post_dedup"
+	"tg_app_micserv/internal/post_filter"
+	"tg_app_micserv/internal/post_format"
+	"tg_app_micserv/internal/post_rank"
+	"tg_app_micserv/internal/post_search"
 	"tg_app_micserv/internal/service_parser"
+	"tg_app_micserv/internal/speech_budget"
+	"tg_app_micserv/internal/tg_middleware"
 	"tg_app_micserv/tools/logger"
 )
 
 // MessageHandler обрабатывает HTTP-запросы, связанные с сообщениями
 type MessageHandler struct {
 	service *service_parser.ServiceParser
+	jobs    *jobs.Manager
+	archive *post_archive.Archive // Архив постов для поиска (nil — архив выключен)
 }
 
 // NewMessageHandler создает новый MessageHandler
-func NewMessageHandler(service *service_parser.ServiceParser) *MessageHandler {
-	return &MessageHandler{ // Возвращает структуру с внедрённым сервисом парсинга
+func NewMessageHandler(service *service_parser.ServiceParser, jobManager *jobs.Manager, archive *post_archive.Archive) *MessageHandler {
+	return &MessageHandler{ // Возвращает структуру с внедрённым сервисом парсинга, менеджером задач и архивом
 		service: service,
+		jobs:    jobManager,
+		archive: archive,
 	}
 }
 
-// HandlerPostParser обрабатывает конечную точку fetch-messages
+// HandlerPostParser обрабатывает конечную точку fetch-messages.
+// Формат ответа выбирается параметром format (json, text, atom) или заголовком Accept;
+// параметр kafka=true дополнительно отправляет посты в Kafka;
+// параметры top=N или percentile=P оставляют только посты с наибольшей вовлечённостью;
+// параметр mode (full, headlines, summary) и summary_length задают режим дайджеста;
+// параметр minutes подгоняет дайджест под длительность озвучивания со скоростью речи speaking_rate;
+// параметры include и exclude (можно повторять) задают фильтры по словам, #хештегам и /выражениям/,
+// skip_ads=true отбрасывает рекламу. Для сводного дайджеста channel можно повторить или перечислить
+// каналы через запятую; dedup (earliest, views) убирает повторы, dedup_mention=true упоминает другие каналы.
+// Параметр query включает поиск по каналу: from и to (дата или время RFC 3339) задают период вместо hours,
+// from_user оставляет только посты этого автора.
 func (h *MessageHandler) HandlerPostParser(w http.ResponseWriter, r *http.Request) {
 	const lbl = "tg_app_micserv/cmd/main.go/main()"
 	logger := logger.NewColorLogger(lbl)
 	slog.SetDefault(logger)
 
-	// Получаем значение параметра "channel" из строки запроса
-	channel := r.URL.Query().Get("channel")
+	// Получаем значение параметра "channel" из строки запроса; несколько каналов объединяются через запятую
+	channel := strings.Join(r.URL.Query()["channel"], ",")
 	if channel == "" {
 		http.Error(w, `{"error":"channel parameter is required"}`, http.StatusBadRequest)
 		return
 	}
 
-	// Получаем значение параметра период времени из строки запроса
+	options, err := parseOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Получаем значение параметра период времени из строки запроса; при поиске период может задать from
 	hoursStr := r.URL.Query().Get("hours")
-	if hoursStr == "" {
+	if hoursStr == "" && (!post_search.Enabled(options) || options.From == nil) {
 		http.Error(w, `{"error":"hours parameter is required"}`, http.StatusBadRequest)
 		return
 	}
 	// Преобразуем параметр "hours" из строки в число с плавающей точкой
-	hours, err := strconv.ParseFloat(hoursStr, 64)
+	var hours float64
+	if hoursStr != "" {
+		hours, err = strconv.ParseFloat(hoursStr, 64)
+		if err != nil {
+			http.Error(w, `{"error":"invalid hours parameter"}`, http.StatusBadRequest)
+			return
+		}
+	}
+
+	format, err := post_format.Negotiate(r)
 	if err != nil {
-		http.Error(w, `{"error":"invalid hours parameter"}`, http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// Вызываем метод сервиса для получения постов
-	messages, err := h.service.PostParser(context.Background(), channel, time.Duration(hours)*time.Hour)
+	messages, filtered, err := h.service.PostParser(r.Context(), channel, time.Duration(hours*float64(time.Hour)), options)
 	if err != nil {
-		response := APIResponse{Error: err.Error()}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
+		response := post_format.Response{Channel: channel, Error: err.Error()}
+		// Telegram ограничил запросы — сообщаем клиенту, когда можно повторить
+		var retryErr *tg_middleware.RetryAfterError
+		if errors.As(err, &retryErr) {
+			response.RetryAfter = retryErr.Seconds()
+			w.Header().Set("Retry-After", strconv.Itoa(response.RetryAfter))
+		}
+		writeJSON(w, parserStatus(err), response)
+		return
+	}
+
+	// Формируем успешный ответ с полученными сообщениями в выбранном формате
+	if err := post_format.Write(w, format, channel, messages, filtered); err != nil {
+		slog.Error("Не удалось отправить ответ", slog.Any("error", err))
+	}
+}
+
+// JobRequest тело запроса на создание задачи парсинга
+type JobRequest struct {
+	Channel     string  `json:"channel"`                // Канал для парсинга или несколько каналов через запятую
+	Hours       float64 `json:"hours"`                  // Период в часах (при поиске можно заменить на from)
+	CallbackURL string  `json:"callback_url,omitempty"` // Адрес для уведомления о завершении
+
+	// Параметры обработки постов: kafka, top, percentile, mode, summary_length, minutes, speaking_rate,
+	// include, exclude, skip_ads, dedup, dedup_mention, query, from, to, from_user
+	tg_post_model.ParseOptions
+}
+
+// HandlerJobs обрабатывает POST /jobs: ставит задачу парсинга в очередь и сразу возвращает её идентификатор
+func (h *MessageHandler) HandlerJobs(w http.ResponseWriter, r *http.Request) {
+	const lbl = "tg_app_micserv/internal/handlers/handlers.go/HandlerJobs()"
+	myLogger := logger.NewColorLogger(lbl)
+
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req JobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if req.Channel == "" {
+		writeError(w, http.StatusBadRequest, "channel parameter is required")
+		return
+	}
+	if req.Hours < 0 || (req.Hours == 0 && (!post_search.Enabled(req.ParseOptions) || req.From == nil)) {
+		writeError(w, http.StatusBadRequest, "invalid hours parameter")
+		return
+	}
+	if err := validateOptions(req.ParseOptions); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	job, err := h.jobs.Submit(req.Channel, req.Hours, req.ParseOptions, req.CallbackURL)
+	if errors.Is(err, jobs.ErrQueueFull) {
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	myLogger.Info("Создали задачу парсинга", slog.String("id", job.ID), slog.String("channel", job.Channel))
+
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// HandlerJob обрабатывает GET /jobs/{id} (состояние и результат задачи) и DELETE /jobs/{id} (отмена)
+func (h *MessageHandler) HandlerJob(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/jobs/")
+	if id == "" || strings.Contains(id, "/") {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+
+	var job jobs.Job
+	var ok bool
+	switch r.Method {
+	case http.MethodGet:
+		job, ok = h.jobs.Get(id)
+	case http.MethodDelete:
+		job, ok = h.jobs.Cancel(id)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// SearchResponse ответ на поиск по архиву
+type SearchResponse struct {
+	Query   string                `json:"query"`
+	Channel string                `json:"channel,omitempty"`
+	Results []post_archive.Result `json:"results"`
+}
+
+// HandlerSearch обрабатывает GET /search?channel=&q=&from=&to=&limit=: полнотекстовый поиск по архиву постов.
+// Без channel поиск идёт по всем каналам архива; from и to — дата (2006-01-02) или время RFC 3339,
+// дата в to включает весь день. В архиве есть только посты уже загруженных периодов.
+func (h *MessageHandler) HandlerSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
+	if h.archive == nil {
+		writeError(w, http.StatusServiceUnavailable, "post archive is disabled")
+		return
+	}
+
+	query := r.URL.Query()
+	q := query.Get("q")
+	if strings.TrimSpace(q) == "" {
+		writeError(w, http.StatusBadRequest, "q parameter is required")
+		return
+	}
+	from, err := parseTime(query.Get("from"), time.Time{}, false)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid from parameter")
+		return
+	}
+	to, err := parseTime(query.Get("to"), time.Now(), true)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid to parameter")
+		return
+	}
+	limit := 0
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit parameter")
+			return
+		}
+	}
+
+	channel := query.Get("channel")
+	results, err := h.archive.Search(channel, q, from, to, limit)
+	if errors.Is(err, post_archive.ErrEmptyQuery) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, SearchResponse{Query: q, Channel: channel, Results: results})
+}
+
+// parseTime разбирает дату или время RFC 3339; пустое значение заменяется на fallback.
+// Если endOfDay, дата без времени означает конец этого дня.
+func parseTime(value string, fallback time.Time, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	return t, nil
+}
+
+// parseOptions читает параметры обработки постов из строки запроса
+func parseOptions(r *http.Request) (tg_post_model.ParseOptions, error) {
+	var options tg_post_model.ParseOptions
+	query := r.URL.Query()
+
+	// Отправка в Kafka выполняется только по явному запросу
+	if kafkaStr := query.Get("kafka"); kafkaStr != "" {
+		publish, err := strconv.ParseBool(kafkaStr)
+		if err != nil {
+			return options, errors.New("invalid kafka parameter")
+		}
+		options.Publish = publish
+	}
+	if topStr := query.Get("top"); topStr != "" {
+		top, err := strconv.Atoi(topStr)
+		if err != nil {
+			return options, errors.New("invalid top parameter")
+		}
+		options.Top = top
+	}
+	if percentileStr := query.Get("percentile"); percentileStr != "" {
+		percentile, err := strconv.ParseFloat(percentileStr, 64)
+		if err != nil {
+			return options, errors.New("invalid percentile parameter")
+		}
+		options.Percentile = percentile
+	}
+	options.Mode = query.Get("mode")
+	if lengthStr := query.Get("summary_length"); lengthStr != "" {
+		length, err := strconv.Atoi(lengthStr)
+		if err != nil {
+			return options, errors.New("invalid summary_length parameter")
+		}
+		options.SummaryLength = length
+	}
+	if minutesStr := query.Get("minutes"); minutesStr != "" {
+		minutes, err := strconv.ParseFloat(minutesStr, 64)
+		if err != nil {
+			return options, errors.New("invalid minutes parameter")
+		}
+		options.Minutes = minutes
+	}
+	if rateStr := query.Get("speaking_rate"); rateStr != "" {
+		rate, err := strconv.ParseFloat(rateStr, 64)
+		if err != nil {
+			return options, errors.New("invalid speaking_rate parameter")
+		}
+		options.SpeakingRate = rate
+	}
+	options.Include = query["include"]
+	options.Exclude = query["exclude"]
+	if skipAdsStr := query.Get("skip_ads"); skipAdsStr != "" {
+		skipAds, err := strconv.ParseBool(skipAdsStr)
+		if err != nil {
+			return options, errors.New("invalid skip_ads parameter")
+		}
+		options.SkipAds = skipAds
+	}
+	options.Dedup = query.Get("dedup")
+	if mentionStr := query.Get("dedup_mention"); mentionStr != "" {
+		mention, err := strconv.ParseBool(mentionStr)
+		if err != nil {
+			return options, errors.New("invalid dedup_mention parameter")
+		}
+		options.DedupMention = mention
+	}
+	options.Query = query.Get("query")
+	options.FromUser = query.Get("from_user")
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err := parseTime(fromStr, time.Time{}, false)
+		if err != nil {
+			return options, errors.New("invalid from parameter")
+		}
+		options.From = &from
+	}
+	if toStr := query.Get("to"); toStr != "" {
+		to, err := parseTime(toStr, time.Time{}, true)
+		if err != nil {
+			return options, errors.New("invalid to parameter")
+		}
+		options.To = &to
+	}
+	return options, validateOptions(options)
+}
+
+// validateOptions проверяет параметры обработки постов, общие для /post_parser и /jobs
+func validateOptions(options tg_post_model.ParseOptions) error {
+	validators := []func(tg_post_model.ParseOptions) error{
+		post_rank.Validate,
+		digest.Validate,
+		speech_budget.Validate,
+		post_filter.Validate,
+		post_dedup.Validate,
+		post_search.Validate,
+	}
+	for _, validate := range validators {
+		if err := validate(options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parserStatus подбирает HTTP-статус для ошибки парсинга канала
+func parserStatus(err error) int {
+	var retryErr *tg_middleware.RetryAfterError
+	switch {
+	case errors.As(err, &retryErr):
+		return http.StatusTooManyRequests
+	case errors.Is(err, account_pool.ErrNoAccounts):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, channel_ref.ErrEmptyRef), errors.Is(err, channel_ref.ErrInvalidRef), errors.Is(err, channel_ref.ErrNotChannel):
+		return http.StatusBadRequest
+	case errors.Is(err, channel_ref.ErrNotFound), errors.Is(err, channel_ref.ErrInviteInvalid), errors.Is(err, channel_ref.ErrInviteExpired), errors.Is(err, channel_ref.ErrAuthorNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, channel_ref.ErrPrivate), errors.Is(err, channel_ref.ErrNotMember), errors.Is(err, channel_ref.ErrJoinRequestSent):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
 
-	// Формируем успешный JSON-ответ с полученными сообщениями
-	response := APIResponse{Messages: messages}
+// writeJSON отправляет JSON-ответ с указанным статусом
+func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
 }
 
-// APIResponse представляет структуру ответа JSON
-type APIResponse struct {
-	Messages string `json:"messages"`
-	Error    string `json:"error,omitempty"`
+// writeError отправляет JSON-ответ с ошибкой
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
 }
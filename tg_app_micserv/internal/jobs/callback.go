@@ -0,0 +1,109 @@
+// Ограничение адресов, на которые отправляются уведомления callback
+
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrCallbackForbidden возвращается, если callback-адрес не разрешён
+var ErrCallbackForbidden = errors.New("callback-адрес не разрешён")
+
+// blockedPrefixes служебные диапазоны, не покрытые методами netip.Addr
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// publicAddr сообщает, является ли адрес публичным: loopback, частные, link-local,
+// multicast, неуказанные и служебные адреса публичными не считаются
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// callbackPolicy решает, куда можно отправлять уведомления: если список хостов задан, то только на них
+// (это доверенные адреса, в том числе внутренние); иначе на любой хост, но только по публичным адресам
+type callbackPolicy struct {
+	hosts map[string]bool
+}
+
+// newCallbackPolicy создаёт политику по списку разрешённых хостов (пусто — любые публичные адреса)
+func newCallbackPolicy(hosts []string) callbackPolicy {
+	policy := callbackPolicy{hosts: make(map[string]bool, len(hosts))}
+	for _, host := range hosts {
+		policy.hosts[strings.ToLower(host)] = true
+	}
+	return policy
+}
+
+// check проверяет callback-адрес при постановке задачи; адреса, в которые разрешается имя хоста,
+// проверяются при каждом соединении, чтобы подмена DNS-записи после проверки ничего не дала
+func (p callbackPolicy) check(callbackURL string) error {
+	u, err := url.Parse(callbackURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("некорректный callback_url: %q", callbackURL)
+	}
+	host := strings.ToLower(u.Hostname())
+	if len(p.hosts) > 0 {
+		if !p.hosts[host] {
+			return fmt.Errorf("%w: хоста %s нет в CALLBACK_HOSTS", ErrCallbackForbidden, host)
+		}
+		return nil
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !publicAddr(addr) {
+		return fmt.Errorf("%w: %s не публичный адрес", ErrCallbackForbidden, host)
+	}
+	return nil
+}
+
+// control не даёт соединиться с непубличным адресом, если список разрешённых хостов не задан
+func (p callbackPolicy) control(_, address string, _ syscall.RawConn) error {
+	if len(p.hosts) > 0 {
+		return nil
+	}
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrCallbackForbidden, address)
+	}
+	if !publicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s не публичный адрес", ErrCallbackForbidden, addrPort.Addr())
+	}
+	return nil
+}
+
+// client возвращает HTTP-клиент для уведомлений: без прокси, чтобы проверялся адрес самого callback,
+// и без перенаправлений, чтобы разрешённый хост не перенаправил уведомление на другой
+func (p callbackPolicy) client() *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: p.control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,249 @@
+// Асинхронное выполнение задач парсинга каналов
+
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"tg_app_micserv/internal/model"
+	"tg_app_micserv/internal/speech_budget"
+	"tg_app_micserv/internal/tg_middleware"
+	"tg_app_micserv/tools/logger"
+)
+
+// ErrQueueFull возвращается, если очередь задач переполнена
+var ErrQueueFull = errors.New("очередь задач переполнена")
+
+// callbackAttempts количество попыток уведомить callback-адрес
+const callbackAttempts = 3
+
+// Parser интерфейс для получения постов канала
+type Parser interface {
+	PostParser(ctx context.Context, channel string, period time.Duration, options tg_post_model.ParseOptions) ([]tg_post_model.Message, []tg_post_model.FilteredPost, error)
+}
+
+// queued задача в очереди вместе с её контекстом отмены
+type queued struct {
+	id  string
+	ctx context.Context
+}
+
+// Manager принимает задачи парсинга и выполняет их в фоне несколькими исполнителями;
+// обычно исполнителей столько же, сколько Telegram-аккаунтов в пуле
+type Manager struct {
+	store      *Store
+	parser     Parser
+	queue      chan queued
+	workers    int            // Количество задач, выполняемых одновременно
+	timeout    time.Duration  // Максимальное время выполнения одной задачи
+	callbacks  callbackPolicy // Куда разрешено отправлять уведомления callback
+	httpClient *http.Client   // Клиент для уведомлений callback
+}
+
+// NewManager создает менеджер задач; callbackHosts — хосты, на которые разрешены уведомления
+// (пусто — любые хосты с публичными адресами)
+func NewManager(parser Parser, store *Store, queueSize, workers int, timeout time.Duration, callbackHosts []string) *Manager {
+	callbacks := newCallbackPolicy(callbackHosts)
+	return &Manager{
+		store:      store,
+		parser:     parser,
+		queue:      make(chan queued, queueSize),
+		workers:    max(workers, 1),
+		timeout:    timeout,
+		callbacks:  callbacks,
+		httpClient: callbacks.client(),
+	}
+}
+
+// Submit ставит задачу парсинга в очередь и сразу возвращает её
+func (m *Manager) Submit(channel string, hours float64, options tg_post_model.ParseOptions, callbackURL string) (Job, error) {
+	if callbackURL != "" {
+		if err := m.callbacks.check(callbackURL); err != nil {
+			return Job{}, err
+		}
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Job{}, err
+	}
+	job := Job{
+		ID:           id,
+		Status:       StatusQueued,
+		Channel:      channel,
+		Hours:        hours,
+		ParseOptions: options,
+		CallbackURL:  callbackURL,
+		CreatedAt:    time.Now(),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.store.Add(job, cancel)
+	select {
+	case m.queue <- queued{id: id, ctx: ctx}:
+		return job, nil
+	default:
+		m.store.Cancel(id)
+		return Job{}, ErrQueueFull
+	}
+}
+
+// Get возвращает задачу по идентификатору
+func (m *Manager) Get(id string) (Job, bool) {
+	return m.store.Get(id)
+}
+
+// Cancel отменяет задачу по идентификатору. Задачу из очереди исполнитель уже не возьмёт,
+// поэтому о её отмене callback-адрес уведомляется сразу
+func (m *Manager) Cancel(id string) (Job, bool) {
+	job, dequeued, ok := m.store.Cancel(id)
+	if dequeued && job.CallbackURL != "" {
+		go m.notify(context.Background(), job)
+	}
+	return job, ok
+}
+
+// Run выполняет задачи из очереди до отмены ctx
+func (m *Manager) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range m.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// work забирает задачи из очереди и выполняет их по одной
+func (m *Manager) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case q := <-m.queue:
+			m.execute(ctx, q)
+		}
+	}
+}
+
+// execute выполняет одну задачу и уведомляет callback-адрес о результате
+func (m *Manager) execute(ctx context.Context, q queued) {
+	const lbl = "tg_app_micserv/internal/jobs/jobs.go/execute()"
+	myLogger := logger.NewColorLogger(lbl)
+
+	// Задачу могли отменить, пока она ждала в очереди; callback уже уведомил Cancel
+	if q.ctx.Err() != nil {
+		return
+	}
+
+	job, ok := m.store.Update(q.id, func(job *Job) {
+		now := time.Now()
+		job.Status = StatusRunning
+		job.StartedAt = &now
+	})
+	if !ok {
+		return
+	}
+	myLogger.Info("Запустили задачу парсинга", slog.String("id", job.ID), slog.String("channel", job.Channel))
+
+	// Задача прерывается по таймауту, при отмене пользователем и при остановке сервиса
+	runCtx, cancel := context.WithTimeout(q.ctx, m.timeout)
+	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	posts, filtered, err := m.parser.PostParser(runCtx, job.Channel, time.Duration(job.Hours*float64(time.Hour)), job.ParseOptions)
+
+	job, _ = m.store.Update(q.id, func(job *Job) {
+		now := time.Now()
+		job.FinishedAt = &now
+		switch {
+		case q.ctx.Err() != nil:
+			job.Status = StatusCanceled
+		case err != nil:
+			job.Status = StatusFailed
+			job.Error = err.Error()
+			var retryErr *tg_middleware.RetryAfterError
+			if errors.As(err, &retryErr) {
+				job.RetryAfter = retryErr.Seconds()
+			}
+		default:
+			job.Status = StatusDone
+			job.Posts = posts
+			job.Duration = speech_budget.Total(posts).Seconds()
+			job.Filtered = filtered
+		}
+	})
+	myLogger.Info("Задача парсинга завершена", slog.String("id", job.ID), slog.String("status", string(job.Status)))
+
+	if job.CallbackURL != "" {
+		m.notify(ctx, job)
+	}
+}
+
+// notify отправляет задачу POST-запросом на callback-адрес, повторяя попытку при ошибке
+func (m *Manager) notify(ctx context.Context, job Job) {
+	const lbl = "tg_app_micserv/internal/jobs/jobs.go/notify()"
+	myLogger := logger.NewColorLogger(lbl)
+
+	body, err := json.Marshal(job)
+	if err != nil {
+		myLogger.Error("Не удалось сериализовать задачу для callback", slog.Any("error", err))
+		return
+	}
+
+	for attempt := 1; attempt <= callbackAttempts; attempt++ {
+		err = m.post(ctx, job.CallbackURL, body)
+		if err == nil {
+			myLogger.Info("Уведомили callback о завершении задачи", slog.String("id", job.ID))
+			return
+		}
+		myLogger.Error("Не удалось уведомить callback", slog.String("id", job.ID), slog.Int("attempt", attempt), slog.Any("error", err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(attempt) * time.Second):
+		}
+	}
+}
+
+// post отправляет JSON на указанный адрес
+func (m *Manager) post(ctx context.Context, callbackURL string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("callback ответил статусом %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// newID возвращает случайный идентификатор задачи
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать идентификатор задачи: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
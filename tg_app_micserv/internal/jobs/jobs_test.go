@@ -0,0 +1,107 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tg_app_micserv/internal/model"
+)
+
+// nopParser парсер, не возвращающий постов
+type nopParser struct{}
+
+func (nopParser) PostParser(context.Context, string, time.Duration, tg_post_model.ParseOptions) ([]tg_post_model.Message, []tg_post_model.FilteredPost, error) {
+	return nil, nil, nil
+}
+
+func TestCancelQueuedNotifiesCallback(t *testing.T) {
+	notified := make(chan Job, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var job Job
+		if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		notified <- job
+	}))
+	defer srv.Close()
+
+	// Исполнители не запущены, поэтому задача остаётся в очереди; тестовый сервер слушает loopback,
+	// поэтому его хост разрешён явно
+	manager := NewManager(nopParser{}, NewStore(time.Hour), 1, 1, time.Minute, []string{"127.0.0.1"})
+	job, err := manager.Submit("@news", 1, tg_post_model.ParseOptions{}, srv.URL)
+	if err != nil {
+		t.Fatalf("не удалось поставить задачу: %v", err)
+	}
+	if job, ok := manager.Cancel(job.ID); !ok || job.Status != StatusCanceled {
+		t.Fatalf("ожидали отменённую задачу, получили %+v, %t", job, ok)
+	}
+
+	select {
+	case got := <-notified:
+		if got.ID != job.ID || got.Status != StatusCanceled {
+			t.Fatalf("неожиданное уведомление: %+v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback не получил уведомление об отмене задачи из очереди")
+	}
+}
+
+func TestCallbackPolicy(t *testing.T) {
+	// Без списка хостов запрещены непубличные адреса, в том числе заданные литералом
+	open := newCallbackPolicy(nil)
+	for _, callbackURL := range []string{"http://127.0.0.1/cb", "http://10.0.0.5/cb", "http://[::1]/cb", "http://169.254.169.254/latest", "http://[::ffff:192.168.1.1]/cb"} {
+		if err := open.check(callbackURL); !errors.Is(err, ErrCallbackForbidden) {
+			t.Errorf("%s: ожидали ErrCallbackForbidden, получили %v", callbackURL, err)
+		}
+	}
+	if err := open.check("https://example.com/cb"); err != nil {
+		t.Errorf("публичный хост отклонён: %v", err)
+	}
+	if err := open.check("ftp://example.com/cb"); err == nil || errors.Is(err, ErrCallbackForbidden) {
+		t.Errorf("ожидали ошибку некорректного адреса, получили %v", err)
+	}
+
+	// Имя хоста, которое разрешается во внутренний адрес, отклоняется при соединении
+	if err := open.control("tcp", "127.0.0.1:8080", nil); !errors.Is(err, ErrCallbackForbidden) {
+		t.Errorf("ожидали запрет соединения с loopback, получили %v", err)
+	}
+	if err := open.control("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("соединение с публичным адресом запрещено: %v", err)
+	}
+
+	// Со списком хостов разрешены только они, даже внутренние
+	allow := newCallbackPolicy([]string{"Hooks.internal"})
+	if err := allow.check("http://hooks.internal:8080/cb"); err != nil {
+		t.Errorf("разрешённый хост отклонён: %v", err)
+	}
+	if err := allow.check("https://example.com/cb"); !errors.Is(err, ErrCallbackForbidden) {
+		t.Errorf("ожидали ErrCallbackForbidden для хоста вне списка, получили %v", err)
+	}
+}
+
+func TestNotifyRefusesLoopbackName(t *testing.T) {
+	hit := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		hit <- struct{}{}
+	}))
+	defer srv.Close()
+
+	// Имя localhost проходит проверку при постановке, но соединение с loopback запрещено
+	manager := NewManager(nopParser{}, NewStore(time.Hour), 1, 1, time.Minute, nil)
+	callbackURL := "http://localhost:" + srv.URL[strings.LastIndex(srv.URL, ":")+1:]
+	if err := manager.post(context.Background(), callbackURL, []byte("{}")); !errors.Is(err, ErrCallbackForbidden) {
+		t.Fatalf("ожидали ErrCallbackForbidden, получили %v", err)
+	}
+	select {
+	case <-hit:
+		t.Fatal("уведомление дошло до loopback-адреса")
+	default:
+	}
+}
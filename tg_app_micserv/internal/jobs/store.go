@@ -0,0 +1,130 @@
+// Хранилище задач парсинга в памяти
+
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"tg_app_micserv/internal/model"
+)
+
+// Status состояние задачи
+type Status string
+
+const (
+	StatusQueued   Status = "queued"   // Задача ожидает выполнения
+	StatusRunning  Status = "running"  // Задача выполняется
+	StatusDone     Status = "done"     // Задача выполнена успешно
+	StatusFailed   Status = "failed"   // Задача завершилась ошибкой
+	StatusCanceled Status = "canceled" // Задача отменена
+)
+
+// Finished сообщает, завершена ли задача
+func (s Status) Finished() bool {
+	return s == StatusDone || s == StatusFailed || s == StatusCanceled
+}
+
+// Job описывает задачу парсинга канала
+type Job struct {
+	ID          string                       `json:"id"`                     // Идентификатор задачи
+	Status      Status                       `json:"status"`                 // Состояние задачи
+	Channel     string                       `json:"channel"`                // Канал для парсинга
+	Hours       float64                      `json:"hours"`                  // Период парсинга в часах
+	CallbackURL string                       `json:"callback_url,omitempty"` // Адрес для уведомления о завершении
+	Posts       []tg_post_model.Message      `json:"posts,omitempty"`        // Результат — спарсенные посты
+	Duration    float64                      `json:"duration,omitempty"`     // Оценка длительности озвучивания всех постов в секундах
+	Filtered    []tg_post_model.FilteredPost `json:"filtered,omitempty"`     // Отчёт о постах, отброшенных фильтрами
+	Error       string                       `json:"error,omitempty"`        // Текст ошибки
+	RetryAfter  int                          `json:"retry_after,omitempty"`  // Через сколько секунд можно повторить, если Telegram ограничил запросы
+	CreatedAt   time.Time                    `json:"created_at"`             // Время создания задачи
+	StartedAt   *time.Time                   `json:"started_at,omitempty"`   // Время начала выполнения
+	FinishedAt  *time.Time                   `json:"finished_at,omitempty"`  // Время завершения
+
+	// Параметры обработки постов, выбранные в запросе
+	tg_post_model.ParseOptions
+}
+
+// entry запись хранилища: задача и функция её отмены
+type entry struct {
+	job    Job
+	cancel context.CancelFunc
+}
+
+// Store хранит задачи в памяти; завершённые задачи удаляются по истечении ttl
+type Store struct {
+	mutex sync.Mutex
+	jobs  map[string]*entry
+	ttl   time.Duration
+}
+
+// NewStore создает новое хранилище задач
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		jobs: make(map[string]*entry),
+		ttl:  ttl,
+	}
+}
+
+// Add добавляет задачу в хранилище
+func (s *Store) Add(job Job, cancel context.CancelFunc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cleanup()
+	s.jobs[job.ID] = &entry{job: job, cancel: cancel}
+}
+
+// Get возвращает копию задачи по идентификатору
+func (s *Store) Get(id string) (Job, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, ok := s.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return e.job, true
+}
+
+// Update изменяет задачу под блокировкой и возвращает её копию
+func (s *Store) Update(id string, update func(job *Job)) (Job, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, ok := s.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	update(&e.job)
+	return e.job, true
+}
+
+// Cancel отменяет незавершённую задачу и возвращает её копию; dequeued сообщает, что задача отменена,
+// так и не начав выполняться
+func (s *Store) Cancel(id string) (job Job, dequeued, ok bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, ok := s.jobs[id]
+	if !ok {
+		return Job{}, false, false
+	}
+	if !e.job.Status.Finished() {
+		e.cancel()
+		// Задача ещё в очереди — помечаем её отменённой сразу, выполняющуюся пометит исполнитель
+		if e.job.Status == StatusQueued {
+			now := time.Now()
+			e.job.Status = StatusCanceled
+			e.job.FinishedAt = &now
+			dequeued = true
+		}
+	}
+	return e.job, dequeued, true
+}
+
+// cleanup удаляет завершённые задачи старше ttl
+func (s *Store) cleanup() {
+	for id, e := range s.jobs {
+		if e.job.Status.Finished() && e.job.FinishedAt != nil && time.Since(*e.job.FinishedAt) > s.ttl {
+			delete(s.jobs, id)
+		}
+	}
+}
@@ -1 +1,54 @@
+// Kafka-консьюмер запросов дайджеста от Telegram-бота
+
 package consumer
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+	"tg_app_micserv/tools/logger"
+)
+
+// Consumer читает сообщения из топика Kafka
+type Consumer struct {
+	reader *kafka.Reader
+}
+
+// NewConsumer создает Kafka-консьюмер топика topic в группе groupID
+func NewConsumer(kafkaPort, topic, groupID string) *Consumer {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: strings.Split(kafkaPort, ","),
+		Topic:   topic,
+		GroupID: groupID,
+	})
+	return &Consumer{reader: reader}
+}
+
+// Consume читает сообщения и передаёт их в handle до отмены ctx.
+// Ошибка обработки одного сообщения не останавливает чтение следующих.
+func (c *Consumer) Consume(ctx context.Context, handle func(ctx context.Context, data []byte) error) error {
+	const lbl = "tg_app_micserv/internal/kafka/consumer/consumer.go/Consume()"
+	myLogger := logger.NewColorLogger(lbl)
+
+	for {
+		msg, err := c.reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			myLogger.Error("Ошибка чтения сообщения из Kafka", slog.Any("error", err))
+			return err
+		}
+
+		if err := handle(ctx, msg.Value); err != nil {
+			myLogger.Error("Ошибка обработки сообщения из Kafka", slog.Any("error", err))
+		}
+	}
+}
+
+// Close закрывает Kafka-консьюмер
+func (c *Consumer) Close() error {
+	return c.reader.Close()
+}
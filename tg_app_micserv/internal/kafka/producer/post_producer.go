@@ -0,0 +1,65 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"tg_app_micserv/internal/model"
+)
+
+// PostPublisher определяет интерфейс для отправки событий о постах в Kafka
+type PostPublisher interface {
+	PublishPost(ctx context.Context, event tg_post_model.PostEvent) error
+}
+
+// PostProducer отправляет события о постах отслеживаемых каналов в отдельный топик
+type PostProducer struct {
+	writer *kafka.Writer
+}
+
+// NewPostProducer создает Kafka Producer для событий о постах
+func NewPostProducer(kafkaPort, topic string) *PostProducer {
+	writer := &kafka.Writer{
+		Addr:                   kafka.TCP(strings.Split(kafkaPort, ",")...),
+		Topic:                  topic,
+		Balancer:               &kafka.Hash{}, // События одного поста попадают в одну партицию и сохраняют порядок
+		MaxAttempts:            3,
+		WriteTimeout:           1 * time.Second,
+		BatchTimeout:           10 * time.Millisecond, // Пост должен попасть в топик без заметной задержки
+		RequiredAcks:           kafka.RequireOne,
+		Async:                  false,
+		AllowAutoTopicCreation: true,
+	}
+	return &PostProducer{
+		writer: writer,
+	}
+}
+
+// PublishPost отправляет событие о посте в Kafka; ключ сообщения — канал и идентификатор поста
+func (p *PostProducer) PublishPost(ctx context.Context, event tg_post_model.PostEvent) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("ошибка при сериализации события о посте: %w", err)
+	}
+
+	err = p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(fmt.Sprintf("%d:%d", event.ChannelID, event.Message.ID)),
+		Value: value,
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка при записи события о посте в Kafka: %w", err)
+	}
+	return nil
+}
+
+// Close закрывает Kafka-продюсер событий
+func (p *PostProducer) Close() error {
+	if err := p.writer.Close(); err != nil {
+		return fmt.Errorf("ошибка при закрытии Kafka-продюсера событий: %w", err)
+	}
+	return nil
+}
@@ -10,12 +10,13 @@ import (
 
 	"github.com/segmentio/kafka-go"
 	"tg_app_micserv/config"
+	"tg_app_micserv/internal/model"
 	"tg_app_micserv/tools/logger"
 )
 
-// MessageProducer определяет интерфейс для отправки сообщений в Kafka
+// MessageProducer определяет интерфейс для отправки дайджестов в Kafka
 type MessageProducer interface {
-	ProduceMessages(ctx context.Context, messages map[int64]string) error
+	ProduceDigest(ctx context.Context, digest tg_post_model.Digest) error
 }
 
 // Producer реализует отправку сообщений в Kafka
@@ -46,43 +47,31 @@ func NewProducer(cfg *config.Config) (*Producer, error) {
 	}, nil
 }
 
-// ProduceMessages отправляет карту сообщений в Kafka
-func (p *Producer) ProduceMessages(ctx context.Context, mapTextForAudio map[int64]string) error {
-	const lbl = "tg_app_micserv/internal/kafka/producer.go/ProduceMessages()"
+// ProduceDigest отправляет дайджест в Kafka одним сообщением, чтобы посты озвучивались вместе и по порядку
+func (p *Producer) ProduceDigest(ctx context.Context, digest tg_post_model.Digest) error {
+	const lbl = "tg_app_micserv/internal/kafka/producer.go/ProduceDigest()"
 	logger := logger.NewColorLogger(lbl)
 	slog.SetDefault(logger)
 
-	var kafkaMessages []kafka.Message
-	// Преобразуем карту в сообщения Kafka
-	for timestamp, text := range mapTextForAudio {
-		value, err := json.Marshal(map[string]interface{}{
-			"timestamp": timestamp,
-			"text":      text,
-		})
-		if err != nil {
-			slog.Error(fmt.Sprintf("Ошибка при сериализации сообщения, error: %v, timestamp: %v", err, timestamp))
-			continue
-		}
-
-		kafkaMessages = append(kafkaMessages, kafka.Message{
-			Key:   []byte(fmt.Sprintf("%d", timestamp)),
-			Value: value,
-		})
+	if len(digest.Posts) == 0 {
+		slog.Error("Дайджест для Kafka пуст")
+		return nil
 	}
-	slog.Info("Карта постов для Kafka заполнена")
 
-	if len(kafkaMessages) == 0 {
-		slog.Error("Карта постов для Kafka пуста")
-		return nil
+	value, err := json.Marshal(digest)
+	if err != nil {
+		return fmt.Errorf("ошибка при сериализации дайджеста: %w", err)
 	}
 
-	// Отправляем сообщения в Kafka
-	err := p.writer.WriteMessages(ctx, kafkaMessages...)
+	// Отправляем сообщение в Kafka
+	err = p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(digest.ChannelName),
+		Value: value,
+	})
 	if err != nil {
 		return fmt.Errorf("ошибка при записи сообщений в Kafka: %w", err)
 	}
-
-	//p.logger.Info("Сообщения успешно отправлены в Kafka", "count", len(kafkaMessages))
+	slog.Info("Дайджест отправлен в Kafka", slog.Int("posts", len(digest.Posts)))
 	return nil
 }
 
@@ -12,6 +12,7 @@ import (
 // ServiceParser интерфейс для получения сообщений
 type ServiceParser interface {
 	PostParser(ctx context.Context, channel string, period time.Duration) ([]tg_post_model.Message, error)
+	SearchPosts(ctx context.Context, channel string, query tg_post_model.SearchQuery) ([]tg_post_model.Message, error)
 }
 
 // TextCleaner интерфейс для очистки текста сообщения
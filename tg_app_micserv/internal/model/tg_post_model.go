@@ -7,6 +7,184 @@ import "time"
 // Message структура для постов
 
 type Message struct {
-	Text      string
-	Timestamp time.Time
+	ID        int       `json:"id"`                   // Идентификатор поста в канале
+	Channel   string    `json:"channel,omitempty"`    // Канал, из которого получен пост
+	Text      string    `json:"text"`                 // Исходный текст поста или подпись к медиа
+	Title     string    `json:"title,omitempty"`      // Заголовок — выделенное жирным начало поста
+	CleanText string    `json:"clean_text"`           // Очищенный текст поста для озвучивания
+	Timestamp time.Time `json:"timestamp"`            // Время публикации поста
+	MediaType string    `json:"media_type,omitempty"` // Тип вложения (MediaPhoto, MediaVideo, ...), пусто — только текст
+	Forward   *Forward  `json:"forward,omitempty"`    // Источник пересланного поста
+	ReplyTo   int       `json:"reply_to,omitempty"`   // Идентификатор поста, на который дан ответ
+	Poll      *Poll     `json:"poll,omitempty"`       // Опрос
+	Views     int       `json:"views,omitempty"`      // Количество просмотров
+	Forwards  int       `json:"forwards,omitempty"`   // Количество пересылок
+	Reactions int       `json:"reactions,omitempty"`  // Количество реакций
+	Replies   int       `json:"replies,omitempty"`    // Количество комментариев
+	GroupedID int64     `json:"grouped_id,omitempty"` // Идентификатор альбома, общий для всех его постов
+	AlbumSize int       `json:"album_size,omitempty"` // Количество вложений, если посты альбома объединены в один
+	Duration  float64   `json:"duration,omitempty"`   // Оценка длительности озвучивания поста в секундах
+	AlsoIn    []string  `json:"also_in,omitempty"`    // Другие каналы, опубликовавшие почти такой же пост
+}
+
+// Типы вложений поста
+const (
+	MediaPhoto     = "photo"      // Фото
+	MediaVideo     = "video"      // Видео
+	MediaVideoNote = "video_note" // Видеосообщение (кружок)
+	MediaGIF       = "gif"        // Анимация
+	MediaAudio     = "audio"      // Аудиозапись
+	MediaVoice     = "voice"      // Голосовое сообщение
+	MediaSticker   = "sticker"    // Стикер
+	MediaDocument  = "document"   // Файл
+	MediaPoll      = "poll"       // Опрос
+	MediaWebPage   = "webpage"    // Предпросмотр ссылки
+	MediaGeo       = "geo"        // Геопозиция или место
+	MediaContact   = "contact"    // Контакт
+	MediaAlbum     = "album"      // Альбом из нескольких вложений
+	MediaOther     = "other"      // Прочие вложения
+)
+
+// Forward источник пересланного поста
+type Forward struct {
+	From      string    `json:"from,omitempty"`       // Название канала или имя автора
+	ChannelID int64     `json:"channel_id,omitempty"` // Идентификатор исходного канала
+	PostID    int       `json:"post_id,omitempty"`    // Идентификатор поста в исходном канале
+	Date      time.Time `json:"date"`                 // Время публикации оригинала
+}
+
+// Poll опрос
+type Poll struct {
+	Question string   `json:"question"`         // Вопрос
+	Options  []string `json:"options"`          // Варианты ответа
+	Quiz     bool     `json:"quiz,omitempty"`   // Викторина с правильным ответом
+	Closed   bool     `json:"closed,omitempty"` // Опрос завершён
+	Voters   int      `json:"voters,omitempty"` // Количество проголосовавших
+}
+
+// Типы событий о постах канала
+const (
+	PostEventNew    = "new"    // Опубликован новый пост
+	PostEventEdited = "edited" // Пост отредактирован
+)
+
+// PostEvent событие о новом или отредактированном посте отслеживаемого канала
+type PostEvent struct {
+	Type      string     `json:"type"`                // Тип события: new или edited
+	Channel   string     `json:"channel"`             // Имя канала
+	ChannelID int64      `json:"channel_id"`          // Идентификатор канала в Telegram
+	Message   Message    `json:"message"`             // Пост
+	EditedAt  *time.Time `json:"edited_at,omitempty"` // Время редактирования поста
+}
+
+// Digest дайджест для озвучивания, отправляемый в Kafka сервису Text-to-Speech
+type Digest struct {
+	RequestID    string       `json:"request_id,omitempty"`    // Идентификатор запроса бота, возвращается в ответе Text-to-Speech
+	ChatID       int64        `json:"chat_id,omitempty"`       // Чат пользователя Telegram, которому доставить аудио
+	ChannelName  string       `json:"channel_name,omitempty"`  // Канал или каналы дайджеста через запятую (для вступления)
+	PeriodHours  int          `json:"period_hours,omitempty"`  // Период дайджеста в часах (для вступления)
+	SpeakingRate float64      `json:"speaking_rate,omitempty"` // Скорость речи (0 — по умолчанию)
+	VoiceName    string       `json:"voice_name,omitempty"`    // Голос синтеза речи (пусто — по умолчанию)
+	Posts        []DigestPost `json:"posts"`                   // Посты по порядку озвучивания
+}
+
+// DigestPost пост дайджеста; пост однозначно определяют канал и идентификатор, а не время публикации
+type DigestPost struct {
+	Channel   string `json:"channel,omitempty"` // Канал, из которого получен пост
+	ID        int    `json:"id"`                // Идентификатор поста в канале
+	Timestamp int64  `json:"timestamp"`         // Unix-время публикации поста
+	Text      string `json:"text"`              // Очищенный текст поста для озвучивания
+}
+
+// ParseOptions параметры обработки постов, выбранные в запросе
+type ParseOptions struct {
+	Publish    bool    `json:"kafka,omitempty"`      // Отправить посты в Kafka
+	Top        int     `json:"top,omitempty"`        // Оставить N постов с наибольшей вовлечённостью (0 — все)
+	Percentile float64 `json:"percentile,omitempty"` // Оставить посты с вовлечённостью не ниже этого процентиля (0 — все)
+
+	Mode          string `json:"mode,omitempty"`           // Режим дайджеста: full, headlines или summary (пусто — full)
+	SummaryLength int    `json:"summary_length,omitempty"` // Длина краткого содержания поста в символах (0 — по умолчанию)
+
+	Minutes      float64 `json:"minutes,omitempty"`       // Длительность дайджеста в минутах, под которую подгоняются посты (0 — без ограничения)
+	SpeakingRate float64 `json:"speaking_rate,omitempty"` // Скорость речи для оценки длительности (0 — 1.0)
+
+	Include []string `json:"include,omitempty"`  // Оставить посты, где есть хотя бы одно слово, #хештег или /выражение/
+	Exclude []string `json:"exclude,omitempty"`  // Отбросить посты, где есть хотя бы одно слово, #хештег или /выражение/
+	SkipAds bool     `json:"skip_ads,omitempty"` // Отбросить рекламные посты
+
+	Dedup        string `json:"dedup,omitempty"`         // Убрать повторы из разных каналов, оставив earliest или views (пусто — не убирать)
+	DedupMention bool   `json:"dedup_mention,omitempty"` // Добавить к оставшемуся посту «также сообщили каналы …»
+
+	Query    string     `json:"query,omitempty"`     // Искать посты по запросу поиском Telegram вместо чтения всей истории
+	From     *time.Time `json:"from,omitempty"`      // Начало периода поиска (nil — сейчас минус период запроса)
+	To       *time.Time `json:"to,omitempty"`        // Конец периода поиска (nil — сейчас)
+	FromUser string     `json:"from_user,omitempty"` // Искать только посты этого автора (@имя) — в группах и каналах с подписями
+
+	RequestID string `json:"request_id,omitempty"` // Идентификатор запроса бота для дайджеста в Kafka
+	ChatID    int64  `json:"chat_id,omitempty"`    // Чат пользователя Telegram, которому доставить озвученный дайджест
+	VoiceName string `json:"voice_name,omitempty"` // Голос синтеза речи для дайджеста в Kafka (пусто — по умолчанию)
+}
+
+// BotRequest запрос дайджеста от Telegram-бота из Kafka
+type BotRequest struct {
+	RequestID      string   `json:"request_id"`                 // Идентификатор запроса
+	ChatID         int64    `json:"chat_id"`                    // Чат пользователя
+	Channel        string   `json:"channel"`                    // Канал или несколько каналов через запятую
+	SpeakingRate   float64  `json:"speaking_rate"`              // Скорость речи
+	VoiceName      string   `json:"voice_name,omitempty"`       // Голос синтеза речи
+	Hours          int      `json:"hours"`                      // Период в часах
+	Top            int      `json:"top,omitempty"`              // Оставить N самых популярных постов
+	Percentile     float64  `json:"percentile,omitempty"`       // Оставить посты популярнее этого процентиля
+	Mode           string   `json:"mode,omitempty"`             // Режим дайджеста
+	Minutes        int      `json:"minutes,omitempty"`          // Длительность дайджеста в минутах
+	Include        []string `json:"include,omitempty"`          // Слова, #хештеги и /выражения/, которые должны быть в посте
+	Exclude        []string `json:"exclude,omitempty"`          // Слова, #хештеги и /выражения/, которых не должно быть в посте
+	SkipAds        bool     `json:"skip_ads,omitempty"`         // Отбросить рекламу
+	Dedup          bool     `json:"dedup,omitempty"`            // Убрать повторы из разных каналов, упомянув другие каналы
+	SearchQuery    string   `json:"search_query,omitempty"`     // Искать посты по запросу
+	SearchFromUser string   `json:"search_from_user,omitempty"` // Искать только посты этого автора
+	SearchDays     int      `json:"search_days,omitempty"`      // За сколько последних дней искать посты
+}
+
+// Options переводит запрос бота в параметры обработки постов: дайджест отправляется в Kafka
+// вместе с чатом и идентификатором запроса, при поиске период задают дни поиска
+func (r BotRequest) Options(now time.Time) ParseOptions {
+	options := ParseOptions{
+		Publish:      true,
+		Top:          r.Top,
+		Percentile:   r.Percentile,
+		Mode:         r.Mode,
+		Minutes:      float64(r.Minutes),
+		SpeakingRate: r.SpeakingRate,
+		Include:      r.Include,
+		Exclude:      r.Exclude,
+		SkipAds:      r.SkipAds,
+		RequestID:    r.RequestID,
+		ChatID:       r.ChatID,
+		VoiceName:    r.VoiceName,
+	}
+	if r.Dedup {
+		options.Dedup, options.DedupMention = "earliest", true // post_dedup.KeepEarliest
+	}
+	if r.SearchQuery != "" {
+		from := now.AddDate(0, 0, -r.SearchDays)
+		options.Query, options.FromUser, options.From = r.SearchQuery, r.SearchFromUser, &from
+	}
+	return options
+}
+
+// SearchQuery параметры поиска постов канала
+type SearchQuery struct {
+	Query    string    // Текст запроса
+	From     time.Time // Начало периода
+	To       time.Time // Конец периода
+	FromUser string    // Автор постов (@имя), пусто — любой
+}
+
+// FilteredPost пост, не прошедший фильтры, — запись отчёта о фильтрации
+type FilteredPost struct {
+	ID      int    `json:"id"`               // Идентификатор поста в канале
+	Reason  string `json:"reason"`           // Причина: ad, excluded или no_match
+	Detail  string `json:"detail,omitempty"` // Сработавший фильтр или признак рекламы
+	Excerpt string `json:"excerpt"`          // Начало текста поста
 }
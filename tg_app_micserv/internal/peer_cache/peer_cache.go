@@ -0,0 +1,177 @@
+// Постоянный кеш найденных каналов и пользователей во встроенной базе bbolt
+
+package peer_cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Виды собеседников в кеше
+const (
+	KindChannel = "channel" // Канал
+	KindUser    = "user"    // Пользователь или бот
+)
+
+// Бакеты базы: собеседники по идентификатору и индекс имён
+var (
+	bucketPeers     = []byte("peers")
+	bucketUsernames = []byte("usernames")
+)
+
+// Peer найденный собеседник: всё, что нужно для запросов без повторного разрешения имени
+type Peer struct {
+	Kind       string    `json:"kind"`        // Вид собеседника
+	ID         int64     `json:"id"`          // Идентификатор в Telegram
+	AccessHash int64     `json:"access_hash"` // Access hash для запросов от имени аккаунта
+	Username   string    `json:"username"`    // Имя в нижнем регистре (пусто у приватных каналов)
+	Title      string    `json:"title"`       // Название канала или имя пользователя
+	Left       bool      `json:"left"`        // Аккаунт не подписан на канал
+	ResolvedAt time.Time `json:"resolved_at"` // Время последнего обращения к API
+}
+
+// Store хранит собеседников в файле bbolt; записи старше ttl считаются устаревшими
+// и запрашиваются у Telegram заново
+type Store struct {
+	db     *bolt.DB
+	ttl    time.Duration
+	prefix string // Префикс ключей аккаунта: access hash у каждого аккаунта свой
+}
+
+// Open открывает или создаёт кеш в указанном файле
+func Open(path string, ttl time.Duration) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть кеш собеседников %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketPeers, bucketUsernames} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("не удалось подготовить кеш собеседников: %w", err)
+	}
+	return &Store{db: db, ttl: ttl}, nil
+}
+
+// Account возвращает кеш аккаунта в той же базе; закрывать его не нужно
+func (s *Store) Account(name string) *Store {
+	return &Store{db: s.db, ttl: s.ttl, prefix: name + "/"}
+}
+
+// ByUsername возвращает актуального собеседника по имени
+func (s *Store) ByUsername(username string) (Peer, bool) {
+	var peer Peer
+	var found bool
+	s.db.View(func(tx *bolt.Tx) error {
+		id := tx.Bucket(bucketUsernames).Get(s.usernameKey(username))
+		if id == nil {
+			return nil
+		}
+		peer, found = s.get(tx, id)
+		return nil
+	})
+	return peer, found
+}
+
+// ByID возвращает актуального собеседника по виду и идентификатору
+func (s *Store) ByID(kind string, id int64) (Peer, bool) {
+	var peer Peer
+	var found bool
+	s.db.View(func(tx *bolt.Tx) error {
+		peer, found = s.get(tx, s.peerKey(kind, id))
+		return nil
+	})
+	return peer, found
+}
+
+// Put сохраняет собеседника и обновляет индекс имён
+func (s *Store) Put(peer Peer) error {
+	peer.Username = strings.ToLower(peer.Username)
+	if peer.ResolvedAt.IsZero() {
+		peer.ResolvedAt = time.Now()
+	}
+	data, err := json.Marshal(peer)
+	if err != nil {
+		return fmt.Errorf("не удалось сериализовать собеседника: %w", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		key := s.peerKey(peer.Kind, peer.ID)
+		// Имя могло смениться — убираем старую запись индекса
+		if old, ok := decode(tx.Bucket(bucketPeers).Get(key)); ok && old.Username != "" && old.Username != peer.Username {
+			if err := tx.Bucket(bucketUsernames).Delete(s.usernameKey(old.Username)); err != nil {
+				return err
+			}
+		}
+		if err := tx.Bucket(bucketPeers).Put(key, data); err != nil {
+			return err
+		}
+		if peer.Username != "" {
+			return tx.Bucket(bucketUsernames).Put(s.usernameKey(peer.Username), key)
+		}
+		return nil
+	})
+}
+
+// Invalidate удаляет собеседника из кеша, например после ошибки CHANNEL_INVALID
+func (s *Store) Invalidate(kind string, id int64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		key := s.peerKey(kind, id)
+		if old, ok := decode(tx.Bucket(bucketPeers).Get(key)); ok && old.Username != "" {
+			if err := tx.Bucket(bucketUsernames).Delete(s.usernameKey(old.Username)); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket(bucketPeers).Delete(key)
+	})
+}
+
+// Close закрывает базу вместе с кешами всех аккаунтов
+func (s *Store) Close() error {
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("не удалось закрыть кеш собеседников: %w", err)
+	}
+	return nil
+}
+
+// get возвращает собеседника по ключу, если запись не устарела
+func (s *Store) get(tx *bolt.Tx, key []byte) (Peer, bool) {
+	peer, ok := decode(tx.Bucket(bucketPeers).Get(key))
+	if !ok || (s.ttl > 0 && time.Since(peer.ResolvedAt) > s.ttl) {
+		return Peer{}, false
+	}
+	return peer, true
+}
+
+// decode разбирает запись базы
+func decode(data []byte) (Peer, bool) {
+	if data == nil {
+		return Peer{}, false
+	}
+	var peer Peer
+	if err := json.Unmarshal(data, &peer); err != nil {
+		return Peer{}, false
+	}
+	return peer, true
+}
+
+// peerKey возвращает ключ записи: идентификаторы каналов и пользователей могут совпадать
+func (s *Store) peerKey(kind string, id int64) []byte {
+	return []byte(s.prefix + kind + ":" + strconv.FormatInt(id, 10))
+}
+
+// usernameKey возвращает ключ индекса имён
+func (s *Store) usernameKey(username string) []byte {
+	return []byte(s.prefix + strings.ToLower(username))
+}
@@ -0,0 +1,70 @@
+package peer_cache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peers.db")
+	store, err := Open(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Put(Peer{Kind: KindChannel, ID: 42, AccessHash: 7, Username: "News"}); err != nil {
+		t.Fatal(err)
+	}
+	if peer, ok := store.ByUsername("news"); !ok || peer.AccessHash != 7 {
+		t.Fatalf("канал не найден по имени: %+v", peer)
+	}
+	// Пользователь с тем же идентификатором не затирает канал
+	if err := store.Put(Peer{Kind: KindUser, ID: 42, Username: "someone"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.ByID(KindChannel, 42); !ok {
+		t.Fatal("канал пропал после записи пользователя с тем же идентификатором")
+	}
+
+	// У каждого аккаунта свой access hash — кеши аккаунтов не пересекаются
+	if _, ok := store.Account("second").ByUsername("news"); ok {
+		t.Fatal("канал одного аккаунта виден другому")
+	}
+
+	// Смена имени убирает старую запись индекса
+	if err := store.Put(Peer{Kind: KindChannel, ID: 42, AccessHash: 7, Username: "news_ru"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.ByUsername("news"); ok {
+		t.Fatal("старое имя канала осталось в индексе")
+	}
+
+	// Кеш переживает перезапуск
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	store, err = Open(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if _, ok := store.ByUsername("news_ru"); !ok {
+		t.Fatal("канал не сохранился после перезапуска")
+	}
+
+	if err := store.Invalidate(KindChannel, 42); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.ByUsername("news_ru"); ok {
+		t.Fatal("канал остался в кеше после инвалидации")
+	}
+
+	// Устаревшие записи не возвращаются
+	if err := store.Put(Peer{Kind: KindChannel, ID: 1, Username: "old", ResolvedAt: time.Now().Add(-2 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.ByID(KindChannel, 1); ok {
+		t.Fatal("устаревшая запись вернулась из кеша")
+	}
+}
@@ -0,0 +1,80 @@
+// Получение постов канала через архив
+
+package post_archive
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"tg_app_micserv/internal/model"
+	"tg_app_micserv/internal/model/interfaces"
+	"tg_app_micserv/tools/logger"
+)
+
+// Parser отдаёт посты из архива, если период канала уже загружен, а иначе загружает их из Telegram
+// и сохраняет в архив
+type Parser struct {
+	parser  interfaces.ServiceParser
+	archive *Archive
+}
+
+// NewParser создаёт парсер, работающий через архив
+func NewParser(parser interfaces.ServiceParser, archive *Archive) *Parser {
+	return &Parser{parser: parser, archive: archive}
+}
+
+// PostParser возвращает посты канала за последние period
+func (p *Parser) PostParser(ctx context.Context, channel string, period time.Duration) ([]tg_post_model.Message, error) {
+	const lbl = "tg_app_micserv/internal/post_archive/parser.go/PostParser()"
+	myLogger := logger.NewColorLogger(lbl)
+
+	now := time.Now()
+	from := now.Add(-period)
+
+	// Ошибка архива не мешает получить посты из Telegram
+	covered, err := p.archive.Covered(channel, from, now)
+	if err != nil {
+		myLogger.Error("Не удалось проверить архив", slog.String("channel", channel), slog.Any("error", err))
+	}
+	if covered {
+		posts, err := p.archive.Posts(channel, from, now)
+		if err == nil {
+			myLogger.Info("Отдали посты из архива", slog.String("channel", channel), slog.Int("posts", len(posts)))
+			return posts, nil
+		}
+		myLogger.Error("Не удалось прочитать архив", slog.String("channel", channel), slog.Any("error", err))
+	}
+
+	posts, err := p.parser.PostParser(ctx, channel, period)
+	if err != nil {
+		return nil, err
+	}
+	// Telegram мог отдать не все посты периода, поэтому загруженным считаем период только начиная
+	// с самого старого полученного поста: более старые посты архива не удаляются, а период до него
+	// при следующем запросе загрузится заново
+	if oldest := oldestPost(posts); !oldest.IsZero() && oldest.After(from) {
+		from = oldest
+	}
+	if err := p.archive.Save(channel, from, now, posts); err != nil {
+		myLogger.Error("Не удалось сохранить посты в архив", slog.String("channel", channel), slog.Any("error", err))
+	}
+	return posts, nil
+}
+
+// oldestPost возвращает время публикации самого старого поста; нулевое время — постов нет
+func oldestPost(posts []tg_post_model.Message) time.Time {
+	var oldest time.Time
+	for _, post := range posts {
+		if oldest.IsZero() || post.Timestamp.Before(oldest) {
+			oldest = post.Timestamp
+		}
+	}
+	return oldest
+}
+
+// SearchPosts ищет посты канала поиском Telegram: архив хранит только загруженные периоды целиком,
+// а найденные посты такой период не покрывают, поэтому запрос передаётся дальше без архива
+func (p *Parser) SearchPosts(ctx context.Context, channel string, query tg_post_model.SearchQuery) ([]tg_post_model.Message, error) {
+	return p.parser.SearchPosts(ctx, channel, query)
+}
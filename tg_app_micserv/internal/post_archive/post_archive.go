@@ -0,0 +1,270 @@
+// Локальный архив постов каналов в SQLite с полнотекстовым поиском FTS5.
+// Архив требует сборки с CGO и тегом sqlite_fts5: CGO_ENABLED=1 go build -tags sqlite_fts5 ./...
+// Включается переменной ARCHIVE_FILE; без неё сервис работает без архива.
+
+package post_archive
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"tg_app_micserv/internal/channel_ref"
+	"tg_app_micserv/internal/model"
+)
+
+// Ограничения поиска
+const (
+	DefaultSearchLimit = 50  // Количество результатов поиска по умолчанию
+	MaxSearchLimit     = 500 // Максимальное количество результатов поиска
+	snippetWords       = 16  // Длина фрагмента с найденными словами
+)
+
+// ErrEmptyQuery возвращается, если в поисковом запросе нет ни одного слова
+var ErrEmptyQuery = errors.New("в поисковом запросе нет слов")
+
+// schema таблицы архива: посты, полнотекстовый индекс по их тексту и загруженные периоды каналов.
+// Токенизатор unicode61 приводит русский текст к нижнему регистру, но ё не заменяет — это делает indexText
+const schema = `
+CREATE TABLE IF NOT EXISTS posts (
+	channel   TEXT    NOT NULL,
+	id        INTEGER NOT NULL,
+	published INTEGER NOT NULL,
+	text      TEXT    NOT NULL,
+	data      TEXT    NOT NULL,
+	PRIMARY KEY (channel, id)
+);
+CREATE INDEX IF NOT EXISTS posts_published ON posts (channel, published);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS posts_fts USING fts5 (
+	text, content = 'posts', content_rowid = 'rowid', tokenize = 'unicode61 remove_diacritics 2'
+);
+CREATE TRIGGER IF NOT EXISTS posts_ai AFTER INSERT ON posts BEGIN
+	INSERT INTO posts_fts (rowid, text) VALUES (new.rowid, new.text);
+END;
+CREATE TRIGGER IF NOT EXISTS posts_ad AFTER DELETE ON posts BEGIN
+	INSERT INTO posts_fts (posts_fts, rowid, text) VALUES ('delete', old.rowid, old.text);
+END;
+CREATE TRIGGER IF NOT EXISTS posts_au AFTER UPDATE ON posts BEGIN
+	INSERT INTO posts_fts (posts_fts, rowid, text) VALUES ('delete', old.rowid, old.text);
+	INSERT INTO posts_fts (rowid, text) VALUES (new.rowid, new.text);
+END;
+
+CREATE TABLE IF NOT EXISTS coverage (
+	channel TEXT    NOT NULL,
+	from_ts INTEGER NOT NULL,
+	to_ts   INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS coverage_channel ON coverage (channel);
+`
+
+// Result найденный пост с фрагментом текста, где встретились слова запроса
+type Result struct {
+	tg_post_model.Message
+	Snippet string `json:"snippet"` // Фрагмент текста, найденные слова выделены «»
+}
+
+// Archive хранит загруженные посты каналов и периоды, за которые они загружены полностью.
+// Период считается загруженным, если он целиком покрыт загрузками и последняя из них не старше freshness:
+// свежие посты и изменения старых за это время в архив не попадут.
+type Archive struct {
+	db        *sql.DB
+	freshness time.Duration
+}
+
+// Open открывает или создаёт архив в указанном файле
+func Open(path string, freshness time.Duration) (*Archive, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть архив постов %s: %w", path, err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		if strings.Contains(err.Error(), "fts5") {
+			return nil, fmt.Errorf("SQLite собран без FTS5, соберите сервис с тегом sqlite_fts5: %w", err)
+		}
+		return nil, fmt.Errorf("не удалось подготовить архив постов: %w", err)
+	}
+	return &Archive{db: db, freshness: freshness}, nil
+}
+
+// Close закрывает архив
+func (a *Archive) Close() error {
+	return a.db.Close()
+}
+
+// Save заменяет посты канала за период [from, to] загруженными и отмечает период как загруженный.
+// Посты, удалённые из канала, при повторной загрузке периода удаляются и из архива.
+func (a *Archive) Save(channel string, from, to time.Time, posts []tg_post_model.Message) error {
+	channel = Key(channel)
+	tx, err := a.db.Begin()
+	if err != nil {
+		return fmt.Errorf("не удалось начать запись в архив: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM posts WHERE channel = ? AND published BETWEEN ? AND ?`, channel, from.Unix(), to.Unix()); err != nil {
+		return fmt.Errorf("не удалось обновить архив канала %s: %w", channel, err)
+	}
+	for _, post := range posts {
+		data, err := json.Marshal(post)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO posts (channel, id, published, text, data) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (channel, id) DO UPDATE SET published = excluded.published, text = excluded.text, data = excluded.data`,
+			channel, post.ID, post.Timestamp.Unix(), indexText(post), string(data))
+		if err != nil {
+			return fmt.Errorf("не удалось сохранить пост %d канала %s: %w", post.ID, channel, err)
+		}
+	}
+
+	// Объединяем новый период с пересекающимися загруженными периодами
+	start, end := from.Unix(), to.Unix()
+	rows, err := tx.Query(`SELECT rowid, from_ts, to_ts FROM coverage WHERE channel = ? AND from_ts <= ? AND to_ts >= ?`, channel, end, start)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать загруженные периоды канала %s: %w", channel, err)
+	}
+	var merged []int64
+	for rows.Next() {
+		var rowID, rowFrom, rowTo int64
+		if err := rows.Scan(&rowID, &rowFrom, &rowTo); err != nil {
+			rows.Close()
+			return err
+		}
+		merged = append(merged, rowID)
+		start, end = min(start, rowFrom), max(end, rowTo)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, rowID := range merged {
+		if _, err := tx.Exec(`DELETE FROM coverage WHERE rowid = ?`, rowID); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`INSERT INTO coverage (channel, from_ts, to_ts) VALUES (?, ?, ?)`, channel, start, end); err != nil {
+		return fmt.Errorf("не удалось сохранить загруженный период канала %s: %w", channel, err)
+	}
+	return tx.Commit()
+}
+
+// Covered сообщает, загружен ли период [from, now] канала целиком и достаточно недавно
+func (a *Archive) Covered(channel string, from, now time.Time) (bool, error) {
+	var count int
+	err := a.db.QueryRow(`SELECT COUNT(*) FROM coverage WHERE channel = ? AND from_ts <= ? AND to_ts >= ?`,
+		Key(channel), from.Unix(), now.Add(-a.freshness).Unix()).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("не удалось прочитать загруженные периоды канала: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Posts возвращает посты канала за период [from, to] от новых к старым
+func (a *Archive) Posts(channel string, from, to time.Time) ([]tg_post_model.Message, error) {
+	rows, err := a.db.Query(`SELECT data FROM posts WHERE channel = ? AND published BETWEEN ? AND ? ORDER BY published DESC, id DESC`,
+		Key(channel), from.Unix(), to.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать архив канала: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []tg_post_model.Message
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var post tg_post_model.Message
+		if err := json.Unmarshal([]byte(data), &post); err != nil {
+			return nil, fmt.Errorf("повреждённая запись архива: %w", err)
+		}
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
+
+// Search ищет посты по словам запроса за период [from, to]; пустой channel — поиск по всем каналам архива.
+// Слова ищутся по началу без окончания, чтобы находились другие падежи и формы: «ставки» найдёт «ставка».
+// Результаты упорядочены по релевантности.
+func (a *Archive) Search(channel, query string, from, to time.Time, limit int) ([]Result, error) {
+	match := matchQuery(query)
+	if match == "" {
+		return nil, ErrEmptyQuery
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	limit = min(limit, MaxSearchLimit)
+	key := ""
+	if channel != "" {
+		key = Key(channel)
+	}
+
+	rows, err := a.db.Query(`SELECT p.channel, p.data, snippet(posts_fts, 0, '«', '»', '…', ?)
+		FROM posts_fts JOIN posts p ON p.rowid = posts_fts.rowid
+		WHERE posts_fts MATCH ? AND (? = '' OR p.channel = ?) AND p.published BETWEEN ? AND ?
+		ORDER BY rank LIMIT ?`,
+		snippetWords, match, key, key, from.Unix(), to.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось выполнить поиск по архиву: %w", err)
+	}
+	defer rows.Close()
+
+	results := []Result{}
+	for rows.Next() {
+		var channel, data string
+		var result Result
+		if err := rows.Scan(&channel, &data, &result.Snippet); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &result.Message); err != nil {
+			return nil, fmt.Errorf("повреждённая запись архива: %w", err)
+		}
+		result.Channel = channel
+		result.Snippet = strings.TrimSpace(result.Snippet)
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// Key возвращает ключ канала в архиве: каноническую ссылку, чтобы @Name, name и t.me/name совпадали
+func Key(channel string) string {
+	if ref, err := channel_ref.Parse(channel); err == nil {
+		return ref.String()
+	}
+	return strings.ToLower(strings.TrimSpace(channel))
+}
+
+// yoReplacer заменяет ё на е, чтобы «ёлка» и «елка» находили друг друга
+var yoReplacer = strings.NewReplacer("ё", "е", "Ё", "Е")
+
+// indexText возвращает текст поста для полнотекстового индекса
+func indexText(post tg_post_model.Message) string {
+	return yoReplacer.Replace(strings.TrimSpace(post.Title + "\n" + post.Text))
+}
+
+// matchQuery превращает запрос пользователя в запрос FTS5: все слова должны встретиться в посте,
+// а длинные слова ищутся по началу без окончания
+func matchQuery(query string) string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		word = yoReplacer.Replace(word)
+		runes := []rune(word)
+		switch {
+		case len(runes) >= 7:
+			word = string(runes[:len(runes)-2])
+		case len(runes) >= 5:
+			word = string(runes[:len(runes)-1])
+		}
+		terms = append(terms, `"`+word+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,104 @@
+//go:build sqlite_fts5
+
+package post_archive
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tg_app_micserv/internal/model"
+)
+
+func TestArchive(t *testing.T) {
+	archive, err := Open(filepath.Join(t.TempDir(), "archive.db"), 10*time.Minute)
+	if err != nil {
+		t.Fatalf("не удалось открыть архив: %v", err)
+	}
+	defer archive.Close()
+
+	now := time.Now()
+	posts := []tg_post_model.Message{
+		{ID: 2, Text: "Центробанк снизил ключевую ставку. Ещё новость про ёлку", Timestamp: now.Add(-time.Hour)},
+		{ID: 1, Text: "Сборная выиграла матч", Timestamp: now.Add(-2 * time.Hour)},
+	}
+	if err := archive.Save("@News", now.Add(-3*time.Hour), now, posts); err != nil {
+		t.Fatalf("не удалось сохранить посты: %v", err)
+	}
+
+	if covered, err := archive.Covered("https://t.me/news", now.Add(-3*time.Hour), now); err != nil || !covered {
+		t.Fatalf("период должен быть загружен: %v, %v", covered, err)
+	}
+	if covered, _ := archive.Covered("@news", now.Add(-5*time.Hour), now); covered {
+		t.Fatal("более длинный период не загружен")
+	}
+	got, err := archive.Posts("news", now.Add(-3*time.Hour), now)
+	if err != nil || len(got) != 2 || got[0].ID != 2 {
+		t.Fatalf("ожидали два поста от новых к старым, получили %+v, %v", got, err)
+	}
+
+	results, err := archive.Search("@news", "ставки", time.Time{}, now, 0)
+	if err != nil || len(results) != 1 || results[0].ID != 2 {
+		t.Fatalf("ожидали пост 2 по запросу «ставки», получили %+v, %v", results, err)
+	}
+	if results, _ := archive.Search("", "елку", time.Time{}, now, 0); len(results) != 1 {
+		t.Fatalf("ё и е должны совпадать, получили %+v", results)
+	}
+
+	// Повторная загрузка периода убирает удалённые из канала посты
+	if err := archive.Save("@news", now.Add(-3*time.Hour), now, posts[:1]); err != nil {
+		t.Fatalf("не удалось сохранить посты: %v", err)
+	}
+	if results, _ := archive.Search("@news", "матч", time.Time{}, now, 0); len(results) != 0 {
+		t.Fatalf("удалённый пост остался в поиске: %+v", results)
+	}
+}
+
+// truncatedParser отдаёт только последние посты периода, как одна страница истории Telegram
+type truncatedParser struct {
+	posts []tg_post_model.Message
+}
+
+func (p *truncatedParser) PostParser(context.Context, string, time.Duration) ([]tg_post_model.Message, error) {
+	return p.posts, nil
+}
+
+func (p *truncatedParser) SearchPosts(context.Context, string, tg_post_model.SearchQuery) ([]tg_post_model.Message, error) {
+	return nil, nil
+}
+
+func TestParserTruncatedPage(t *testing.T) {
+	archive, err := Open(filepath.Join(t.TempDir(), "archive.db"), 10*time.Minute)
+	if err != nil {
+		t.Fatalf("не удалось открыть архив: %v", err)
+	}
+	defer archive.Close()
+
+	now := time.Now()
+	old := tg_post_model.Message{ID: 1, Text: "Старый пост", Timestamp: now.Add(-5 * time.Hour)}
+	if err := archive.Save("@news", now.Add(-6*time.Hour), now.Add(-4*time.Hour), []tg_post_model.Message{old}); err != nil {
+		t.Fatalf("не удалось сохранить посты: %v", err)
+	}
+
+	// Telegram отдал только свежие посты: старый пост не попал на страницу, но из канала не удалён
+	inner := &truncatedParser{posts: []tg_post_model.Message{
+		{ID: 3, Text: "Свежий пост", Timestamp: now.Add(-time.Hour)},
+		{ID: 2, Text: "Пост поновее", Timestamp: now.Add(-2 * time.Hour)},
+	}}
+	posts, err := NewParser(inner, archive).PostParser(context.Background(), "@news", 6*time.Hour)
+	if err != nil || len(posts) != 2 {
+		t.Fatalf("ожидали два поста из Telegram, получили %+v, %v", posts, err)
+	}
+
+	got, err := archive.Posts("@news", now.Add(-6*time.Hour), now)
+	if err != nil || len(got) != 3 || got[2].ID != 1 {
+		t.Fatalf("старый пост должен остаться в архиве, получили %+v, %v", got, err)
+	}
+	if covered, _ := archive.Covered("@news", now.Add(-6*time.Hour), now); covered {
+		t.Fatal("период старше самого старого полученного поста не должен считаться загруженным")
+	}
+	if covered, _ := archive.Covered("@news", now.Add(-2*time.Hour), now); !covered {
+		t.Fatal("период с самого старого полученного поста должен считаться загруженным")
+	}
+}
@@ -0,0 +1,177 @@
+// Поиск почти одинаковых постов разных каналов по SimHash
+
+package post_dedup
+
+import (
+	"errors"
+	"hash/fnv"
+	"math/bits"
+	"slices"
+	"strings"
+	"unicode"
+
+	"tg_app_micserv/internal/model"
+)
+
+// Какой пост из группы повторов остаётся в дайджесте
+const (
+	KeepEarliest = "earliest" // Опубликованный раньше всех
+	KeepViews    = "views"    // Набравший больше всего просмотров
+)
+
+// Параметры SimHash: посты сравниваются по шинглам из shingleSize слов подряд и считаются повторами,
+// если их отпечатки отличаются не больше чем в maxDistance битах из 64
+const (
+	shingleSize = 3
+	maxDistance = 12
+)
+
+// ErrInvalidKeep возвращается при неизвестном правиле выбора поста из группы повторов
+var ErrInvalidKeep = errors.New("dedup должен быть earliest или views")
+
+// Validate проверяет параметры дедупликации из запроса
+func Validate(options tg_post_model.ParseOptions) error {
+	switch options.Dedup {
+	case "", KeepEarliest, KeepViews:
+		return nil
+	}
+	return ErrInvalidKeep
+}
+
+// Fingerprint возвращает SimHash нормализованного текста; false — в тексте слишком мало слов для сравнения
+func Fingerprint(text string) (uint64, bool) {
+	words := normalize(text)
+	if len(words) < shingleSize {
+		return 0, false
+	}
+
+	var weights [64]int
+	for i := 0; i+shingleSize <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+shingleSize], " ")))
+		sum := h.Sum64()
+		for bit := range weights {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+	return fingerprint, true
+}
+
+// Distance возвращает число различающихся битов двух отпечатков
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Apply объединяет почти одинаковые посты в группы и оставляет из каждой группы один пост по правилу
+// options.Dedup; в AlsoIn оставшегося поста перечисляются другие каналы, сообщившие то же самое.
+// Посты без текста и слишком короткие посты не сравниваются. Порядок оставшихся постов не меняется.
+func Apply(posts []tg_post_model.Message, options tg_post_model.ParseOptions) []tg_post_model.Message {
+	if options.Dedup == "" || len(posts) < 2 {
+		return posts
+	}
+
+	fingerprints := make([]uint64, len(posts))
+	comparable := make([]bool, len(posts))
+	for i, post := range posts {
+		fingerprints[i], comparable[i] = Fingerprint(post.Text)
+	}
+
+	// group[i] — индекс первого поста группы, в которую попал пост i
+	group := make([]int, len(posts))
+	for i := range posts {
+		group[i] = i
+		if !comparable[i] {
+			continue
+		}
+		for j := 0; j < i; j++ {
+			if comparable[j] && group[j] == j && Distance(fingerprints[i], fingerprints[j]) <= maxDistance {
+				group[i] = j
+				break
+			}
+		}
+	}
+
+	// Выбираем в каждой группе пост, который останется
+	best := make(map[int]int)
+	for i, first := range group {
+		current, ok := best[first]
+		if !ok || better(posts[i], posts[current], options.Dedup) {
+			best[first] = i
+		}
+	}
+
+	var result []tg_post_model.Message
+	for i, post := range posts {
+		if best[group[i]] != i {
+			continue
+		}
+		for j, other := range posts {
+			if j != i && group[j] == group[i] && other.Channel != post.Channel && !slices.Contains(post.AlsoIn, other.Channel) {
+				post.AlsoIn = append(post.AlsoIn, other.Channel)
+			}
+		}
+		result = append(result, post)
+	}
+	return result
+}
+
+// AlsoReported возвращает фразу о других каналах, сообщивших то же самое, например
+// «Также сообщили каналы rian_ru и tass_agency.»; пустая строка — других каналов нет
+func AlsoReported(channels []string) string {
+	names := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		if channel = strings.TrimPrefix(channel, "@"); channel != "" {
+			names = append(names, channel)
+		}
+	}
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return "Также сообщил канал " + names[0] + "."
+	}
+	return "Также сообщили каналы " + strings.Join(names[:len(names)-1], ", ") + " и " + names[len(names)-1] + "."
+}
+
+// better сообщает, предпочтительнее ли пост a поста b по правилу keep
+func better(a, b tg_post_model.Message, keep string) bool {
+	if keep == KeepViews && a.Views != b.Views {
+		return a.Views > b.Views
+	}
+	return a.Timestamp.Before(b.Timestamp)
+}
+
+// normalize приводит текст к списку слов: нижний регистр, ё заменена на е, ссылки, хештеги,
+// упоминания и знаки препинания отброшены
+func normalize(text string) []string {
+	var words []string
+	for _, field := range strings.Fields(strings.ToLower(text)) {
+		if strings.Contains(field, "://") || strings.HasPrefix(field, "#") || strings.HasPrefix(field, "@") {
+			continue
+		}
+		word := strings.Map(func(r rune) rune {
+			switch {
+			case r == 'ё':
+				return 'е'
+			case unicode.IsLetter(r) || unicode.IsDigit(r):
+				return r
+			}
+			return -1
+		}, field)
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
@@ -0,0 +1,36 @@
+package post_dedup
+
+import (
+	"testing"
+	"time"
+
+	"tg_app_micserv/internal/model"
+)
+
+func TestApply(t *testing.T) {
+	now := time.Now()
+	news := "Центральный банк России снизил ключевую ставку до 16% годовых. Регулятор объяснил решение замедлением инфляции и охлаждением кредитования."
+	posts := []tg_post_model.Message{
+		{ID: 1, Channel: "@rian_ru", Text: news, Views: 500, Timestamp: now.Add(-time.Hour)},
+		{ID: 2, Channel: "@tass_agency", Text: "⚡️ Центральный банк России снизил ключевую ставку до 16% годовых. Регулятор объяснил решение замедлением инфляции и охлаждением кредитования. https://tass.ru/1", Views: 900, Timestamp: now.Add(-50 * time.Minute)},
+		{ID: 3, Channel: "@rbc_news", Text: "Сборная России по хоккею обыграла Белоруссию в товарищеском матче со счётом 4:2.", Timestamp: now},
+		{ID: 4, Channel: "@rbc_news", Text: "Центральный банк России снизил ключевую ставку до 16% годовых. Регулятор объяснил решение замедлением инфляции.", Views: 100, Timestamp: now.Add(-40 * time.Minute)},
+	}
+
+	kept := Apply(posts, tg_post_model.ParseOptions{Dedup: KeepEarliest})
+	if len(kept) != 2 || kept[0].ID != 1 || kept[1].ID != 3 {
+		t.Fatalf("ожидали посты [1 3], получили %+v", kept)
+	}
+	if len(kept[0].AlsoIn) != 2 {
+		t.Fatalf("ожидали два канала в AlsoIn, получили %v", kept[0].AlsoIn)
+	}
+
+	kept = Apply(posts, tg_post_model.ParseOptions{Dedup: KeepViews})
+	if len(kept) != 2 || kept[0].ID != 2 {
+		t.Fatalf("ожидали самый просматриваемый пост 2, получили %+v", kept)
+	}
+
+	if got := AlsoReported([]string{"@rian_ru", "tass_agency"}); got != "Также сообщили каналы rian_ru и tass_agency." {
+		t.Fatalf("получили %q", got)
+	}
+}
@@ -0,0 +1,56 @@
+// Распознавание рекламных постов
+
+package post_filter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// adHashtags хештеги, которыми каналы помечают рекламные посты
+var adHashtags = []string{"реклама", "промо", "партнер", "партнёр", "спонсор", "ad", "ads", "sponsored", "promo"}
+
+// adMarkers фразы, которыми по закону о рекламе и по обычаю каналов помечают рекламные посты
+var adMarkers = []string{
+	"на правах рекламы", "рекламодатель", "рекламная интеграция",
+	"партнерский пост", "партнёрский пост", "партнерский материал", "партнёрский материал",
+	"спонсор поста", "спонсор выпуска", "при поддержке партнера", "при поддержке партнёра",
+	"по промокоду", "промокод на скидку",
+}
+
+// adPatterns признаки рекламы, которые ищутся регулярными выражениями
+var adPatterns = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	// Токен маркировки рекламы: «erid: 2VtzqwXYZ» или параметр erid в ссылке
+	{name: "erid", re: regexp.MustCompile(`(?i)(^|[^a-z])erid\s*[:=]\s*[a-z0-9]{5,}`)},
+	// Пометка «Реклама» отдельной строкой или перед сведениями о рекламодателе
+	{name: "реклама", re: regexp.MustCompile(`(?im)^\s*реклама\s*[.:,]?\s*($|ооо|ип|ао|инн|огрн)`)},
+	// Ссылки с партнёрскими и рекламными метками
+	{name: "партнёрская ссылка", re: regexp.MustCompile(`(?i)https?://\S+[?&](utm_(source|medium|campaign)|ref|promo|aff|partner)=`)},
+	// Реферальные ссылки на ботов
+	{name: "реферальная ссылка", re: regexp.MustCompile(`(?i)t\.me/[a-z0-9_]+bot\?start=`)},
+}
+
+// DetectAd возвращает признак рекламы, найденный в тексте поста, или пустую строку, если пост не похож на рекламу
+func DetectAd(text string) string {
+	lower := strings.ToLower(text)
+	tags := hashtags(lower)
+	for _, tag := range adHashtags {
+		if tags[tag] {
+			return "#" + tag
+		}
+	}
+	for _, marker := range adMarkers {
+		if strings.Contains(lower, marker) {
+			return marker
+		}
+	}
+	for _, pattern := range adPatterns {
+		if pattern.re.MatchString(text) {
+			return pattern.name
+		}
+	}
+	return ""
+}
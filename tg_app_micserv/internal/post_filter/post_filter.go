@@ -0,0 +1,168 @@
+// Фильтрация постов по ключевым словам, регулярным выражениям, хештегам и распознавание рекламы
+
+package post_filter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"tg_app_micserv/internal/model"
+)
+
+// excerptRunes длина фрагмента поста в отчёте о фильтрации
+const excerptRunes = 80
+
+// Причины, по которым пост не попал в дайджест
+const (
+	ReasonAd       = "ad"       // Реклама
+	ReasonExcluded = "excluded" // Найден исключающий фильтр
+	ReasonNoMatch  = "no_match" // Не найден ни один включающий фильтр
+)
+
+// ErrInvalidFilter возвращается при некорректном регулярном выражении в фильтре
+var ErrInvalidFilter = errors.New("некорректный фильтр")
+
+// matcher одно условие фильтра: ключевое слово, хештег или регулярное выражение
+type matcher struct {
+	source  string         // Фильтр в том виде, в каком его задал пользователь
+	keyword string         // Ключевое слово в нижнем регистре
+	hashtag string         // Хештег без решётки в нижнем регистре
+	re      *regexp.Regexp // Регулярное выражение
+}
+
+// Filter отбирает посты по фильтрам из запроса
+type Filter struct {
+	include []matcher // Пост остаётся, только если совпал хотя бы один из фильтров (пусто — любые посты)
+	exclude []matcher // Пост отбрасывается, если совпал хотя бы один из фильтров
+	skipAds bool      // Отбрасывать рекламу
+}
+
+// New разбирает фильтры из запроса. Фильтр вида #тег проверяет хештеги поста, /выражение/ — регулярное
+// выражение без учёта регистра, остальные — ключевые слова, которые ищутся в тексте без учёта регистра.
+func New(options tg_post_model.ParseOptions) (*Filter, error) {
+	include, err := compile(options.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compile(options.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{include: include, exclude: exclude, skipAds: options.SkipAds}, nil
+}
+
+// Validate проверяет фильтры из запроса
+func Validate(options tg_post_model.ParseOptions) error {
+	_, err := New(options)
+	return err
+}
+
+// Apply оставляет посты, прошедшие фильтры, и возвращает отчёт об отброшенных постах.
+// Проверяется исходный текст поста, а не очищенный: в нём сохраняются хештеги, ссылки и латиница.
+func (f *Filter) Apply(posts []tg_post_model.Message) ([]tg_post_model.Message, []tg_post_model.FilteredPost) {
+	var kept []tg_post_model.Message
+	var filtered []tg_post_model.FilteredPost
+	for _, post := range posts {
+		reason, detail := f.check(post.Text)
+		if reason == "" {
+			kept = append(kept, post)
+			continue
+		}
+		filtered = append(filtered, tg_post_model.FilteredPost{
+			ID:      post.ID,
+			Reason:  reason,
+			Detail:  detail,
+			Excerpt: excerpt(post.Text),
+		})
+	}
+	return kept, filtered
+}
+
+// check возвращает причину, по которой пост отбрасывается, и сработавший признак; пустая причина — пост остаётся
+func (f *Filter) check(text string) (string, string) {
+	if f.skipAds {
+		if marker := DetectAd(text); marker != "" {
+			return ReasonAd, marker
+		}
+	}
+
+	lower := strings.ToLower(text)
+	tags := hashtags(lower)
+	for _, m := range f.exclude {
+		if m.match(text, lower, tags) {
+			return ReasonExcluded, m.source
+		}
+	}
+	if len(f.include) == 0 {
+		return "", ""
+	}
+	for _, m := range f.include {
+		if m.match(text, lower, tags) {
+			return "", ""
+		}
+	}
+	return ReasonNoMatch, ""
+}
+
+// match проверяет условие на тексте поста; lower — текст в нижнем регистре, tags — хештеги поста
+func (m matcher) match(text, lower string, tags map[string]bool) bool {
+	switch {
+	case m.re != nil:
+		return m.re.MatchString(text)
+	case m.hashtag != "":
+		return tags[m.hashtag]
+	}
+	return strings.Contains(lower, m.keyword)
+}
+
+// compile разбирает список фильтров, пропуская пустые
+func compile(filters []string) ([]matcher, error) {
+	var result []matcher
+	for _, source := range filters {
+		source = strings.TrimSpace(source)
+		m := matcher{source: source}
+		switch {
+		case source == "" || source == "#":
+			continue
+		case len(source) > 2 && strings.HasPrefix(source, "/") && strings.HasSuffix(source, "/"):
+			re, err := regexp.Compile("(?i)" + source[1:len(source)-1])
+			if err != nil {
+				return nil, fmt.Errorf("%w %q: %v", ErrInvalidFilter, source, err)
+			}
+			m.re = re
+		case strings.HasPrefix(source, "#"):
+			m.hashtag = strings.ToLower(source[1:])
+		default:
+			m.keyword = strings.ToLower(source)
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// hashtags возвращает хештеги текста без решётки
+func hashtags(text string) map[string]bool {
+	tags := make(map[string]bool)
+	for _, word := range strings.Fields(text) {
+		for _, tag := range strings.Split(word, "#")[1:] {
+			tag = strings.TrimRightFunc(tag, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' })
+			if tag != "" {
+				tags[tag] = true
+			}
+		}
+	}
+	return tags
+}
+
+// excerpt возвращает начало поста в одну строку для отчёта
+func excerpt(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= excerptRunes {
+		return text
+	}
+	return strings.TrimSpace(string([]rune(text)[:excerptRunes])) + "…"
+}
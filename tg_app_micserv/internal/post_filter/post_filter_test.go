@@ -0,0 +1,60 @@
+package post_filter
+
+import (
+	"errors"
+	"testing"
+
+	"tg_app_micserv/internal/model"
+)
+
+func TestDetectAd(t *testing.T) {
+	ads := []string{
+		"Лучший курс по Go! #реклама",
+		"Скидки до 50%.\nРеклама. ООО «Ромашка», ИНН 7700000000\nerid: 2VtzqwXYZab",
+		"Подробнее: https://shop.example/item?utm_source=telegram",
+		"Забирай бонус в t.me/super_bot?start=ref123",
+	}
+	for _, text := range ads {
+		if DetectAd(text) == "" {
+			t.Fatalf("реклама не распознана: %q", text)
+		}
+	}
+	for _, text := range []string{"Правительство обсудило рекламный рынок", "Новые кроссовки #adidas"} {
+		if marker := DetectAd(text); marker != "" {
+			t.Fatalf("обычный пост принят за рекламу по признаку %q: %q", marker, text)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	posts := []tg_post_model.Message{
+		{ID: 1, Text: "Курс рубля укрепился #экономика"},
+		{ID: 2, Text: "Футбол: итоги тура #спорт"},
+		{ID: 3, Text: "Ставка ЦБ снижена. erid: 2VtzqwXYZ"},
+		{ID: 4, Text: "Погода на выходные"},
+	}
+	filter, err := New(tg_post_model.ParseOptions{
+		Include: []string{"#экономика", "/ставк[аи]/", "футбол"},
+		Exclude: []string{"#спорт"},
+		SkipAds: true,
+	})
+	if err != nil {
+		t.Fatalf("неожиданная ошибка: %v", err)
+	}
+
+	kept, filtered := filter.Apply(posts)
+	if len(kept) != 1 || kept[0].ID != 1 {
+		t.Fatalf("ожидали только пост 1, получили %+v", kept)
+	}
+	reasons := map[int]string{}
+	for _, post := range filtered {
+		reasons[post.ID] = post.Reason
+	}
+	if reasons[2] != ReasonExcluded || reasons[3] != ReasonAd || reasons[4] != ReasonNoMatch {
+		t.Fatalf("неожиданный отчёт о фильтрации: %+v", filtered)
+	}
+
+	if _, err := New(tg_post_model.ParseOptions{Exclude: []string{"/[/"}}); !errors.Is(err, ErrInvalidFilter) {
+		t.Fatalf("ожидали ErrInvalidFilter, получили %v", err)
+	}
+}
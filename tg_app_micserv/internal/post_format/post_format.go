@@ -0,0 +1,212 @@
+// Форматы выдачи спарсенных постов: JSON, текст по строкам и Atom-лента канала
+
+package post_format
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"tg_app_micserv/internal/model"
+	"tg_app_micserv/internal/speech_budget"
+)
+
+// Format формат выдачи постов
+type Format string
+
+const (
+	FormatJSON Format = "json" // JSON-объект со списком постов
+	FormatText Format = "text" // Один пост на строку
+	FormatAtom Format = "atom" // Atom-лента канала
+)
+
+// Типы содержимого для форматов
+const (
+	contentTypeJSON = "application/json"
+	contentTypeText = "text/plain; charset=utf-8"
+	contentTypeAtom = "application/atom+xml; charset=utf-8"
+)
+
+// titleRunes максимальная длина заголовка записи Atom в символах
+const titleRunes = 80
+
+// Response тело ответа в формате JSON
+type Response struct {
+	Channel    string                       `json:"channel,omitempty"`
+	Messages   []tg_post_model.Message      `json:"messages"`
+	Duration   float64                      `json:"duration,omitempty"` // Оценка длительности озвучивания всех постов в секундах
+	Filtered   []tg_post_model.FilteredPost `json:"filtered,omitempty"` // Отчёт о постах, отброшенных фильтрами
+	Error      string                       `json:"error,omitempty"`
+	RetryAfter int                          `json:"retry_after,omitempty"` // Через сколько секунд можно повторить запрос
+}
+
+// Negotiate выбирает формат по параметру format, а если он не указан — по заголовку Accept.
+// По умолчанию используется JSON.
+func Negotiate(r *http.Request) (Format, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		switch Format(strings.ToLower(format)) {
+		case FormatJSON:
+			return FormatJSON, nil
+		case FormatText, "txt":
+			return FormatText, nil
+		case FormatAtom:
+			return FormatAtom, nil
+		}
+		return "", fmt.Errorf("unsupported format %q", format)
+	}
+
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		switch strings.ToLower(mediaType) {
+		case "application/json":
+			return FormatJSON, nil
+		case "text/plain":
+			return FormatText, nil
+		case "application/atom+xml", "application/xml", "text/xml":
+			return FormatAtom, nil
+		}
+	}
+	return FormatJSON, nil
+}
+
+// Write отправляет посты канала в выбранном формате; оценка длительности озвучивания
+// во всех форматах передаётся в заголовке X-Digest-Duration (в секундах), а количество
+// отброшенных фильтрами постов — в X-Filtered-Posts; подробный отчёт о фильтрации есть только в JSON
+func Write(w http.ResponseWriter, format Format, channel string, posts []tg_post_model.Message, filtered []tg_post_model.FilteredPost) error {
+	duration := speech_budget.Total(posts).Seconds()
+	w.Header().Set("X-Digest-Duration", strconv.FormatFloat(duration, 'f', 0, 64))
+	w.Header().Set("X-Filtered-Posts", strconv.Itoa(len(filtered)))
+	switch format {
+	case FormatText:
+		w.Header().Set("Content-Type", contentTypeText)
+		return writeText(w, posts)
+	case FormatAtom:
+		data, err := Atom(channel, posts)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", contentTypeAtom)
+		_, err = w.Write(data)
+		return err
+	default:
+		if posts == nil {
+			posts = []tg_post_model.Message{}
+		}
+		w.Header().Set("Content-Type", contentTypeJSON)
+		return json.NewEncoder(w).Encode(Response{Channel: channel, Messages: posts, Duration: duration, Filtered: filtered})
+	}
+}
+
+// writeText пишет очищенный текст постов по одному на строку;
+// переводы строк внутри поста заменяются пробелами
+func writeText(w io.Writer, posts []tg_post_model.Message) error {
+	for _, post := range posts {
+		text := strings.Join(strings.Fields(post.CleanText), " ")
+		if _, err := fmt.Fprintln(w, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Atom формирует Atom-ленту канала из постов
+func Atom(channel string, posts []tg_post_model.Message) ([]byte, error) {
+	name := strings.TrimPrefix(channel, "@")
+	channelURL := "https://t.me/" + name
+
+	feed := atomFeed{
+		XMLNS:  atomNamespace,
+		ID:     channelURL,
+		Title:  "@" + name,
+		Link:   atomLink{Href: channelURL, Rel: "alternate"},
+		Author: atomAuthor{Name: "@" + name},
+	}
+
+	var updated time.Time
+	for _, post := range posts {
+		if post.Timestamp.After(updated) {
+			updated = post.Timestamp
+		}
+		postURL := fmt.Sprintf("%s/%d", channelURL, post.ID)
+		// В сводной ленте нескольких каналов ссылка ведёт в канал самого поста
+		if post.Channel != "" {
+			postURL = fmt.Sprintf("https://t.me/%s/%d", strings.TrimPrefix(post.Channel, "@"), post.ID)
+		}
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:        postURL,
+			Title:     entryTitle(post.CleanText),
+			Link:      atomLink{Href: postURL, Rel: "alternate"},
+			Published: post.Timestamp.UTC().Format(time.RFC3339),
+			Updated:   post.Timestamp.UTC().Format(time.RFC3339),
+			Content:   atomContent{Type: "text", Value: post.Text},
+		})
+	}
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	feed.Updated = updated.UTC().Format(time.RFC3339)
+
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("не удалось сформировать Atom-ленту: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// entryTitle возвращает начало текста поста в одну строку для заголовка записи
+func entryTitle(text string) string {
+	title := strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(title) <= titleRunes {
+		return title
+	}
+	runes := []rune(title)
+	return strings.TrimSpace(string(runes[:titleRunes])) + "…"
+}
+
+// atomNamespace пространство имён Atom
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// atomFeed корневой элемент ленты
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atomLink ссылка на канал или пост
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+// atomAuthor автор ленты
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// atomEntry запись ленты — один пост
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Link      atomLink    `xml:"link"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Content   atomContent `xml:"content"`
+}
+
+// atomContent исходный текст поста
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
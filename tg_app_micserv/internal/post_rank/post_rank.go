@@ -0,0 +1,78 @@
+// Отбор самых интересных постов по вовлечённости
+
+package post_rank
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"tg_app_micserv/internal/model"
+)
+
+// ErrInvalidPolicy возвращается при некорректных параметрах отбора
+var ErrInvalidPolicy = errors.New("укажите либо top, либо percentile от 0 до 100")
+
+// Веса действий читателей относительно одного просмотра: переслать пост или ответить на него
+// значит больше, чем просто его прочитать
+const (
+	weightView     = 1
+	weightForward  = 20
+	weightReaction = 5
+	weightReply    = 10
+)
+
+// Validate проверяет параметры отбора из запроса
+func Validate(options tg_post_model.ParseOptions) error {
+	if options.Top < 0 || options.Percentile < 0 || options.Percentile >= 100 || (options.Top > 0 && options.Percentile > 0) {
+		return ErrInvalidPolicy
+	}
+	return nil
+}
+
+// Score оценивает вовлечённость поста: взвешенная сумма просмотров, пересылок, реакций и комментариев
+// за час жизни поста, чтобы свежие посты не проигрывали старым, которые успели набрать просмотры
+func Score(post tg_post_model.Message, now time.Time) float64 {
+	engagement := float64(weightView*post.Views + weightForward*post.Forwards + weightReaction*post.Reactions + weightReply*post.Replies)
+	// Пост моложе часа считается часовым, иначе первые минуты дают огромную оценку
+	age := math.Max(now.Sub(post.Timestamp).Hours(), 1)
+	return engagement / age
+}
+
+// Select оставляет top постов с наибольшей оценкой или посты с оценкой не ниже процентиля percentile.
+// Порядок оставшихся постов не меняется; без параметров отбора посты возвращаются как есть.
+func Select(posts []tg_post_model.Message, options tg_post_model.ParseOptions, now time.Time) []tg_post_model.Message {
+	if len(posts) == 0 || (options.Top <= 0 && options.Percentile <= 0) {
+		return posts
+	}
+
+	scores := make([]float64, len(posts))
+	for i, post := range posts {
+		scores[i] = Score(post, now)
+	}
+	order := make([]int, len(posts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+
+	keep := options.Top
+	if options.Percentile > 0 {
+		// Ближайший ранг: процентиль p оставляет верхние (100 - p)% постов, но не меньше одного
+		keep = int(math.Ceil(float64(len(posts)) * (100 - options.Percentile) / 100))
+	}
+	keep = min(max(keep, 1), len(posts))
+
+	selected := make([]bool, len(posts))
+	for _, i := range order[:keep] {
+		selected[i] = true
+	}
+	result := make([]tg_post_model.Message, 0, keep)
+	for i, post := range posts {
+		if selected[i] {
+			result = append(result, post)
+		}
+	}
+	return result
+}
@@ -0,0 +1,52 @@
+package post_rank
+
+import (
+	"testing"
+	"time"
+
+	"tg_app_micserv/internal/model"
+)
+
+func TestSelect(t *testing.T) {
+	now := time.Now()
+	posts := []tg_post_model.Message{
+		{ID: 4, Views: 100, Timestamp: now.Add(-30 * time.Minute)},
+		{ID: 3, Views: 1000, Forwards: 10, Timestamp: now.Add(-2 * time.Hour)},
+		// Старый пост набрал больше просмотров, но за час жизни уступает свежим
+		{ID: 2, Views: 1500, Timestamp: now.Add(-6 * time.Hour)},
+		{ID: 1, Views: 50, Reactions: 100, Timestamp: now.Add(-3 * time.Hour)},
+	}
+
+	ids := func(posts []tg_post_model.Message) []int {
+		var result []int
+		for _, post := range posts {
+			result = append(result, post.ID)
+		}
+		return result
+	}
+
+	got := ids(Select(posts, tg_post_model.ParseOptions{Top: 2}, now))
+	if len(got) != 2 || got[0] != 3 || got[1] != 2 {
+		t.Fatalf("top 2: получили %v, ожидали [3 2] в исходном порядке", got)
+	}
+
+	got = ids(Select(posts, tg_post_model.ParseOptions{Percentile: 75}, now))
+	if len(got) != 1 || got[0] != 3 {
+		t.Fatalf("процентиль 75: получили %v, ожидали [3]", got)
+	}
+
+	if got := Select(posts, tg_post_model.ParseOptions{}, now); len(got) != len(posts) {
+		t.Fatalf("без отбора ожидали все посты, получили %d", len(got))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, options := range []tg_post_model.ParseOptions{{Top: -1}, {Percentile: 100}, {Top: 5, Percentile: 50}} {
+		if Validate(options) == nil {
+			t.Fatalf("ожидали ошибку для %+v", options)
+		}
+	}
+	if err := Validate(tg_post_model.ParseOptions{Top: 5}); err != nil {
+		t.Fatalf("неожиданная ошибка: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+// Поиск постов канала по запросу через поиск Telegram
+
+package post_search
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"tg_app_micserv/internal/model"
+)
+
+// maxQueryRunes максимальная длина поискового запроса
+const maxQueryRunes = 256
+
+// Ошибки параметров поиска
+var (
+	ErrQueryRequired = errors.New("from, to и from_user задаются только вместе с query")
+	ErrQueryTooLong  = errors.New("query длиннее 256 символов")
+	ErrInvalidRange  = errors.New("from должен быть раньше to")
+)
+
+// Enabled сообщает, что в запросе выбран поиск по каналу вместо чтения истории
+func Enabled(options tg_post_model.ParseOptions) bool {
+	return strings.TrimSpace(options.Query) != ""
+}
+
+// Validate проверяет параметры поиска из запроса
+func Validate(options tg_post_model.ParseOptions) error {
+	if !Enabled(options) {
+		if options.From != nil || options.To != nil || options.FromUser != "" {
+			return ErrQueryRequired
+		}
+		return nil
+	}
+	if utf8.RuneCountInString(options.Query) > maxQueryRunes {
+		return ErrQueryTooLong
+	}
+	if options.From != nil && options.To != nil && !options.From.Before(*options.To) {
+		return ErrInvalidRange
+	}
+	return nil
+}
+
+// Query собирает параметры поиска: период — от options.From (или now минус period) до options.To (или now)
+func Query(options tg_post_model.ParseOptions, period time.Duration, now time.Time) tg_post_model.SearchQuery {
+	query := tg_post_model.SearchQuery{
+		Query:    strings.TrimSpace(options.Query),
+		From:     now.Add(-period),
+		To:       now,
+		FromUser: strings.TrimSpace(options.FromUser),
+	}
+	if options.From != nil {
+		query.From = *options.From
+	}
+	if options.To != nil {
+		query.To = *options.To
+	}
+	return query
+}
@@ -0,0 +1,45 @@
+package post_search
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"tg_app_micserv/internal/model"
+)
+
+func TestQuery(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	query := Query(tg_post_model.ParseOptions{Query: " ставка ЦБ ", FromUser: "@author"}, 7*24*time.Hour, now)
+	if query.Query != "ставка ЦБ" || query.FromUser != "@author" {
+		t.Fatalf("неожиданные параметры поиска: %+v", query)
+	}
+	if !query.From.Equal(now.AddDate(0, 0, -7)) || !query.To.Equal(now) {
+		t.Fatalf("ожидали последнюю неделю, получили %v – %v", query.From, query.To)
+	}
+
+	from := now.AddDate(0, -1, 0)
+	query = Query(tg_post_model.ParseOptions{Query: "ставка", From: &from}, 0, now)
+	if !query.From.Equal(from) || !query.To.Equal(now) {
+		t.Fatalf("ожидали период от from до сейчас, получили %v – %v", query.From, query.To)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	from := time.Now()
+	to := from.Add(-time.Hour)
+	cases := []struct {
+		options tg_post_model.ParseOptions
+		err     error
+	}{
+		{tg_post_model.ParseOptions{}, nil},
+		{tg_post_model.ParseOptions{Query: "ставка", From: &to, To: &from}, nil},
+		{tg_post_model.ParseOptions{FromUser: "@author"}, ErrQueryRequired},
+		{tg_post_model.ParseOptions{Query: "ставка", From: &from, To: &to}, ErrInvalidRange},
+	}
+	for _, c := range cases {
+		if err := Validate(c.options); !errors.Is(err, c.err) {
+			t.Fatalf("для %+v ожидали %v, получили %v", c.options, c.err, err)
+		}
+	}
+}
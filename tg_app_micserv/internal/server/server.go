@@ -16,19 +16,32 @@ type Server struct {
 	srv *http.Server
 }
 
-// NewServer создает новый экземпляр Server
-func NewServer(port string, handler *handlers.MessageHandler) *Server {
+// writeMargin запас времени сверх таймаута парсинга на запись ответа клиенту
+const writeMargin = 10 * time.Second
+
+// NewServer создает новый экземпляр Server; parseTimeout ограничивает синхронный парсинг /post_parser,
+// а таймаут записи ответа выставляется с запасом, чтобы соединение не рвалось до окончания парсинга
+func NewServer(port string, parseTimeout time.Duration, handler *handlers.MessageHandler, admin *handlers.AdminHandler) *Server {
 	// Настройка HTTP-сервера
 	srv := &http.Server{
 		Addr:         ":" + port,
 		Handler:      nil, // Используем дефолтный http.DefaultServeMux
 		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		WriteTimeout: parseTimeout + writeMargin,
 		IdleTimeout:  120 * time.Second,
 	}
 
 	// Регистрация обработчика эндпоинта
-	http.HandleFunc("/post_parser", handler.HandlerPostParser)
+	http.Handle("/post_parser", http.TimeoutHandler(http.HandlerFunc(handler.HandlerPostParser), parseTimeout, `{"error":"превышено время парсинга, используйте /jobs"}`))
+	http.HandleFunc("/jobs", handler.HandlerJobs)
+	http.HandleFunc("/jobs/", handler.HandlerJob)
+	http.HandleFunc("/search", handler.HandlerSearch)
+	http.HandleFunc("/admin/accounts", admin.HandlerAccounts)
+	http.HandleFunc("/admin/login/qr", admin.HandlerLoginQR)
+	http.HandleFunc("/admin/login/qr.png", admin.HandlerLoginQRImage)
+	http.HandleFunc("/admin/login/password", admin.HandlerLoginPassword)
+	http.HandleFunc("/admin/session", admin.HandlerSession)
+	// Метрики ожиданий FLOOD_WAIT и ограничителя запросов публикует expvar на /debug/vars
 
 	return &Server{
 		srv: srv,
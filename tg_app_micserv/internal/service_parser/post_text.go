@@ -0,0 +1,145 @@
+// Подготовка текста постов с вложениями, пересылками и опросами к озвучиванию
+
+package service_parser
+
+import (
+	"strings"
+
+	"tg_app_micserv/internal/model"
+)
+
+// placeholders описания вложений для постов без подписи
+var placeholders = map[string]string{
+	tg_post_model.MediaPhoto:     "Фото без подписи",
+	tg_post_model.MediaVideo:     "Видео без подписи",
+	tg_post_model.MediaVideoNote: "Видеосообщение",
+	tg_post_model.MediaGIF:       "Анимация без подписи",
+	tg_post_model.MediaAudio:     "Аудиозапись без подписи",
+	tg_post_model.MediaVoice:     "Голосовое сообщение",
+	tg_post_model.MediaSticker:   "Стикер",
+	tg_post_model.MediaDocument:  "Файл без подписи",
+	tg_post_model.MediaGeo:       "Геопозиция",
+	tg_post_model.MediaContact:   "Контакт",
+}
+
+// PostText готовит очищенный текст поста: указывает источник пересылки, зачитывает опрос
+// и описывает вложение, если у него нет подписи
+func PostText(msg tg_post_model.Message) string {
+	return postText(msg, FormatText(msg.Text))
+}
+
+// postText собирает текст поста вокруг уже очищенного текста text (например, сокращённого для дайджеста)
+func postText(msg tg_post_model.Message, text string) string {
+	var parts []string
+	if msg.Forward != nil {
+		parts = append(parts, forwardText(msg.Forward))
+	}
+
+	switch {
+	case msg.Poll != nil:
+		if text != "" {
+			parts = append(parts, text)
+		}
+		parts = append(parts, pollText(msg.Poll))
+	case text != "":
+		parts = append(parts, text)
+	case msg.MediaType == tg_post_model.MediaAlbum:
+		parts = append(parts, albumText(msg.AlbumSize))
+	case placeholders[msg.MediaType] != "":
+		parts = append(parts, placeholders[msg.MediaType])
+	default:
+		// Пост без текста и без понятного вложения нечего озвучивать
+		return ""
+	}
+	return FormatText(strings.Join(parts, "\n"))
+}
+
+// MergeAlbums объединяет посты одного альбома в один: Telegram присылает каждое вложение альбома
+// отдельным сообщением, а подпись есть только у одного из них
+func MergeAlbums(messages []tg_post_model.Message) []tg_post_model.Message {
+	var result []tg_post_model.Message
+	albums := make(map[int64]int) // Идентификатор альбома → индекс поста в result
+	for _, msg := range messages {
+		if msg.GroupedID == 0 {
+			result = append(result, msg)
+			continue
+		}
+		i, ok := albums[msg.GroupedID]
+		if !ok {
+			albums[msg.GroupedID] = len(result)
+			msg.AlbumSize = 1
+			result = append(result, msg)
+			continue
+		}
+
+		album := &result[i]
+		// Основой альбома становится вложение с подписью
+		if album.Text == "" && msg.Text != "" {
+			msg.AlbumSize, msg.MediaType = album.AlbumSize, album.MediaType
+			msg.Views, msg.Forwards = max(msg.Views, album.Views), max(msg.Forwards, album.Forwards)
+			msg.Reactions, msg.Replies = max(msg.Reactions, album.Reactions), max(msg.Replies, album.Replies)
+			*album = msg
+		} else {
+			album.Views, album.Forwards = max(album.Views, msg.Views), max(album.Forwards, msg.Forwards)
+			album.Reactions, album.Replies = max(album.Reactions, msg.Reactions), max(album.Replies, msg.Replies)
+		}
+		album.AlbumSize++
+		album.MediaType = tg_post_model.MediaAlbum
+	}
+	return result
+}
+
+// forwardText называет источник пересланного поста
+func forwardText(fwd *tg_post_model.Forward) string {
+	// Название очищается отдельно: латинское имя канала после очистки пропадёт целиком
+	from := strings.TrimSpace(FormatText(fwd.From))
+	switch {
+	case from == "":
+		return "Пересланный пост."
+	case fwd.ChannelID != 0:
+		return "Пересланный пост из канала «" + from + "»."
+	}
+	return "Пересланный пост от автора «" + from + "»."
+}
+
+// pollText зачитывает вопрос и варианты ответа опроса
+func pollText(poll *tg_post_model.Poll) string {
+	kind := "Опрос"
+	if poll.Quiz {
+		kind = "Викторина"
+	}
+	text := kind + ": " + sentence(FormatText(poll.Question))
+
+	var options []string
+	for _, option := range poll.Options {
+		if option = strings.TrimSpace(FormatText(option)); option != "" {
+			options = append(options, option)
+		}
+	}
+	if len(options) > 0 {
+		text += " Варианты ответа: " + strings.Join(options, "; ") + "."
+	}
+	return text
+}
+
+// albumCounts количество вложений альбома прописью: очистка текста удаляет цифры, а в альбоме не больше 10 вложений
+var albumCounts = map[int]string{
+	2: "двух", 3: "трёх", 4: "четырёх", 5: "пяти", 6: "шести", 7: "семи", 8: "восьми", 9: "девяти", 10: "десяти",
+}
+
+// albumText описывает альбом без подписи
+func albumText(size int) string {
+	if count, ok := albumCounts[size]; ok {
+		return "Альбом из " + count + " вложений без подписи"
+	}
+	return "Альбом без подписи"
+}
+
+// sentence завершает фразу точкой, если в конце нет знака препинания
+func sentence(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.ContainsAny(s[len(s)-1:], ".!?") {
+		return s
+	}
+	return s + "."
+}
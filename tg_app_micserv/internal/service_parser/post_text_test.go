@@ -0,0 +1,76 @@
+package service_parser
+
+import (
+	"testing"
+
+	"tg_app_micserv/internal/model"
+)
+
+func TestPostText(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  tg_post_model.Message
+		want string
+	}{
+		{
+			name: "фото без подписи",
+			msg:  tg_post_model.Message{MediaType: tg_post_model.MediaPhoto},
+			want: "Фото без подписи",
+		},
+		{
+			name: "подпись к фото",
+			msg:  tg_post_model.Message{Text: "Закат над морем", MediaType: tg_post_model.MediaPhoto},
+			want: "Закат над морем",
+		},
+		{
+			name: "пересланный пост",
+			msg:  tg_post_model.Message{Text: "Новости дня", Forward: &tg_post_model.Forward{From: "Вестник", ChannelID: 1}},
+			want: "Пересланный пост из канала «Вестник».\nНовости дня",
+		},
+		{
+			name: "пересланный пост из канала с латинским названием",
+			msg:  tg_post_model.Message{Text: "Новости дня", Forward: &tg_post_model.Forward{From: "Daily News", ChannelID: 1}},
+			want: "Пересланный пост.\nНовости дня",
+		},
+		{
+			name: "опрос",
+			msg:  tg_post_model.Message{MediaType: tg_post_model.MediaPoll, Poll: &tg_post_model.Poll{Question: "Куда поедем?", Options: []string{"Море", "Горы"}}},
+			want: "Опрос: Куда поедем? Варианты ответа: Море; Горы.",
+		},
+		{
+			name: "альбом",
+			msg:  tg_post_model.Message{MediaType: tg_post_model.MediaAlbum, AlbumSize: 3},
+			want: "Альбом из трёх вложений без подписи",
+		},
+		{
+			name: "неизвестное вложение",
+			msg:  tg_post_model.Message{MediaType: tg_post_model.MediaOther},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PostText(tt.msg); got != tt.want {
+				t.Fatalf("получили %q, ожидали %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeAlbums(t *testing.T) {
+	messages := []tg_post_model.Message{
+		{ID: 5, Text: "Отдельный пост"},
+		{ID: 4, GroupedID: 7, MediaType: tg_post_model.MediaPhoto, Views: 10},
+		{ID: 3, GroupedID: 7, MediaType: tg_post_model.MediaPhoto, Text: "Подпись альбома", Views: 12},
+		{ID: 2, GroupedID: 7, MediaType: tg_post_model.MediaVideo},
+	}
+
+	merged := MergeAlbums(messages)
+	if len(merged) != 2 {
+		t.Fatalf("ожидали 2 поста, получили %d", len(merged))
+	}
+	album := merged[1]
+	if album.ID != 3 || album.Text != "Подпись альбома" || album.MediaType != tg_post_model.MediaAlbum || album.AlbumSize != 3 || album.Views != 12 {
+		t.Fatalf("неожиданный альбом: %+v", album)
+	}
+}
@@ -3,15 +3,25 @@
 package service_parser
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
+	"sort"
 	"strings"
 	"tg_app_micserv/internal/kafka"
 	"time"
 	"unicode/utf8"
 
+	"tg_app_micserv/internal/digest"
+	"tg_app_micserv/internal/model"
 	"tg_app_micserv/internal/model/interfaces"
+	"tg_app_micserv/internal/post_dedup"
+	"tg_app_micserv/internal/post_filter"
+	"tg_app_micserv/internal/post_rank"
+	"tg_app_micserv/internal/post_search"
+	"tg_app_micserv/internal/speech_budget"
 	"tg_app_micserv/tools/logger"
 )
 
@@ -23,49 +33,172 @@ type ServiceParser struct {
 }
 
 // NewMessageService создает новый ServiceParser
-func NewServiceParser(parser interfaces.ServiceParser) *ServiceParser {
+func NewServiceParser(parser interfaces.ServiceParser, producer kafka.MessageProducer) *ServiceParser {
 	return &ServiceParser{
-		parser: parser,
+		parser:   parser,
+		producer: producer,
 	}
 }
 
-// FetchMessages парсит и обрабатывает посты(текст) из канала
-func (s *ServiceParser) PostParser(ctx context.Context, nameChannel string, timePeriod time.Duration) (string, error) {
+// PostParser парсит и обрабатывает посты(текст) из канала и возвращает посты с очищенным текстом
+// и отчёт о постах, отброшенных фильтрами options.Include, options.Exclude и options.SkipAds.
+// В nameChannel можно перечислить несколько каналов через запятую — получится сводный дайджест,
+// из которого options.Dedup убирает почти одинаковые посты разных каналов.
+// Если задан options.Query, вместо чтения истории посты ищутся поиском Telegram за период
+// options.From–options.To (по умолчанию — последние timePeriod), при options.FromUser — только посты автора.
+// Если заданы options.Top или options.Percentile, остаются только посты с наибольшей вовлечённостью;
+// options.Mode сокращает каждый пост до заголовка или краткого содержания, а options.Minutes
+// подгоняет дайджест под заданную длительность озвучивания; оценка длительности проставляется каждому посту.
+// Если options.Publish == true, очищенные посты дополнительно отправляются в Kafka.
+func (s *ServiceParser) PostParser(ctx context.Context, nameChannel string, timePeriod time.Duration, options tg_post_model.ParseOptions) ([]tg_post_model.Message, []tg_post_model.FilteredPost, error) {
 	const lbl = "tg_app_micserv/cmd/main.go/main()"
 	logger := logger.NewColorLogger(lbl)
 	slog.SetDefault(logger)
 
-	// Получаем сообщения из fetcher (например, Telegram-клиента)
-	messages, err := s.parser.PostParser(ctx, nameChannel, timePeriod)
+	filter, err := post_filter.New(options)
 	if err != nil {
-		slog.Error(fmt.Sprintf("Ошибка из PostParser: %v", err))
-		return "", err
+		return nil, nil, err
+	}
+
+	// Получаем сообщения каждого канала из fetcher (например, Telegram-клиента)
+	channels := SplitChannels(nameChannel)
+	search := post_search.Query(options, timePeriod, time.Now())
+	var messages []tg_post_model.Message
+	for _, channel := range channels {
+		var channelMessages []tg_post_model.Message
+		if post_search.Enabled(options) {
+			channelMessages, err = s.parser.SearchPosts(ctx, channel, search)
+		} else {
+			channelMessages, err = s.parser.PostParser(ctx, channel, timePeriod)
+		}
+		if err != nil {
+			slog.Error(fmt.Sprintf("Ошибка из PostParser: %v", err))
+			return nil, nil, err
+		}
+		// Альбомы объединяем внутри канала: идентификаторы альбомов разных каналов могут совпасть
+		for _, msg := range MergeAlbums(channelMessages) {
+			msg.Channel = channel
+			messages = append(messages, msg)
+		}
 	}
 	slog.Info("Успешно спарсили посты")
 
-	// Создаем карту для хранения обработанных сообщений
-	messageMap := make(map[int64]string)
+	// Посты сводного дайджеста идут вперемешку, от новых к старым
+	if len(channels) > 1 {
+		sort.SliceStable(messages, func(i, j int) bool { return messages[i].Timestamp.After(messages[j].Timestamp) })
+	}
+
+	// Отбрасываем рекламу и посты, не прошедшие фильтры пользователя
+	messages, filtered := filter.Apply(messages)
+	if len(filtered) > 0 {
+		slog.Info(fmt.Sprintf("Фильтры отбросили %d постов", len(filtered)))
+	}
 
-	// Обрабатываем каждое сообщение
+	// Обрабатываем каждое сообщение, вложения одного альбома озвучиваются одним постом
+	var posts []tg_post_model.Message
 	for _, msg := range messages {
-		// Очищаем текст сообщения
-		cleanedText := FormatText(msg.Text)
-		if cleanedText != "" {
-			// Используем временную метку как ключ
-			messageMap[msg.Timestamp.Unix()] = cleanedText
+		// Очищаем текст сообщения, добавляя описание вложений, пересылки и опроса
+		msg.CleanText = PostText(msg)
+		if msg.CleanText != "" {
+			posts = append(posts, msg)
 		}
 	}
 
-	// Отправляем карту в Kafka
-	if err := s.producer.ProduceMessages(ctx, messageMap); err != nil {
-		return "", fmt.Errorf("ошибка отправки в Kafka: %w", err)
+	// Убираем повторы одной новости из разных каналов
+	if deduped := post_dedup.Apply(posts, options); len(deduped) < len(posts) {
+		slog.Info(fmt.Sprintf("Убрали %d повторов", len(posts)-len(deduped)))
+		posts = deduped
+	}
+
+	// Отбираем самые интересные посты, если пользователь выбрал отбор
+	if selected := post_rank.Select(posts, options, time.Now()); len(selected) < len(posts) {
+		slog.Info(fmt.Sprintf("Отобрали %d из %d постов по вовлечённости", len(selected), len(posts)))
+		posts = selected
+	}
+
+	// Сокращаем посты для дайджеста заголовков или краткого содержания
+	if options.Mode != "" && options.Mode != digest.ModeFull {
+		for i, post := range posts {
+			text := digest.Apply(FormatText(post.Text), FormatText(post.Title), options)
+			posts[i].CleanText = postText(post, text)
+		}
+	}
+
+	// Сообщаем, какие ещё каналы опубликовали ту же новость
+	if options.DedupMention {
+		for i, post := range posts {
+			if also := post_dedup.AlsoReported(post.AlsoIn); also != "" {
+				posts[i].CleanText += "\n" + also
+			}
+		}
 	}
 
-	return "", nil
+	// Оцениваем длительность озвучивания и подгоняем дайджест под выбранное время
+	count := len(posts)
+	posts = speech_budget.Fit(posts, options, time.Now())
+	if options.Minutes > 0 {
+		slog.Info(fmt.Sprintf("Подогнали дайджест под %.0f мин.: осталось %d из %d постов, оценка %s",
+			options.Minutes, len(posts), count, speech_budget.Total(posts).Round(time.Second)))
+	}
+
+	if !options.Publish {
+		return posts, filtered, nil
+	}
+
+	// Отправляем дайджест в Kafka: посты по порядку публикации, от старых к новым
+	if err := s.producer.ProduceDigest(ctx, NewDigest(nameChannel, timePeriod, posts, options)); err != nil {
+		return nil, nil, fmt.Errorf("ошибка отправки в Kafka: %w", err)
+	}
+	slog.Info("Успешно отправили посты в Kafka")
+
+	return posts, filtered, nil
 }
 
 //----------------------------------------------------------------------------------------------------------------------
 
+// NewDigest собирает дайджест для озвучивания: посты идут от старых к новым, каждый со своим каналом
+// и идентификатором, поэтому посты разных каналов, опубликованные в одну секунду, не теряются
+func NewDigest(nameChannel string, timePeriod time.Duration, posts []tg_post_model.Message, options tg_post_model.ParseOptions) tg_post_model.Digest {
+	digest := tg_post_model.Digest{
+		RequestID:    options.RequestID,
+		ChatID:       options.ChatID,
+		ChannelName:  strings.Join(SplitChannels(nameChannel), ", "),
+		PeriodHours:  int(timePeriod.Hours()),
+		SpeakingRate: options.SpeakingRate,
+		VoiceName:    options.VoiceName,
+		Posts:        make([]tg_post_model.DigestPost, 0, len(posts)),
+	}
+	for _, post := range posts {
+		digest.Posts = append(digest.Posts, tg_post_model.DigestPost{
+			Channel:   post.Channel,
+			ID:        post.ID,
+			Timestamp: post.Timestamp.Unix(),
+			Text:      post.CleanText,
+		})
+	}
+	slices.SortStableFunc(digest.Posts, func(a, b tg_post_model.DigestPost) int { return cmp.Compare(a.Timestamp, b.Timestamp) })
+	return digest
+}
+
+// SplitChannels разбирает список каналов через запятую, пропуская пустые и повторяющиеся
+func SplitChannels(nameChannel string) []string {
+	var channels []string
+	seen := make(map[string]bool)
+	for _, channel := range strings.Split(nameChannel, ",") {
+		channel = strings.TrimSpace(channel)
+		if channel == "" || seen[strings.ToLower(channel)] {
+			continue
+		}
+		seen[strings.ToLower(channel)] = true
+		channels = append(channels, channel)
+	}
+	if len(channels) == 0 {
+		// Пустое имя передаём парсеру как есть, чтобы он вернул привычную ошибку
+		return []string{nameChannel}
+	}
+	return channels
+}
+
 // FormatText очищает и форматирует текст постов
 func FormatText(input string) string {
 	lines := strings.Split(input, "\n")
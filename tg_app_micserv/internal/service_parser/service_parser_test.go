@@ -0,0 +1,54 @@
+package service_parser
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"tg_app_micserv/internal/model"
+)
+
+func TestNewDigest(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	posts := []tg_post_model.Message{
+		{ID: 9, Channel: "@b", CleanText: "новее", Timestamp: at.Add(time.Minute)},
+		{ID: 5, Channel: "@a", CleanText: "канал а", Timestamp: at},
+		{ID: 7, Channel: "@b", CleanText: "канал б", Timestamp: at},
+	}
+	digest := NewDigest("@a, @b", 24*time.Hour, posts, tg_post_model.ParseOptions{SpeakingRate: 1.5})
+
+	// Посты, опубликованные в одну секунду, не затирают друг друга и идут от старых к новым
+	if len(digest.Posts) != 3 || digest.Posts[0].ID != 5 || digest.Posts[1].ID != 7 || digest.Posts[2].ID != 9 {
+		t.Fatalf("неожиданный порядок постов: %+v", digest.Posts)
+	}
+	if digest.Posts[1].Channel != "@b" || digest.Posts[1].Text != "канал б" || digest.Posts[1].Timestamp != at.Unix() {
+		t.Fatalf("неожиданный пост: %+v", digest.Posts[1])
+	}
+	if digest.ChannelName != "@a, @b" || digest.PeriodHours != 24 || digest.SpeakingRate != 1.5 {
+		t.Fatalf("неожиданные параметры дайджеста: %+v", digest)
+	}
+}
+
+// TestBotRequestDigest проверяет путь запроса бота до сообщения сервису Text-to-Speech:
+// чат, идентификатор запроса и голос из сообщения бота попадают в дайджест
+func TestBotRequestDigest(t *testing.T) {
+	data := `{"request_id":"req-1","chat_id":42,"channel":"@news","speaking_rate":1.5,"voice_name":"ru-RU-Wavenet-A","hours":24,"minutes":10}`
+	var req tg_post_model.BotRequest
+	if err := json.Unmarshal([]byte(data), &req); err != nil {
+		t.Fatal(err)
+	}
+	options := req.Options(time.Now())
+	if !options.Publish || options.Minutes != 10 {
+		t.Fatalf("неожиданные параметры обработки: %+v", options)
+	}
+
+	posts := []tg_post_model.Message{{ID: 5, Channel: "@news", CleanText: "текст", Timestamp: time.Unix(1700000000, 0)}}
+	digest, err := json.Marshal(NewDigest(req.Channel, time.Duration(req.Hours)*time.Hour, posts, options))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"request_id":"req-1","chat_id":42,"channel_name":"@news","period_hours":24,"speaking_rate":1.5,"voice_name":"ru-RU-Wavenet-A","posts":[{"channel":"@news","id":5,"timestamp":1700000000,"text":"текст"}]}`
+	if string(digest) != want {
+		t.Fatalf("неожиданный дайджест:\n%s\nожидали:\n%s", digest, want)
+	}
+}
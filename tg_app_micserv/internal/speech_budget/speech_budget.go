@@ -0,0 +1,155 @@
+// Оценка длительности озвучивания и подгонка дайджеста под заданное время
+
+package speech_budget
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"tg_app_micserv/internal/digest"
+	"tg_app_micserv/internal/model"
+	"tg_app_micserv/internal/post_rank"
+)
+
+// Параметры оценки: сколько символов очищенного текста голос произносит в секунду при скорости 1.0
+// и сколько добавляют к каждому посту объявление времени публикации и паузы между постами.
+// Значения по умолчанию приблизительные; их стоит уточнить по длительностям глав синтезированных
+// дайджестов для используемых голосов и задать через Calibrate
+var (
+	charsPerSecond = DefaultCharsPerSecond
+	postOverhead   = DefaultPostOverhead
+)
+
+// Значения параметров оценки по умолчанию
+const (
+	DefaultCharsPerSecond = 14.0
+	DefaultPostOverhead   = 3 * time.Second
+)
+
+// Допустимая скорость речи — как в сервисе озвучивания; 0 означает скорость по умолчанию
+const (
+	defaultSpeakingRate = 1.0
+	minSpeakingRate     = 0.25
+	maxSpeakingRate     = 4.0
+)
+
+// minSummaryLength длина, короче которой посты при подгонке не сокращаются
+const minSummaryLength = 150
+
+// ErrInvalidBudget возвращается при некорректной длительности или скорости речи
+var ErrInvalidBudget = errors.New("minutes должно быть неотрицательным, а speaking_rate — от 0.25 до 4")
+
+// Calibrate задаёт параметры оценки длительности; вызывается при запуске, до обработки запросов
+func Calibrate(chars float64, overhead time.Duration) {
+	charsPerSecond = chars
+	postOverhead = overhead
+}
+
+// Validate проверяет длительность дайджеста и скорость речи из запроса
+func Validate(options tg_post_model.ParseOptions) error {
+	if options.Minutes < 0 || math.IsNaN(options.Minutes) {
+		return ErrInvalidBudget
+	}
+	if options.SpeakingRate != 0 && (options.SpeakingRate < minSpeakingRate || options.SpeakingRate > maxSpeakingRate) {
+		return ErrInvalidBudget
+	}
+	return nil
+}
+
+// Estimate оценивает длительность озвучивания поста с объявлением и паузами
+func Estimate(text string, speakingRate float64) time.Duration {
+	if speakingRate == 0 {
+		speakingRate = defaultSpeakingRate
+	}
+	seconds := float64(utf8.RuneCountInString(text)) / (charsPerSecond * speakingRate)
+	return time.Duration(seconds*float64(time.Second)) + postOverhead
+}
+
+// Total возвращает суммарную оценку длительности постов
+func Total(posts []tg_post_model.Message) time.Duration {
+	var total time.Duration
+	for _, post := range posts {
+		total += time.Duration(post.Duration * float64(time.Second))
+	}
+	return total
+}
+
+// Fit проставляет постам оценку длительности и, если задан options.Minutes, подгоняет дайджест под это время:
+// сначала длинные посты сокращаются до краткого содержания, затем отбрасываются наименее популярные посты,
+// а если и один оставшийся пост не помещается — его текст обрезается по границе предложения.
+// Порядок оставшихся постов не меняется.
+func Fit(posts []tg_post_model.Message, options tg_post_model.ParseOptions, now time.Time) []tg_post_model.Message {
+	estimate(posts, options.SpeakingRate)
+	budget := time.Duration(options.Minutes * float64(time.Minute))
+	if budget <= 0 || Total(posts) <= budget {
+		return posts
+	}
+
+	// Сокращаем посты пропорционально превышению, но не короче minSummaryLength
+	ratio := float64(budget) / float64(Total(posts))
+	for i, post := range posts {
+		length := max(int(float64(utf8.RuneCountInString(post.CleanText))*ratio), minSummaryLength)
+		posts[i].CleanText = digest.Summarize(post.CleanText, length)
+	}
+	estimate(posts, options.SpeakingRate)
+
+	// Отбрасываем наименее популярные посты, пока дайджест не уложится во время
+	for len(posts) > 1 && Total(posts) > budget {
+		worst := 0
+		for i, post := range posts {
+			if post_rank.Score(post, now) < post_rank.Score(posts[worst], now) {
+				worst = i
+			}
+		}
+		posts = slices.Delete(posts, worst, worst+1)
+	}
+
+	// Единственный оставшийся пост обрезаем до длины, которая успеет прозвучать
+	if len(posts) == 1 && Total(posts) > budget {
+		speakingRate := cmp.Or(options.SpeakingRate, defaultSpeakingRate)
+		runes := int((budget - postOverhead).Seconds() * charsPerSecond * speakingRate)
+		posts[0].CleanText = trim(posts[0].CleanText, max(runes, 1))
+		estimate(posts, options.SpeakingRate)
+	}
+	return posts
+}
+
+// estimate проставляет постам оценку длительности в секундах
+func estimate(posts []tg_post_model.Message, speakingRate float64) {
+	for i, post := range posts {
+		posts[i].Duration = math.Round(Estimate(post.CleanText, speakingRate).Seconds()*10) / 10
+	}
+}
+
+// trim обрезает текст до length символов: по последнему целиком поместившемуся предложению,
+// а если не поместилось даже первое — по последнему пробелу
+func trim(text string, length int) string {
+	if utf8.RuneCountInString(text) <= length {
+		return text
+	}
+
+	var result []string
+	total := 0
+	for _, sentence := range digest.Sentences(text) {
+		size := utf8.RuneCountInString(sentence)
+		if total+size > length {
+			break
+		}
+		result = append(result, sentence)
+		total += size + 1
+	}
+	if len(result) > 0 {
+		return strings.Join(result, " ")
+	}
+
+	cut := string([]rune(text)[:length])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut)
+}
@@ -0,0 +1,53 @@
+package speech_budget
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"tg_app_micserv/internal/model"
+)
+
+func TestEstimate(t *testing.T) {
+	text := strings.Repeat("а", 140)
+	if got := Estimate(text, 1); got != 13*time.Second {
+		t.Fatalf("ожидали 13s, получили %v", got)
+	}
+	if got := Estimate(text, 2); got != 8*time.Second {
+		t.Fatalf("при двойной скорости ожидали 8s, получили %v", got)
+	}
+}
+
+func TestFit(t *testing.T) {
+	now := time.Now()
+	sentence := "Новость о важном событии в стране. "
+	posts := []tg_post_model.Message{
+		{ID: 1, Views: 10, CleanText: strings.Repeat(sentence, 10), Timestamp: now.Add(-time.Hour)},
+		{ID: 2, Views: 1000, CleanText: strings.Repeat(sentence, 10), Timestamp: now.Add(-time.Hour)},
+		{ID: 3, Views: 500, CleanText: strings.Repeat(sentence, 10), Timestamp: now.Add(-time.Hour)},
+	}
+
+	fitted := Fit(posts, tg_post_model.ParseOptions{Minutes: 0.5}, now)
+	if total := Total(fitted); total > 30*time.Second {
+		t.Fatalf("дайджест не уложился в 30s: %v", total)
+	}
+	if len(fitted) == 0 || fitted[0].ID != 2 {
+		t.Fatalf("ожидали, что останется самый популярный пост, получили %+v", fitted)
+	}
+
+	all := Fit([]tg_post_model.Message{{CleanText: sentence}}, tg_post_model.ParseOptions{}, now)
+	if all[0].Duration == 0 {
+		t.Fatal("без ограничения длительности оценка всё равно должна проставляться")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, options := range []tg_post_model.ParseOptions{{Minutes: -1}, {SpeakingRate: 5}, {SpeakingRate: 0.1}} {
+		if Validate(options) == nil {
+			t.Fatalf("ожидали ошибку для %+v", options)
+		}
+	}
+	if err := Validate(tg_post_model.ParseOptions{Minutes: 10, SpeakingRate: 1.5}); err != nil {
+		t.Fatalf("неожиданная ошибка: %v", err)
+	}
+}
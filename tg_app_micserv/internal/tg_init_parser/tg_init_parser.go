@@ -13,7 +13,10 @@ import (
 	"github.com/gotd/td/telegram"
 	"github.com/gotd/td/telegram/auth"
 	"github.com/gotd/td/tg"
+	"tg_app_micserv/internal/channel_ref"
 	"tg_app_micserv/internal/model"
+	"tg_app_micserv/internal/peer_cache"
+	"tg_app_micserv/internal/tg_message"
 	"tg_app_micserv/tools/logger"
 )
 
@@ -23,34 +26,174 @@ type Client struct {
 	tgAppClient    *telegram.Client
 	phone          string
 	twoFacPassword string
-	closer         chan struct{} // Для управления завершением
-	once           sync.Once     // Для идемпотентности Close
+	joinInvites    bool              // Вступать в каналы по пригласительным ссылкам
+	peers          *peer_cache.Store // Кеш найденных каналов
+	closer         chan struct{}     // Для управления завершением
+	once           sync.Once         // Для идемпотентности Close
 }
 
-// NewClient создает новый клиент Telegram
-func NewClient(tgApiID int, tgApiHash, phone, twoFacPassword string, storage telegram.SessionStorage) *Client {
+// NewClient создает новый клиент Telegram; middlewares оборачивают все запросы к API
+func NewClient(tgApiID int, tgApiHash, phone, twoFacPassword string, joinInvites bool, peers *peer_cache.Store, storage telegram.SessionStorage, middlewares ...telegram.Middleware) *Client {
 	tgAppClient := telegram.NewClient(tgApiID, tgApiHash, telegram.Options{
 		SessionStorage: storage,
+		Middlewares:    middlewares,
 	})
 	return &Client{
 		client:         tgAppClient,
 		tgAppClient:    tgAppClient,
 		phone:          phone,
 		twoFacPassword: twoFacPassword,
+		joinInvites:    joinInvites,
+		peers:          peers,
 		closer:         make(chan struct{}),
 	}
 }
 
-// PostParser извлекает сообщения из канала Telegram (парсит заданный канал)
+// Ограничения загрузки постов: Telegram отдаёт историю и результаты поиска страницами не больше pageSize
+const (
+	pageSize        = 100
+	maxHistoryPosts = 1000 // Больше постов за один период не загружается
+	maxSearchPosts  = 1000 // Больше постов за один поиск не загружается
+)
+
+// PostParser извлекает сообщения из канала Telegram (парсит заданный канал).
+// Канал можно указать именем, ссылкой t.me, числовым идентификатором или пригласительной ссылкой.
+// История загружается страницами до начала периода, но не больше maxHistoryPosts постов.
 func (c *Client) PostParser(ctx context.Context, tgNameChannel string, timePeriod time.Duration) ([]tg_post_model.Message, error) {
+	// Создаем срез для хранения сообщений
+	var messages []tg_post_model.Message
+	err := c.withChannel(ctx, tgNameChannel, func(ctx context.Context, api *tg.Client, _ *channel_ref.Resolver, channel channel_ref.Channel) error {
+		messages = nil
+
+		// Вычисляем временной порог, чтобы брать только последние сообщения
+		timeThreshold := time.Now().Add(-timePeriod)
+
+		// Загружаем историю страницами от новых к старым, пока не дойдём до порога
+		request := &tg.MessagesGetHistoryRequest{
+			Peer:  channel.InputPeer(),
+			Limit: pageSize,
+		}
+		for len(messages) < maxHistoryPosts {
+			tgMessages, err := api.MessagesGetHistory(ctx, request)
+			if err != nil {
+				return err
+			}
+
+			msgSlice, entities, err := messagesOf(tgMessages)
+			if err != nil {
+				return err
+			}
+
+			// Перебираем все сообщения из полученного среза
+			for _, msg := range msgSlice {
+				message, ok := msg.(*tg.Message)
+				if !ok {
+					continue
+				}
+
+				// Преобразуем дату сообщения в time.Time
+				msgTime := time.Unix(int64(message.Date), 0)
+				if msgTime.Before(timeThreshold) {
+					return nil
+				}
+
+				// Добавляем сообщение в результат вместе с вложениями, пересылкой и статистикой
+				messages = append(messages, tg_message.Convert(message, entities))
+			}
+
+			// Короткая страница — история закончилась; следующая страница начинается после последнего поста
+			if len(msgSlice) < pageSize {
+				break
+			}
+			request.OffsetID = msgSlice[len(msgSlice)-1].GetID()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Возвращаем срез сообщений
+	return messages, nil
+}
+
+// SearchPosts ищет посты канала поиском Telegram (messages.search) за период query.From–query.To,
+// при заданном query.FromUser — только посты этого автора. Результаты загружаются страницами
+// от новых к старым, но не больше maxSearchPosts.
+func (c *Client) SearchPosts(ctx context.Context, tgNameChannel string, query tg_post_model.SearchQuery) ([]tg_post_model.Message, error) {
+	var messages []tg_post_model.Message
+	err := c.withChannel(ctx, tgNameChannel, func(ctx context.Context, api *tg.Client, resolver *channel_ref.Resolver, channel channel_ref.Channel) error {
+		messages = nil
+
+		request := &tg.MessagesSearchRequest{
+			Peer:    channel.InputPeer(),
+			Q:       query.Query,
+			Filter:  &tg.InputMessagesFilterEmpty{},
+			MinDate: int(query.From.Unix()),
+			MaxDate: int(query.To.Unix()),
+			Limit:   pageSize,
+		}
+		if query.FromUser != "" {
+			author, err := resolver.ResolveAuthor(ctx, query.FromUser)
+			if err != nil {
+				return err
+			}
+			request.SetFromID(author)
+		}
+
+		for len(messages) < maxSearchPosts {
+			found, err := api.MessagesSearch(ctx, request)
+			if err != nil {
+				return err
+			}
+			msgSlice, entities, err := messagesOf(found)
+			if err != nil {
+				return err
+			}
+
+			for _, msg := range msgSlice {
+				if message, ok := msg.(*tg.Message); ok {
+					messages = append(messages, tg_message.Convert(message, entities))
+				}
+			}
+
+			// Короткая страница — результаты закончились; следующая страница начинается после последнего поста
+			if len(msgSlice) < pageSize {
+				break
+			}
+			request.OffsetID = msgSlice[len(msgSlice)-1].GetID()
+		}
+		slog.Info(fmt.Sprintf("Нашли %d постов канала %s по запросу %q", len(messages), channel.Name(), query.Query))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// WithClient выполняет request с клиентом Telegram аккаунта, без авторизации по номеру телефона и поиска канала
+func (c *Client) WithClient(ctx context.Context, request func(ctx context.Context, client *telegram.Client) error) error {
+	return c.tgAppClient.Run(ctx, func(ctx context.Context) error {
+		return request(ctx, c.tgAppClient)
+	})
+}
+
+// withChannel авторизует клиента, находит канал и выполняет request. Если Telegram отверг канал,
+// он убирается из кеша; если данные были из кеша, они могли устареть, поэтому канал находится заново
+// и request повторяется.
+func (c *Client) withChannel(ctx context.Context, tgNameChannel string, request func(ctx context.Context, api *tg.Client, resolver *channel_ref.Resolver, channel channel_ref.Channel) error) error {
 	const lbl = "tg_app_micserv/cmd/main.go/main()"
 	logger := logger.NewColorLogger(lbl)
 	slog.SetDefault(logger)
 
-	// Создаем срез для хранения сообщений
-	var messages []tg_post_model.Message
+	ref, err := channel_ref.Parse(tgNameChannel)
+	if err != nil {
+		return err
+	}
+
 	// Запускаем клиента Telegram в асинхронном режиме
-	err := c.tgAppClient.Run(ctx, func(ctx context.Context) error {
+	err = c.tgAppClient.Run(ctx, func(ctx context.Context) error {
 		status, err := c.tgAppClient.Auth().Status(ctx) // Проверяем статус аутентификации текущего клиента
 		if err != nil {
 			slog.Error("не удалось проверить статус аутентификации")
@@ -75,95 +218,50 @@ func (c *Client) PostParser(ctx context.Context, tgNameChannel string, timePerio
 
 		// Получаем API клиента Telegram
 		api := c.tgAppClient.API()
-		usernameRequest := &tg.ContactsResolveUsernameRequest{
-			Username: tgNameChannel,
-		}
-		// Отправляем запрос разрешения username
-		resolved, err := api.ContactsResolveUsername(ctx, usernameRequest)
+		// Находим канал по ссылке, сначала в кеше собеседников
+		resolver := channel_ref.NewResolver(api, c.joinInvites, c.peers)
+		channel, err := resolver.Resolve(ctx, ref)
 		if err != nil {
-			return fmt.Errorf("не удалось разрешить tgNameChannel: %w", err)
+			return fmt.Errorf("не удалось найти канал %s: %w", ref, err)
 		}
+		slog.Info(fmt.Sprintf("Нашли канал %s (id %d, из кеша: %t)", channel.Name(), channel.ID, channel.Cached))
 
-		// Переменная для хранения входного представления канала или пользователя
-		var inputPeer tg.InputPeerClass
-		if len(resolved.Chats) > 0 {
-			chat, ok := resolved.Chats[0].(*tg.Channel)
-			if !ok {
-				slog.Info("Ожидаемый тип канала, получен")
-				return fmt.Errorf("Ожидаемый тип канала, получен %T", resolved.Chats[0])
-			}
-			inputPeer = &tg.InputPeerChannel{
-				ChannelID:  chat.ID,
-				AccessHash: chat.AccessHash,
-			}
-		} else if len(resolved.Users) > 0 {
-			user, ok := resolved.Users[0].(*tg.User)
-			if !ok {
-				return fmt.Errorf("Ожидаемый тип пользователя, получен %T", resolved.Users[0])
+		err = request(ctx, api, resolver, channel)
+		if err != nil && channel_ref.IsInvalidPeer(err) {
+			resolver.Invalidate(channel)
+			if channel.Cached {
+				slog.Info("Данные канала в кеше устарели, запрашиваем заново")
+				channel, err = resolver.Resolve(ctx, ref)
+				if err != nil {
+					return fmt.Errorf("не удалось найти канал %s: %w", ref, err)
+				}
+				err = request(ctx, api, resolver, channel)
 			}
-			inputPeer = &tg.InputPeerUser{
-				UserID:     user.ID,
-				AccessHash: user.AccessHash,
-			}
-		} else {
-			return fmt.Errorf("Канал с таким именем не найден")
 		}
-
-		// Вычисляем временной порог, чтобы брать только последние сообщения
-		timeThreshold := time.Now().Add(-timePeriod)
-
-		// Запрашиваем историю сообщений из канала / пользователя
-		tgMessages, err := api.MessagesGetHistory(ctx, &tg.MessagesGetHistoryRequest{
-			Peer:  inputPeer,
-			Limit: 100,
-		})
 		if err != nil {
 			slog.Error("Не удалось получить посты из канала")
 			return fmt.Errorf("Не удалось получить посты из канала: %w", err)
 		}
-
-		// Создаем срез для хранения сообщений Telegram API
-		var msgSlice []tg.MessageClass
-		switch m := tgMessages.(type) {
-		case *tg.MessagesMessages:
-			msgSlice = m.Messages
-		case *tg.MessagesMessagesSlice:
-			msgSlice = m.Messages
-		case *tg.MessagesChannelMessages:
-			msgSlice = m.Messages
-		default:
-			return fmt.Errorf("неожиданный тип сообщения: %T", m)
-		}
-
-		// Перебираем все сообщения из полученного среза
-		for _, msg := range msgSlice {
-			message, ok := msg.(*tg.Message)
-			if !ok {
-				continue
-			}
-
-			// Преобразуем дату сообщения в time.Time
-			msgTime := time.Unix(int64(message.Date), 0)
-			if msgTime.Before(timeThreshold) {
-				break
-			}
-
-			// Добавляем сообщение в результат с нужными полями
-			messages = append(messages, tg_post_model.Message{
-				Text:      message.Message,
-				Timestamp: msgTime,
-			})
-		}
-
 		return nil
 	})
 	if err != nil {
 		slog.Error("Не удалось запустить Telegram App Client")
-		return nil, fmt.Errorf("не удалось запустить Telegram tgAppClient: %w", err)
+		return fmt.Errorf("не удалось запустить Telegram tgAppClient: %w", err)
 	}
+	return nil
+}
 
-	// Возвращаем срез сообщений
-	return messages, nil
+// messagesOf достаёт сообщения из ответа Telegram и собирает имена каналов и пользователей для пересланных постов
+func messagesOf(tgMessages tg.MessagesMessagesClass) ([]tg.MessageClass, tg.Entities, error) {
+	switch m := tgMessages.(type) {
+	case *tg.MessagesMessages:
+		return m.Messages, tg_message.NewEntities(m.Users, m.Chats), nil
+	case *tg.MessagesMessagesSlice:
+		return m.Messages, tg_message.NewEntities(m.Users, m.Chats), nil
+	case *tg.MessagesChannelMessages:
+		return m.Messages, tg_message.NewEntities(m.Users, m.Chats), nil
+	}
+	return nil, tg.Entities{}, fmt.Errorf("неожиданный тип сообщения: %T", tgMessages)
 }
 
 // Close закрывает клиент
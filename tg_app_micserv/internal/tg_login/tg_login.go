@@ -0,0 +1,453 @@
+// Вход в аккаунты Telegram по QR-коду и управление сессиями
+
+package tg_login
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/png"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/telegram/auth"
+	"github.com/gotd/td/telegram/auth/qrlogin"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+	"rsc.io/qr"
+	"tg_app_micserv/internal/tg_session_storage"
+	"tg_app_micserv/tools/logger"
+)
+
+// Ошибки управления входом
+var (
+	ErrUnknownAccount   = errors.New("аккаунт не найден")
+	ErrNoLogin          = errors.New("вход по QR-коду не запущен")
+	ErrNoQRCode         = errors.New("QR-код недоступен: вход не ожидает сканирования")
+	ErrPasswordUnneeded = errors.New("пароль двухфакторной аутентификации сейчас не требуется")
+)
+
+// loginTimeout максимальное время на сканирование QR-кода и ввод пароля
+const loginTimeout = 5 * time.Minute
+
+// Status этап входа по QR-коду
+type Status string
+
+const (
+	StatusWaitingScan    Status = "waiting_scan"    // QR-код показан и ждёт сканирования в приложении Telegram
+	StatusPasswordNeeded Status = "password_needed" // Код подтверждён, нужен пароль двухфакторной аутентификации
+	StatusAuthorized     Status = "authorized"      // Вход выполнен, сессия сохранена
+	StatusFailed         Status = "failed"          // Вход не удался или истекло время ожидания
+)
+
+// Account параметры аккаунта, в который выполняется вход
+type Account struct {
+	Name        string                // Имя аккаунта
+	APIID       int                   // API ID приложения Telegram
+	APIHash     string                // API Hash приложения Telegram
+	SessionFile string                // Файл сессии аккаунта, общий с клиентом парсинга
+	Middlewares []telegram.Middleware // Middleware запросов к API
+}
+
+// Pool пул аккаунтов парсинга. Запросы к сессии уже авторизованного аккаунта выполняются клиентом
+// этого аккаунта из пула, чтобы с файлом сессии не работали два клиента одновременно
+type Pool interface {
+	WithClient(ctx context.Context, name string, request func(ctx context.Context, client *telegram.Client) error) error
+	LoggedOut(name string) // Исключает аккаунт из работы после выхода
+	Restore(name string)   // Возвращает аккаунт в работу после входа
+}
+
+// User пользователь, от имени которого авторизована сессия
+type User struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username,omitempty"`
+	FirstName string `json:"first_name,omitempty"`
+	Phone     string `json:"phone,omitempty"`
+}
+
+// State состояние входа по QR-коду
+type State struct {
+	Account   string     `json:"account"`              // Имя аккаунта
+	Status    Status     `json:"status"`               // Этап входа
+	URL       string     `json:"url,omitempty"`        // Ссылка tg://login из QR-кода
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Время, когда QR-код сменится новым
+	User      *User      `json:"user,omitempty"`       // Пользователь после успешного входа
+	Error     string     `json:"error,omitempty"`      // Последняя ошибка (например, неверный пароль)
+}
+
+// Authorization активная сессия аккаунта
+type Authorization struct {
+	Hash       int64     `json:"hash"`    // Идентификатор сессии для отзыва
+	Current    bool      `json:"current"` // Сессия этого сервиса
+	Device     string    `json:"device"`
+	Platform   string    `json:"platform"`
+	AppName    string    `json:"app_name"`
+	IP         string    `json:"ip"`
+	Country    string    `json:"country"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastActive time.Time `json:"last_active"`
+}
+
+// Session сведения о сессии аккаунта
+type Session struct {
+	Account        string          `json:"account"`
+	Authorized     bool            `json:"authorized"`
+	User           *User           `json:"user,omitempty"`
+	Authorizations []Authorization `json:"authorizations,omitempty"`
+}
+
+// login вход по QR-коду, выполняющийся в фоне
+type login struct {
+	state    State
+	token    qrlogin.Token
+	password chan string // Пароль двухфакторной аутентификации от администратора
+	done     bool
+}
+
+// Manager выполняет вход в аккаунты по QR-коду и управляет их сессиями.
+// Пустое имя аккаунта во всех методах означает первый аккаунт.
+type Manager struct {
+	mutex    sync.Mutex
+	accounts map[string]Account
+	first    string // Имя первого аккаунта
+	logins   map[string]*login
+	pool     Pool // Пул аккаунтов парсинга
+}
+
+// NewManager создает Manager для аккаунтов пула pool
+func NewManager(accounts []Account, pool Pool) *Manager {
+	m := &Manager{
+		accounts: make(map[string]Account, len(accounts)),
+		logins:   make(map[string]*login),
+		pool:     pool,
+	}
+	for _, account := range accounts {
+		m.accounts[account.Name] = account
+	}
+	if len(accounts) > 0 {
+		m.first = accounts[0].Name
+	}
+	return m
+}
+
+// Start запускает вход по QR-коду; если вход уже идёт, возвращает его состояние
+func (m *Manager) Start(name string) (State, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	account, err := m.lookup(name)
+	if err != nil {
+		return State{}, err
+	}
+	if l, ok := m.logins[account.Name]; ok && !l.done {
+		return l.state, nil
+	}
+
+	l := &login{
+		state:    State{Account: account.Name, Status: StatusWaitingScan},
+		password: make(chan string, 1),
+	}
+	m.logins[account.Name] = l
+	go m.run(account, l)
+	return l.state, nil
+}
+
+// Status возвращает состояние последнего входа по QR-коду
+func (m *Manager) Status(name string) (State, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	l, err := m.login(name)
+	if err != nil {
+		return State{}, err
+	}
+	return l.state, nil
+}
+
+// QRCode возвращает текущий QR-код входа в формате PNG
+func (m *Manager) QRCode(name string) ([]byte, error) {
+	m.mutex.Lock()
+	l, err := m.login(name)
+	if err == nil && (l.state.Status != StatusWaitingScan || l.state.URL == "") {
+		err = ErrNoQRCode
+	}
+	var token qrlogin.Token
+	if err == nil {
+		token = l.token
+	}
+	m.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := token.Image(qr.M)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось сформировать QR-код: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("не удалось закодировать QR-код: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Password передаёт пароль двухфакторной аутентификации входу, который его ожидает
+func (m *Manager) Password(name, password string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	l, err := m.login(name)
+	if err != nil {
+		return err
+	}
+	if l.state.Status != StatusPasswordNeeded {
+		return ErrPasswordUnneeded
+	}
+	select {
+	case l.password <- password:
+		return nil
+	default:
+		return errors.New("пароль уже проверяется")
+	}
+}
+
+// Session возвращает пользователя сессии и список активных сессий аккаунта
+func (m *Manager) Session(ctx context.Context, name string) (Session, error) {
+	account, err := m.account(name)
+	if err != nil {
+		return Session{}, err
+	}
+
+	session := Session{Account: account.Name}
+	err = m.pool.WithClient(ctx, account.Name, func(ctx context.Context, client *telegram.Client) error {
+		status, err := client.Auth().Status(ctx)
+		if err != nil {
+			return fmt.Errorf("не удалось проверить статус аутентификации: %w", err)
+		}
+		if !status.Authorized {
+			return nil
+		}
+		session.Authorized = true
+		session.User = newUser(status.User)
+
+		authorizations, err := client.API().AccountGetAuthorizations(ctx)
+		if err != nil {
+			return fmt.Errorf("не удалось получить список сессий: %w", err)
+		}
+		for _, a := range authorizations.Authorizations {
+			session.Authorizations = append(session.Authorizations, Authorization{
+				Hash:       a.Hash,
+				Current:    a.Current,
+				Device:     a.DeviceModel,
+				Platform:   a.Platform,
+				AppName:    a.AppName,
+				IP:         a.IP,
+				Country:    a.Country,
+				CreatedAt:  time.Unix(int64(a.DateCreated), 0),
+				LastActive: time.Unix(int64(a.DateActive), 0),
+			})
+		}
+		return nil
+	})
+	return session, err
+}
+
+// Logout завершает сессию сервиса, исключает аккаунт из пула и удаляет файл сессии; если hash не нулевой,
+// вместо этого отзывает другую сессию аккаунта с этим идентификатором
+func (m *Manager) Logout(ctx context.Context, name string, hash int64) error {
+	const lbl = "tg_app_micserv/internal/tg_login/tg_login.go/Logout()"
+	myLogger := logger.NewColorLogger(lbl)
+
+	account, err := m.account(name)
+	if err != nil {
+		return err
+	}
+
+	err = m.pool.WithClient(ctx, account.Name, func(ctx context.Context, client *telegram.Client) error {
+		if hash != 0 {
+			if _, err := client.API().AccountResetAuthorization(ctx, hash); err != nil {
+				return fmt.Errorf("не удалось отозвать сессию: %w", err)
+			}
+			return nil
+		}
+		if _, err := client.API().AuthLogOut(ctx); err != nil && !tgerr.Is(err, "AUTH_KEY_UNREGISTERED") {
+			return fmt.Errorf("не удалось выйти из аккаунта: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if hash != 0 {
+		myLogger.Info("Отозвали сессию аккаунта", slog.String("account", account.Name), slog.Int64("hash", hash))
+		return nil
+	}
+
+	// Ключ авторизации после выхода недействителен — аккаунт не получает запросов парсинга до повторного входа,
+	// а файл удаляется, чтобы следующий вход начался с чистой сессии
+	m.pool.LoggedOut(account.Name)
+	if err := os.Remove(account.SessionFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("не удалось удалить файл сессии: %w", err)
+	}
+	myLogger.Info("Вышли из аккаунта", slog.String("account", account.Name))
+	return nil
+}
+
+// run выполняет вход по QR-коду до успеха, ошибки или истечения loginTimeout
+func (m *Manager) run(account Account, l *login) {
+	const lbl = "tg_app_micserv/internal/tg_login/tg_login.go/run()"
+	myLogger := logger.NewColorLogger(lbl)
+
+	ctx, cancel := context.WithTimeout(context.Background(), loginTimeout)
+	defer cancel()
+
+	dispatcher := tg.NewUpdateDispatcher()
+	loggedIn := qrlogin.OnLoginToken(dispatcher)
+	client := newClient(account, dispatcher)
+
+	var user *User
+	err := client.Run(ctx, func(ctx context.Context) error {
+		status, err := client.Auth().Status(ctx)
+		if err != nil {
+			return fmt.Errorf("не удалось проверить статус аутентификации: %w", err)
+		}
+		if status.Authorized {
+			user = newUser(status.User)
+			return nil
+		}
+
+		_, err = client.QR().Auth(ctx, loggedIn, func(_ context.Context, token qrlogin.Token) error {
+			m.update(l, func(state *State) {
+				expires := token.Expires()
+				l.token = token
+				state.URL = token.URL()
+				state.ExpiresAt = &expires
+			})
+			return nil
+		})
+		if tgerr.Is(err, "SESSION_PASSWORD_NEEDED") {
+			err = m.checkPassword(ctx, client, l)
+		}
+		if err != nil {
+			return err
+		}
+
+		status, err = client.Auth().Status(ctx)
+		if err != nil {
+			return fmt.Errorf("не удалось проверить статус аутентификации: %w", err)
+		}
+		user = newUser(status.User)
+		return nil
+	})
+
+	m.update(l, func(state *State) {
+		l.done = true
+		state.URL = ""
+		state.ExpiresAt = nil
+		if err != nil {
+			state.Status = StatusFailed
+			state.Error = err.Error()
+			return
+		}
+		state.Status = StatusAuthorized
+		state.Error = ""
+		state.User = user
+	})
+	if err != nil {
+		myLogger.Error("Вход по QR-коду не удался", slog.String("account", account.Name), slog.Any("error", err))
+		return
+	}
+	myLogger.Info("Выполнили вход по QR-коду", slog.String("account", account.Name), slog.Int64("user_id", user.ID))
+	// Вход возвращает в пул аккаунт, из которого вышли или который был заблокирован
+	m.pool.Restore(account.Name)
+}
+
+// checkPassword ждёт пароль двухфакторной аутентификации и повторяет запрос, пока пароль неверный
+func (m *Manager) checkPassword(ctx context.Context, client *telegram.Client, l *login) error {
+	m.update(l, func(state *State) {
+		state.Status = StatusPasswordNeeded
+		state.URL = ""
+		state.ExpiresAt = nil
+	})
+
+	for {
+		var password string
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case password = <-l.password:
+		}
+
+		_, err := client.Auth().Password(ctx, password)
+		if errors.Is(err, auth.ErrPasswordInvalid) {
+			m.update(l, func(state *State) { state.Error = "неверный пароль" })
+			continue
+		}
+		return err
+	}
+}
+
+// update изменяет состояние входа под блокировкой
+func (m *Manager) update(l *login, update func(state *State)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	update(&l.state)
+}
+
+// login возвращает последний вход аккаунта; вызывается под блокировкой
+func (m *Manager) login(name string) (*login, error) {
+	account, err := m.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	l, ok := m.logins[account.Name]
+	if !ok {
+		return nil, ErrNoLogin
+	}
+	return l, nil
+}
+
+// account возвращает параметры аккаунта по имени
+func (m *Manager) account(name string) (Account, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.lookup(name)
+}
+
+// lookup находит аккаунт по имени, пустое имя — первый аккаунт; вызывается под блокировкой
+func (m *Manager) lookup(name string) (Account, error) {
+	if name == "" {
+		name = m.first
+	}
+	account, ok := m.accounts[name]
+	if !ok {
+		return Account{}, fmt.Errorf("%w: %s", ErrUnknownAccount, name)
+	}
+	return account, nil
+}
+
+// newClient создает клиента Telegram с сессией аккаунта; handler может быть nil
+func newClient(account Account, handler telegram.UpdateHandler) *telegram.Client {
+	return telegram.NewClient(account.APIID, account.APIHash, telegram.Options{
+		SessionStorage: tg_session_storage.NewFileSessionStorage(account.SessionFile),
+		UpdateHandler:  handler,
+		Middlewares:    account.Middlewares,
+	})
+}
+
+// newUser преобразует пользователя Telegram в сведения для ответа
+func newUser(user *tg.User) *User {
+	if user == nil {
+		return nil
+	}
+	return &User{
+		ID:        user.ID,
+		Username:  user.Username,
+		FirstName: user.FirstName,
+		Phone:     user.Phone,
+	}
+}
@@ -0,0 +1,190 @@
+// Преобразование сообщений Telegram в посты
+
+package tg_message
+
+import (
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/gotd/td/tg"
+	"tg_app_micserv/internal/model"
+)
+
+// NewEntities собирает пользователей и каналы из ответа Telegram, чтобы подставить имена источников пересылки
+func NewEntities(users []tg.UserClass, chats []tg.ChatClass) tg.Entities {
+	entities := tg.Entities{
+		Users:    make(map[int64]*tg.User),
+		Chats:    make(map[int64]*tg.Chat),
+		Channels: make(map[int64]*tg.Channel),
+	}
+	for _, u := range users {
+		if user, ok := u.(*tg.User); ok {
+			entities.Users[user.ID] = user
+		}
+	}
+	for _, c := range chats {
+		switch chat := c.(type) {
+		case *tg.Chat:
+			entities.Chats[chat.ID] = chat
+		case *tg.Channel:
+			entities.Channels[chat.ID] = chat
+		}
+	}
+	return entities
+}
+
+// Convert преобразует сообщение Telegram в пост; очищенный текст заполняет сервис парсинга
+func Convert(message *tg.Message, entities tg.Entities) tg_post_model.Message {
+	post := tg_post_model.Message{
+		ID:        message.ID,
+		Text:      message.Message,
+		Title:     title(message),
+		Timestamp: time.Unix(int64(message.Date), 0),
+		GroupedID: message.GroupedID,
+	}
+	post.Views, _ = message.GetViews()
+	post.Forwards, _ = message.GetForwards()
+	if replies, ok := message.GetReplies(); ok {
+		post.Replies = replies.Replies
+	}
+	if reactions, ok := message.GetReactions(); ok {
+		for _, result := range reactions.Results {
+			post.Reactions += result.Count
+		}
+	}
+	if reply, ok := message.ReplyTo.(*tg.MessageReplyHeader); ok {
+		post.ReplyTo = reply.ReplyToMsgID
+	}
+	if fwd, ok := message.GetFwdFrom(); ok {
+		post.Forward = forward(fwd, entities)
+	}
+	if media, ok := message.GetMedia(); ok {
+		post.MediaType = mediaType(media)
+		if poll, ok := media.(*tg.MessageMediaPoll); ok {
+			post.Poll = convertPoll(poll)
+		}
+	}
+	return post
+}
+
+// title возвращает заголовок поста — выделенный жирным фрагмент в первой строке
+func title(message *tg.Message) string {
+	// Смещения сущностей Telegram считаются в UTF-16
+	text := utf16.Encode([]rune(message.Message))
+	firstLine := len(text)
+	for i, c := range text {
+		if c == '\n' {
+			firstLine = i
+			break
+		}
+	}
+	for _, entity := range message.Entities {
+		bold, ok := entity.(*tg.MessageEntityBold)
+		if !ok || bold.Offset >= firstLine {
+			continue
+		}
+		end := min(bold.Offset+bold.Length, len(text))
+		if title := strings.TrimSpace(string(utf16.Decode(text[bold.Offset:end]))); title != "" {
+			return title
+		}
+	}
+	return ""
+}
+
+// forward определяет источник пересланного поста
+func forward(fwd tg.MessageFwdHeader, entities tg.Entities) *tg_post_model.Forward {
+	result := &tg_post_model.Forward{
+		From:   fwd.FromName,
+		PostID: fwd.ChannelPost,
+		Date:   time.Unix(int64(fwd.Date), 0),
+	}
+	switch peer := fwd.FromID.(type) {
+	case *tg.PeerChannel:
+		result.ChannelID = peer.ChannelID
+		if channel, ok := entities.Channels[peer.ChannelID]; ok {
+			result.From = channel.Title
+		}
+	case *tg.PeerUser:
+		if user, ok := entities.Users[peer.UserID]; ok {
+			result.From = strings.TrimSpace(user.FirstName + " " + user.LastName)
+		}
+	case *tg.PeerChat:
+		if chat, ok := entities.Chats[peer.ChatID]; ok {
+			result.From = chat.Title
+		}
+	}
+	return result
+}
+
+// convertPoll преобразует опрос Telegram
+func convertPoll(media *tg.MessageMediaPoll) *tg_post_model.Poll {
+	poll := &tg_post_model.Poll{
+		Question: media.Poll.Question.Text,
+		Quiz:     media.Poll.Quiz,
+		Closed:   media.Poll.Closed,
+		Voters:   media.Results.TotalVoters,
+	}
+	for _, answer := range media.Poll.Answers {
+		poll.Options = append(poll.Options, answer.Text.Text)
+	}
+	return poll
+}
+
+// mediaType определяет тип вложения сообщения
+func mediaType(media tg.MessageMediaClass) string {
+	switch media := media.(type) {
+	case *tg.MessageMediaPhoto:
+		return tg_post_model.MediaPhoto
+	case *tg.MessageMediaDocument:
+		return documentType(media)
+	case *tg.MessageMediaPoll:
+		return tg_post_model.MediaPoll
+	case *tg.MessageMediaWebPage:
+		return tg_post_model.MediaWebPage
+	case *tg.MessageMediaGeo, *tg.MessageMediaGeoLive, *tg.MessageMediaVenue:
+		return tg_post_model.MediaGeo
+	case *tg.MessageMediaContact:
+		return tg_post_model.MediaContact
+	case *tg.MessageMediaEmpty:
+		return ""
+	}
+	return tg_post_model.MediaOther
+}
+
+// documentType различает видео, голосовые, стикеры и прочие файлы по атрибутам документа
+func documentType(media *tg.MessageMediaDocument) string {
+	switch {
+	case media.Round:
+		return tg_post_model.MediaVideoNote
+	case media.Voice:
+		return tg_post_model.MediaVoice
+	case media.Video:
+		return tg_post_model.MediaVideo
+	}
+
+	document, ok := media.Document.(*tg.Document)
+	if !ok {
+		return tg_post_model.MediaDocument
+	}
+	result := tg_post_model.MediaDocument
+	for _, attr := range document.Attributes {
+		switch attr := attr.(type) {
+		case *tg.DocumentAttributeSticker:
+			return tg_post_model.MediaSticker
+		case *tg.DocumentAttributeAnimated:
+			return tg_post_model.MediaGIF
+		case *tg.DocumentAttributeAudio:
+			if attr.Voice {
+				return tg_post_model.MediaVoice
+			}
+			result = tg_post_model.MediaAudio
+		case *tg.DocumentAttributeVideo:
+			if attr.RoundMessage {
+				return tg_post_model.MediaVideoNote
+			}
+			result = tg_post_model.MediaVideo
+		}
+	}
+	return result
+}
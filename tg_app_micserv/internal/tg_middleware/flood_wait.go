@@ -0,0 +1,170 @@
+// Обработка FLOOD_WAIT и повтор запросов к Telegram API
+
+package tg_middleware
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+)
+
+// Метрики публикуются через expvar на /debug/vars
+var (
+	floodWaits       = expvar.NewMap("tg_flood_waits")          // Количество FLOOD_WAIT по методам
+	floodWaitSeconds = expvar.NewMap("tg_flood_wait_seconds")   // Суммарное время ожидания по методам
+	floodRejected    = expvar.NewMap("tg_flood_wait_rejected")  // Запросы, отклонённые без ожидания
+	internalRetries  = expvar.NewMap("tg_internal_error_retry") // Повторы после внутренних ошибок Telegram
+)
+
+// internalErrorAttempts количество попыток при внутренних ошибках сервера Telegram
+const internalErrorAttempts = 3
+
+// RetryAfterError возвращается, если Telegram потребовал подождать дольше допустимого:
+// запрос можно повторить не раньше чем через RetryAfter
+type RetryAfterError struct {
+	Method     string        // Метод API
+	RetryAfter time.Duration // Через сколько можно повторить запрос
+	Err        error         // Исходная ошибка Telegram (nil, если запрос отклонён без обращения к API)
+}
+
+// Error возвращает текст ошибки
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("Telegram ограничил запросы %s, повторите через %s", e.Method, e.RetryAfter.Round(time.Second))
+}
+
+// Seconds возвращает время до повтора в целых секундах, не меньше одной — для заголовка Retry-After
+func (e *RetryAfterError) Seconds() int {
+	return max(int(math.Ceil(e.RetryAfter.Seconds())), 1)
+}
+
+// Unwrap возвращает исходную ошибку Telegram
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// FloodWait ждёт и повторяет запрос после FLOOD_WAIT, пока суммарное ожидание укладывается в бюджет.
+// Если ожидание больше бюджета или не успевает до дедлайна контекста, возвращается RetryAfterError.
+// Пока действует ограничение по методу, новые запросы к нему сразу получают RetryAfterError,
+// чтобы не продлевать блокировку.
+type FloodWait struct {
+	budget time.Duration // Максимальное суммарное ожидание в рамках одного запроса
+
+	mutex        sync.Mutex
+	blockedUntil map[string]time.Time // Время окончания ограничения по методам
+}
+
+// NewFloodWait создает middleware обработки FLOOD_WAIT
+func NewFloodWait(budget time.Duration) *FloodWait {
+	return &FloodWait{
+		budget:       budget,
+		blockedUntil: make(map[string]time.Time),
+	}
+}
+
+// Handle реализует telegram.Middleware
+func (f *FloodWait) Handle(next tg.Invoker) telegram.InvokeFunc {
+	return func(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+		method := methodName(input)
+		var waited time.Duration
+		internalAttempt := 1
+
+		for {
+			if wait := f.remaining(method); wait > 0 {
+				if err := f.wait(ctx, method, wait, waited, nil); err != nil {
+					return err
+				}
+				waited += wait
+			}
+
+			err := next.Invoke(ctx, input, output)
+			if err == nil {
+				return nil
+			}
+
+			// Внутренние ошибки Telegram обычно временные — повторяем с небольшой паузой
+			if tgerr.IsCode(err, 500) && internalAttempt < internalErrorAttempts {
+				internalRetries.Add(method, 1)
+				if err := sleep(ctx, time.Duration(internalAttempt)*time.Second); err != nil {
+					return err
+				}
+				internalAttempt++
+				continue
+			}
+
+			d, ok := tgerr.AsFloodWait(err)
+			if !ok {
+				return err
+			}
+			floodWaits.Add(method, 1)
+			f.block(method, d)
+			if err := f.wait(ctx, method, d, waited, err); err != nil {
+				return err
+			}
+			waited += d
+		}
+	}
+}
+
+// wait ждёт окончания ограничения, если ожидание укладывается в бюджет и дедлайн контекста
+func (f *FloodWait) wait(ctx context.Context, method string, d, waited time.Duration, cause error) error {
+	deadline, hasDeadline := ctx.Deadline()
+	if waited+d > f.budget || (hasDeadline && time.Until(deadline) < d) {
+		floodRejected.Add(method, 1)
+		return &RetryAfterError{Method: method, RetryAfter: d, Err: cause}
+	}
+	floodWaitSeconds.AddFloat(method, d.Seconds())
+	return sleep(ctx, d)
+}
+
+// block запоминает, до какого времени метод ограничен
+func (f *FloodWait) block(method string, d time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	until := time.Now().Add(d)
+	if until.After(f.blockedUntil[method]) {
+		f.blockedUntil[method] = until
+	}
+}
+
+// remaining возвращает оставшееся время ограничения метода
+func (f *FloodWait) remaining(method string) time.Duration {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	until, ok := f.blockedUntil[method]
+	if !ok {
+		return 0
+	}
+	d := time.Until(until)
+	if d <= 0 {
+		delete(f.blockedUntil, method)
+		return 0
+	}
+	return d
+}
+
+// methodName возвращает имя метода API по типу запроса, например MessagesGetHistoryRequest
+func methodName(input bin.Encoder) string {
+	name := fmt.Sprintf("%T", input)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// sleep ждёт указанное время или отмены контекста
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
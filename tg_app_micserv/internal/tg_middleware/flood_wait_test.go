@@ -0,0 +1,60 @@
+package tg_middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+)
+
+// invokerFunc позволяет подменить Telegram API функцией
+type invokerFunc func(ctx context.Context, input bin.Encoder, output bin.Decoder) error
+
+func (f invokerFunc) Invoke(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+	return f(ctx, input, output)
+}
+
+func TestFloodWaitFailsFastOverBudget(t *testing.T) {
+	calls := 0
+	invoker := NewFloodWait(10 * time.Second).Handle(invokerFunc(func(context.Context, bin.Encoder, bin.Decoder) error {
+		calls++
+		return tgerr.New(420, "FLOOD_WAIT_30")
+	}))
+
+	err := invoker(context.Background(), &tg.MessagesGetHistoryRequest{}, nil)
+	var retryErr *RetryAfterError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("ожидали RetryAfterError, получили %v", err)
+	}
+	if retryErr.Method != "MessagesGetHistoryRequest" || retryErr.Seconds() != 30 {
+		t.Fatalf("неожиданная ошибка: %+v", retryErr)
+	}
+
+	// Пока действует ограничение, запрос отклоняется без обращения к API
+	if err := invoker(context.Background(), &tg.MessagesGetHistoryRequest{}, nil); !errors.As(err, &retryErr) || calls != 1 {
+		t.Fatalf("ожидали отказ без запроса к API, получили %v после %d вызовов", err, calls)
+	}
+	// Ограничение действует только на свой метод
+	if err := invoker(context.Background(), &tg.ContactsResolveUsernameRequest{}, nil); !errors.As(err, &retryErr) || calls != 2 {
+		t.Fatalf("другой метод должен обращаться к API: %v, вызовов %d", err, calls)
+	}
+}
+
+func TestFloodWaitRetriesWithinBudget(t *testing.T) {
+	calls := 0
+	invoker := NewFloodWait(10 * time.Second).Handle(invokerFunc(func(context.Context, bin.Encoder, bin.Decoder) error {
+		calls++
+		if calls == 1 {
+			return tgerr.New(420, "FLOOD_WAIT_1")
+		}
+		return nil
+	}))
+
+	if err := invoker(context.Background(), &tg.MessagesGetHistoryRequest{}, nil); err != nil || calls != 2 {
+		t.Fatalf("ожидали успешный повтор, получили %v после %d вызовов", err, calls)
+	}
+}
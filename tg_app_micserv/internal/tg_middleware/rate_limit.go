@@ -0,0 +1,48 @@
+// Глобальное ограничение частоты запросов к Telegram API
+
+package tg_middleware
+
+import (
+	"context"
+	"expvar"
+	"time"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/tg"
+	"golang.org/x/time/rate"
+)
+
+// Метрики ограничителя частоты
+var (
+	rateLimitWaits       = expvar.NewInt("tg_rate_limit_waits")          // Запросы, которым пришлось ждать токен
+	rateLimitWaitSeconds = expvar.NewFloat("tg_rate_limit_wait_seconds") // Суммарное время ожидания токенов
+)
+
+// RateLimit ограничивает частоту исходящих запросов алгоритмом token bucket.
+// Один экземпляр разделяется всеми клиентами Telegram сервиса.
+type RateLimit struct {
+	limiter *rate.Limiter
+}
+
+// NewRateLimit создает ограничитель: rps запросов в секунду с запасом burst
+func NewRateLimit(rps float64, burst int) *RateLimit {
+	return &RateLimit{
+		limiter: rate.NewLimiter(rate.Limit(rps), burst),
+	}
+}
+
+// Handle реализует telegram.Middleware
+func (r *RateLimit) Handle(next tg.Invoker) telegram.InvokeFunc {
+	return func(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+		start := time.Now()
+		if err := r.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		if waited := time.Since(start); waited > time.Millisecond {
+			rateLimitWaits.Add(1)
+			rateLimitWaitSeconds.Add(waited.Seconds())
+		}
+		return next.Invoke(ctx, input, output)
+	}
+}
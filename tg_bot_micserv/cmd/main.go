@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/joho/godotenv"
 	"log/slog"
 	"net/http"
 	"os"
+	"tg_bot/internal/kafka/consumer"
 	"tg_bot/internal/kafka/producer"
 	"tg_bot/internal/server"
 
@@ -76,6 +78,21 @@ func main() {
 		}
 	}()
 
+	// Доставляем пользователям озвученные дайджесты из ответов Text-to-Speech
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	audioConsumer := consumer.NewConsumer(cfg.KafkaPort, cfg.NameTopicAudio, cfg.KafkaGroupID)
+	defer audioConsumer.Close()
+	go func() {
+		err := audioConsumer.Consume(ctx, func(ctx context.Context, data []byte) error {
+			return userCase.DeliverAudio(ctx, tgBot, data)
+		})
+		if err != nil {
+			slog.Error("Kafka-консьюмер ответов Text-to-Speech остановлен", "error", err)
+		}
+	}()
+	slog.Info(fmt.Sprintf("Успешно создали Kafka-консьюмер, Name Topic: %v", cfg.NameTopicAudio))
+
 	// Настраиваем HTTP-мультиплексор для обработки запросов
 	mux := http.NewServeMux() // эта строка Создаёт новый HTTP-мультиплексор (роутер) для обработки HTTP-запросов и сохраняет его в переменную mux.
 	// ServeMux — это структура, которая используется для маршрутизации HTTP-запросов.
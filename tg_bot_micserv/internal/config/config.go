@@ -13,6 +13,8 @@ type Config struct {
 	ServerPort     string // адрес HTTP-сервера
 	KafkaPort      string // адрес брокера Kafka
 	NameTopicKafka string // имя топика Kafka для отправки сообщений
+	NameTopicAudio string // имя топика Kafka с ответами сервиса Text-to-Speech
+	KafkaGroupID   string // идентификатор группы консьюмеров Kafka
 }
 
 // Load загружает конфигурацию из переменных окружения
@@ -45,10 +47,24 @@ func Load() (*Config, error) {
 	}
 	myLogger.Info(fmt.Sprintf("Успешно записали nameTopicKafka = %v", nameTopicKafka))
 
+	// Получаем топик ответов Text-to-Speech
+	nameTopicAudio := os.Getenv("NAME_KAFKA_AUDIO_TOPIC")
+	if nameTopicAudio == "" {
+		nameTopicAudio = "text-to-speech-responses"
+	}
+
+	// Получаем идентификатор группы консьюмеров
+	kafkaGroupID := os.Getenv("KAFKA_GROUP_ID")
+	if kafkaGroupID == "" {
+		kafkaGroupID = "tg_bot"
+	}
+
 	return &Config{
 		TGBotToken:     token,
 		ServerPort:     serverPort,
 		KafkaPort:      kafkaPort,
 		NameTopicKafka: nameTopicKafka,
+		NameTopicAudio: nameTopicAudio,
+		KafkaGroupID:   kafkaGroupID,
 	}, nil
 }
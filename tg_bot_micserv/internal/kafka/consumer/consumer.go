@@ -0,0 +1,60 @@
+// Файл consumer.go реализует Kafka-консьюмер ответов сервиса Text-to-Speech.
+// Читает ответы из топика и передаёт их обработчику, который доставляет аудио пользователю.
+
+package consumer
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/segmentio/kafka-go"
+	"tg_bot/tools/logger"
+)
+
+// Consumer содержит Kafka-консьюмер
+type Consumer struct {
+	reader *kafka.Reader // объект для чтения сообщений из Kafka
+}
+
+// NewConsumer создаёт новый Kafka-консьюмер
+func NewConsumer(portKafka, nameTopic, groupID string) *Consumer {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: []string{portKafka}, // Указываем адрес брокера Kafka
+		Topic:   nameTopic,           // Указываем топик
+		GroupID: groupID,             // Указываем идентификатор группы
+	})
+	return &Consumer{reader: reader}
+}
+
+// Consume читает сообщения из Kafka и передаёт их в handle до отмены ctx.
+// Ошибка обработки одного сообщения не останавливает чтение следующих.
+func (c *Consumer) Consume(ctx context.Context, handle func(ctx context.Context, data []byte) error) error {
+	const lblConsume = "tg_bot_micserv/internal/kafka/consumer/consumer.go/Consume()"
+	myLogger := logger.NewColorLogger(lblConsume)
+
+	for {
+		msg, err := c.reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			myLogger.Error("Ошибка чтения сообщения из Kafka", slog.Any("error", err))
+			return err
+		}
+
+		if err := handle(ctx, msg.Value); err != nil {
+			myLogger.Error("Ошибка обработки сообщения из Kafka", slog.Any("error", err))
+		}
+	}
+}
+
+// Close закрывает соединение с Kafka
+func (c *Consumer) Close() error {
+	err := c.reader.Close()
+	if err != nil {
+		slog.Error("Ошибка закрытия Kafka-консьюмера", "error", err)
+		return err
+	}
+	slog.Info("Kafka-консьюмер успешно закрыт")
+	return nil
+}
@@ -1,13 +1,29 @@
 // Файл bot_request.go определяет доменную модель TgBotRequest, которая представляет запрос пользователя в Telegram-боте.
-// Модель содержит данные о выбранном канале, скорости речи, периоде времени и состоянии ввода.
+// Модель содержит данные о выбранном канале, скорости речи, голосе, периоде времени, отборе постов, режиме и длительности дайджеста, фильтрах, поиске по каналу и состоянии ввода.
+// Запрос уходит в Kafka сервису парсинга Telegram в JSON; состояние ввода в сообщение не попадает.
 
 package bot_request
 
 // Структура TgBotRequest представляет запрос пользователя к боту
 type TgBotRequest struct {
-	ChatID               int64   // идентификатор чата Telegram
-	NameChanel           string  // имя или ссылка на Telegram-канал
-	SpeakingRate         float64 // скорость речи
-	TimePeriod           int     // период времени в часах
-	AwaitingChannelInput bool    // флаг, указывающий, ожидается ли ввод имени канала
+	RequestID            string   `json:"request_id"`                 // идентификатор запроса, возвращается в ответе сервиса Text-to-Speech
+	ChatID               int64    `json:"chat_id"`                    // идентификатор чата Telegram
+	NameChanel           string   `json:"channel"`                    // имя или ссылка на Telegram-канал; несколько каналов — через запятую
+	SpeakingRate         float64  `json:"speaking_rate"`              // скорость речи
+	VoiceName            string   `json:"voice_name,omitempty"`       // имя голоса синтеза речи (пусто — голос по умолчанию)
+	TimePeriod           int      `json:"hours"`                      // период времени в часах
+	Top                  int      `json:"top,omitempty"`              // оставить N самых популярных постов (0 — все посты)
+	Percentile           float64  `json:"percentile,omitempty"`       // оставить посты популярнее этого процентиля (0 — все посты)
+	DigestMode           string   `json:"mode,omitempty"`             // режим дайджеста: full, headlines или summary (пусто — полный текст)
+	Minutes              int      `json:"minutes,omitempty"`          // длительность дайджеста в минутах, под которую подгоняются посты (0 — без ограничения)
+	Include              []string `json:"include,omitempty"`          // оставить посты, где есть хотя бы одно слово, #хештег или /выражение/
+	Exclude              []string `json:"exclude,omitempty"`          // отбросить посты, где есть хотя бы одно слово, #хештег или /выражение/
+	SkipAds              bool     `json:"skip_ads,omitempty"`         // отбросить рекламные посты
+	Dedup                bool     `json:"dedup,omitempty"`            // убрать повторы одной новости из разных каналов, упомянув другие каналы
+	SearchQuery          string   `json:"search_query,omitempty"`     // искать в канале посты по запросу вместо чтения всех постов за период (пусто — без поиска)
+	SearchFromUser       string   `json:"search_from_user,omitempty"` // искать только посты этого автора (@имя), пусто — любого
+	SearchDays           int      `json:"search_days,omitempty"`      // за сколько последних дней искать посты (0 — за неделю)
+	AwaitingChannelInput bool     `json:"-"`                          // флаг, указывающий, ожидается ли ввод имени канала
+	AwaitingFilterInput  string   `json:"-"`                          // какой список фильтров ожидается от пользователя: include или exclude (пусто — никакой)
+	AwaitingSearchInput  bool     `json:"-"`                          // флаг, указывающий, ожидается ли ввод поискового запроса
 }
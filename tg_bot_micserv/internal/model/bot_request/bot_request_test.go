@@ -0,0 +1,29 @@
+package bot_request
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestWireFormat проверяет сообщение, которое бот отправляет в Kafka сервису парсинга Telegram:
+// чат и идентификатор запроса должны дойти до сервиса Text-to-Speech и вернуться в его ответе
+func TestWireFormat(t *testing.T) {
+	request := TgBotRequest{
+		RequestID:           "req-1",
+		ChatID:              42,
+		NameChanel:          "@news",
+		SpeakingRate:        1.5,
+		VoiceName:           "ru-RU-Wavenet-A",
+		TimePeriod:          24,
+		Minutes:             10,
+		AwaitingSearchInput: true,
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"request_id":"req-1","chat_id":42,"channel":"@news","speaking_rate":1.5,"voice_name":"ru-RU-Wavenet-A","hours":24,"minutes":10}`
+	if string(data) != want {
+		t.Fatalf("неожиданное сообщение:\n%s\nожидали:\n%s", data, want)
+	}
+}
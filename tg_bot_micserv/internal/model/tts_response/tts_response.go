@@ -0,0 +1,95 @@
+// Файл tts_response.go определяет ответ сервиса Text-to-Speech из Kafka и скачивание аудио по ссылке из него.
+// Аудио через Kafka не передаётся: сервис публикует ссылку на файл в хранилище с размером и контрольной суммой.
+
+package tts_response
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ErrNotFound возвращается, если аудиофайл по ссылке уже удалён из хранилища
+var ErrNotFound = errors.New("аудиофайл не найден в хранилище")
+
+// ErrExpired возвращается, если срок действия ссылки на аудиофайл истёк
+var ErrExpired = errors.New("срок действия ссылки на аудиофайл истёк")
+
+// Response ответ сервиса Text-to-Speech на запрос озвучивания
+type Response struct {
+	RequestID string    `json:"request_id,omitempty"` // идентификатор запроса бота, на который дан ответ
+	ChatID    int64     `json:"chat_id,omitempty"`    // чат пользователя, запросившего дайджест
+	AudioRef  *AudioRef `json:"audio_ref,omitempty"`  // ссылка на аудиофайл в хранилище
+	Chapters  []Chapter `json:"chapters,omitempty"`   // главы аудиофайла: вступление и посты
+	Error     string    `json:"error,omitempty"`      // ошибка синтеза (пусто — успешно)
+}
+
+// Chapter глава аудиофайла с её положением в файле
+type Chapter struct {
+	ID      int64  `json:"id"`       // идентификатор поста (0 — вступление)
+	Title   string `json:"title"`    // название главы
+	StartMs int64  `json:"start_ms"` // начало главы в миллисекундах от начала файла
+	EndMs   int64  `json:"end_ms"`   // конец главы в миллисекундах от начала файла
+}
+
+// Duration возвращает длительность синтезированного аудио по концу последней главы (0 — глав нет)
+func (r Response) Duration() time.Duration {
+	var endMs int64
+	for _, chapter := range r.Chapters {
+		endMs = max(endMs, chapter.EndMs)
+	}
+	return time.Duration(endMs) * time.Millisecond
+}
+
+// AudioRef ссылка на аудиофайл в хранилище
+type AudioRef struct {
+	Key         string    `json:"key"`          // ключ объекта в хранилище
+	URL         string    `json:"url"`          // адрес для скачивания объекта
+	Size        int64     `json:"size"`         // размер объекта в байтах
+	SHA256      string    `json:"sha256"`       // контрольная сумма SHA-256 в шестнадцатеричном виде
+	ContentType string    `json:"content_type"` // MIME-тип объекта
+	ExpiresAt   time.Time `json:"expires_at"`   // момент, после которого ссылка недействительна
+}
+
+// Fetch скачивает аудиофайл по URL из ссылки и сверяет его размер и контрольную сумму
+func Fetch(ctx context.Context, client *http.Client, ref *AudioRef) ([]byte, error) {
+	if !ref.ExpiresAt.IsZero() && time.Now().After(ref.ExpiresAt) {
+		return nil, fmt.Errorf("%w: %s", ErrExpired, ref.Key)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ref.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать запрос за аудиофайлом %s: %w", ref.Key, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось скачать аудиофайл %s: %w", ref.Key, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, ref.Key)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("не удалось скачать аудиофайл %s: статус %s", ref.Key, resp.Status)
+	}
+
+	// Читаем не больше заявленного размера плюс один байт, чтобы заметить лишние данные
+	data, err := io.ReadAll(io.LimitReader(resp.Body, ref.Size+1))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать аудиофайл %s: %w", ref.Key, err)
+	}
+	if int64(len(data)) != ref.Size {
+		return nil, fmt.Errorf("размер аудиофайла %s не совпадает: ожидали %d, получили %d", ref.Key, ref.Size, len(data))
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != ref.SHA256 {
+		return nil, fmt.Errorf("контрольная сумма аудиофайла %s не совпадает", ref.Key)
+	}
+	return data, nil
+}
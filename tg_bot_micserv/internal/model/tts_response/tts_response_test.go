@@ -0,0 +1,71 @@
+package tts_response
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFetch(t *testing.T) {
+	content := "ID3 audio"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/audio/digests/a.mp3" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	defer srv.Close()
+
+	sum := sha256.Sum256([]byte(content))
+	ref := &AudioRef{
+		Key:       "digests/a.mp3",
+		URL:       srv.URL + "/audio/digests/a.mp3",
+		Size:      int64(len(content)),
+		SHA256:    hex.EncodeToString(sum[:]),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	ctx := context.Background()
+	data, err := Fetch(ctx, srv.Client(), ref)
+	if err != nil || string(data) != content {
+		t.Fatalf("Fetch: %q, %v", data, err)
+	}
+
+	// Подменённое содержимое не должно пройти проверку контрольной суммы
+	content = "ID3 AUDIO"
+	if _, err := Fetch(ctx, srv.Client(), ref); err == nil {
+		t.Fatalf("ожидали ошибку контрольной суммы")
+	}
+
+	missing := *ref
+	missing.URL = srv.URL + "/audio/digests/b.mp3"
+	if _, err := Fetch(ctx, srv.Client(), &missing); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("ожидали ErrNotFound, получили %v", err)
+	}
+
+	ref.ExpiresAt = time.Now().Add(-time.Minute)
+	if _, err := Fetch(ctx, srv.Client(), ref); !errors.Is(err, ErrExpired) {
+		t.Fatalf("ожидали ErrExpired, получили %v", err)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	// Длительность дайджеста приходит вместе с аудио в главах ответа сервиса Text-to-Speech
+	data := []byte(`{"request_id":"req-1","chat_id":42,"chapters":[{"id":0,"title":"Вступление","start_ms":0,"end_ms":4000},{"id":5,"title":"12:30 — текст","start_ms":4000,"end_ms":185500}]}`)
+	var resp Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatalf("не удалось разобрать ответ: %v", err)
+	}
+	if resp.RequestID != "req-1" || resp.ChatID != 42 || resp.Duration() != 185500*time.Millisecond {
+		t.Fatalf("неожиданный ответ: %+v, длительность %v", resp, resp.Duration())
+	}
+	if (Response{}).Duration() != 0 {
+		t.Fatalf("без глав длительность должна быть нулевой")
+	}
+}
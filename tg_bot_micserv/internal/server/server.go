@@ -41,7 +41,7 @@ func NewServer(cfg *config.Config, handler http.Handler) *Server {
 func (s *Server) Start() error {
 	s.myLogger.Info(fmt.Sprintf("Запуск HTTP-сервера на порту: %v", s.srv.Addr))
 	if err := s.srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		s.myLogger.Error(fmt.Sprintf("Ошибка работы сервера: %v", "error", err))
+		s.myLogger.Error(fmt.Sprintf("Ошибка работы сервера: %v", err))
 		return err
 	}
 	return nil
@@ -6,12 +6,21 @@
 package tg_bot_user_case
 
 import (
+	"cmp"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"math"
+	"net/http"
+	"path"
 	"strconv"
+	"strings"
 	"tg_bot/internal/kafka/producer"
+	"tg_bot/internal/model/bot_request"
+	"tg_bot/internal/model/tts_response"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -27,10 +36,173 @@ var MainKeyboard = tgbotapi.NewReplyKeyboard(
 	),
 	tgbotapi.NewKeyboardButtonRow(
 		tgbotapi.NewKeyboardButton("Период времени"),
+		tgbotapi.NewKeyboardButton("Голос"),
+	),
+	tgbotapi.NewKeyboardButtonRow(
+		tgbotapi.NewKeyboardButton("Отбор постов"),
+		tgbotapi.NewKeyboardButton("Режим дайджеста"),
+	),
+	tgbotapi.NewKeyboardButtonRow(
+		tgbotapi.NewKeyboardButton("Длительность"),
+		tgbotapi.NewKeyboardButton("Фильтры"),
+	),
+	tgbotapi.NewKeyboardButtonRow(
+		tgbotapi.NewKeyboardButton("Поиск по каналу"),
 		tgbotapi.NewKeyboardButton("Отправить"),
 	),
 )
 
+// voiceOptions сопоставляет подписи кнопок выбора голоса с именами голосов Google Text-to-Speech
+var voiceOptions = map[string]string{
+	"Женский A":         "ru-RU-Standard-A",
+	"Мужской B":         "ru-RU-Standard-B",
+	"Женский C":         "ru-RU-Standard-C",
+	"Мужской D":         "ru-RU-Standard-D",
+	"Женский Wavenet A": "ru-RU-Wavenet-A",
+	"Мужской Wavenet B": "ru-RU-Wavenet-B",
+}
+
+// voiceLabel возвращает подпись голоса для сообщений пользователю
+func voiceLabel(voiceName string) string {
+	for label, name := range voiceOptions {
+		if name == voiceName {
+			return label
+		}
+	}
+	return "по умолчанию"
+}
+
+// selection параметры отбора постов по вовлечённости
+type selection struct {
+	top        int     // количество самых популярных постов
+	percentile float64 // процентиль популярности
+}
+
+// selectionOptions сопоставляет подписи кнопок отбора постов с параметрами отбора
+var selectionOptions = map[string]selection{
+	"Все посты":  {},
+	"Топ 5":      {top: 5},
+	"Топ 10":     {top: 10},
+	"Лучшие 25%": {percentile: 75},
+	"Лучшие 50%": {percentile: 50},
+}
+
+// selectionLabel возвращает подпись отбора постов для сообщений пользователю
+func selectionLabel(top int, percentile float64) string {
+	for label, s := range selectionOptions {
+		if s.top == top && s.percentile == percentile {
+			return label
+		}
+	}
+	return "Все посты"
+}
+
+// digestOptions сопоставляет подписи кнопок режима дайджеста с режимами сервиса парсинга
+var digestOptions = map[string]string{
+	"Полный текст":       "full",
+	"Только заголовки":   "headlines",
+	"Краткое содержание": "summary",
+}
+
+// digestLabel возвращает подпись режима дайджеста для сообщений пользователю
+func digestLabel(mode string) string {
+	for label, m := range digestOptions {
+		if m == mode {
+			return label
+		}
+	}
+	return "Полный текст"
+}
+
+// durationOptions сопоставляет подписи кнопок длительности дайджеста с длительностью в минутах
+var durationOptions = map[string]int{
+	"Без ограничения": 0,
+	"5 минут":         5,
+	"10 минут":        10,
+	"15 минут":        15,
+	"30 минут":        30,
+}
+
+// durationLabel возвращает подпись длительности дайджеста для сообщений пользователю
+func durationLabel(minutes int) string {
+	if minutes == 0 {
+		return "без ограничения"
+	}
+	return fmt.Sprintf("до %d мин.", minutes)
+}
+
+// filtersLabel возвращает описание фильтров постов для сообщений пользователю
+func filtersLabel(request *bot_request.TgBotRequest) string {
+	var parts []string
+	if request.SkipAds {
+		parts = append(parts, "без рекламы")
+	}
+	if request.Dedup {
+		parts = append(parts, "без повторов")
+	}
+	if len(request.Include) > 0 {
+		parts = append(parts, "только с "+strings.Join(request.Include, ", "))
+	}
+	if len(request.Exclude) > 0 {
+		parts = append(parts, "кроме "+strings.Join(request.Exclude, ", "))
+	}
+	if len(parts) == 0 {
+		return "нет"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// parseFilters разбирает введённый пользователем список фильтров через запятую
+func parseFilters(text string) []string {
+	var filters []string
+	for _, filter := range strings.Split(text, ",") {
+		if filter = strings.TrimSpace(filter); filter != "" {
+			filters = append(filters, filter)
+		}
+	}
+	return filters
+}
+
+// defaultSearchDays за сколько дней ищутся посты, если период поиска не выбран
+const defaultSearchDays = 7
+
+// newRequestID возвращает случайный идентификатор запроса дайджеста
+func newRequestID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// searchPeriodOptions сопоставляет подписи кнопок периода поиска с количеством дней
+var searchPeriodOptions = map[string]int{
+	"За сутки":  1,
+	"За неделю": 7,
+	"За месяц":  30,
+}
+
+// searchLabel возвращает описание поиска по каналу для сообщений пользователю
+func searchLabel(request *bot_request.TgBotRequest) string {
+	if request.SearchQuery == "" {
+		return "нет"
+	}
+	label := fmt.Sprintf("«%s» за %d дн.", request.SearchQuery, cmp.Or(request.SearchDays, defaultSearchDays))
+	if request.SearchFromUser != "" {
+		label += ", автор " + request.SearchFromUser
+	}
+	return label
+}
+
+// parseSearch разбирает введённый пользователем запрос: последнее слово вида @имя задаёт автора постов
+func parseSearch(text string) (string, string) {
+	words := strings.Fields(text)
+	if len(words) > 1 && strings.HasPrefix(words[len(words)-1], "@") {
+		return strings.Join(words[:len(words)-1], " "), words[len(words)-1]
+	}
+	return strings.Join(words, " "), ""
+}
+
 // init Инициализируем настройки клавиатуры
 func init() {
 	MainKeyboard.ResizeKeyboard = true // Устанавливаем авторазмер клавиатуры
@@ -41,6 +213,7 @@ func init() {
 type UseCase struct {
 	repo          *repo_user_requests.RepoUserRequests // repo — интерфейс репозитория для работы с данными
 	kafkaProducer *producer.Producer                   // Указатель на Kafka-продюсер для отправки сообщений
+	httpClient    *http.Client                         // Клиент для скачивания аудио по ссылкам из ответов Text-to-Speech
 }
 
 // NewUseCase создаёт новый экземпляр UseCase
@@ -48,6 +221,7 @@ func NewUseCase(repo *repo_user_requests.RepoUserRequests, kafkaProducer *produc
 	return &UseCase{
 		repo:          repo,
 		kafkaProducer: kafkaProducer,
+		httpClient:    &http.Client{Timeout: 2 * time.Minute},
 	}
 }
 
@@ -77,7 +251,9 @@ func (uc *UseCase) HandleMessage(ctx context.Context, bot *tgbotapi.BotAPI, chat
 	switch text {
 	case "Выбрать канал":
 
-		request.AwaitingChannelInput = true    // Устанавливаем флаг ожидания ввода канала
+		request.AwaitingChannelInput = true // Устанавливаем флаг ожидания ввода канала
+		request.AwaitingFilterInput = ""
+		request.AwaitingSearchInput = false
 		keyboard := tgbotapi.NewReplyKeyboard( // Создаём клавиатуру с кнопкой "Назад"
 			tgbotapi.NewKeyboardButtonRow(
 				tgbotapi.NewKeyboardButton("Назад"),
@@ -86,7 +262,7 @@ func (uc *UseCase) HandleMessage(ctx context.Context, bot *tgbotapi.BotAPI, chat
 
 		keyboard.ResizeKeyboard = true // Устанавливаем авторазмер клавиатуры
 		keyboard.Selective = false     // Отключаем выборочную видимость
-		msg := tgbotapi.NewMessage(chatID, "Введите имя ТГ канала, или ссылку на канал")
+		msg := tgbotapi.NewMessage(chatID, "Введите имя ТГ канала, или ссылку на канал. Для сводки нескольких каналов перечислите их через запятую")
 		msg.ReplyMarkup = keyboard               // Устанавливаем клавиатуру
 		if _, err := bot.Send(msg); err != nil { // Отправляем сообщение
 			return err
@@ -169,7 +345,292 @@ func (uc *UseCase) HandleMessage(ctx context.Context, bot *tgbotapi.BotAPI, chat
 		uc.repo.SaveRequest(chatID, request)
 		return nil
 
+	case "Голос":
+		keyboard := tgbotapi.NewReplyKeyboard(
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("Женский A"),
+				tgbotapi.NewKeyboardButton("Мужской B"),
+			),
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("Женский C"),
+				tgbotapi.NewKeyboardButton("Мужской D"),
+			),
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("Женский Wavenet A"),
+				tgbotapi.NewKeyboardButton("Мужской Wavenet B"),
+			),
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("Назад"),
+			),
+		)
+		keyboard.ResizeKeyboard = true
+		keyboard.Selective = false
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Выберите голос (текущий: %s):", voiceLabel(request.VoiceName)))
+		msg.ReplyMarkup = keyboard
+		if _, err := bot.Send(msg); err != nil {
+			return err
+		}
+		uc.repo.SaveRequest(chatID, request)
+		return nil
+
+	case "Женский A", "Мужской B", "Женский C", "Мужской D", "Женский Wavenet A", "Мужской Wavenet B":
+		request.VoiceName = voiceOptions[text]
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Голос сохранён: %s. Выберите действие:", text))
+		msg.ReplyMarkup = MainKeyboard
+		if _, err := bot.Send(msg); err != nil {
+			return err
+		}
+		uc.repo.SaveRequest(chatID, request)
+		return nil
+
+	case "Отбор постов":
+		keyboard := tgbotapi.NewReplyKeyboard(
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("Все посты"),
+			),
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("Топ 5"),
+				tgbotapi.NewKeyboardButton("Топ 10"),
+			),
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("Лучшие 25%"),
+				tgbotapi.NewKeyboardButton("Лучшие 50%"),
+			),
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("Назад"),
+			),
+		)
+		keyboard.ResizeKeyboard = true
+		keyboard.Selective = false
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Какие посты озвучивать? Популярность считается по просмотрам, пересылкам, реакциям и комментариям (текущий выбор: %s):", selectionLabel(request.Top, request.Percentile)))
+		msg.ReplyMarkup = keyboard
+		if _, err := bot.Send(msg); err != nil {
+			return err
+		}
+		uc.repo.SaveRequest(chatID, request)
+		return nil
+
+	case "Все посты", "Топ 5", "Топ 10", "Лучшие 25%", "Лучшие 50%":
+		request.Top = selectionOptions[text].top
+		request.Percentile = selectionOptions[text].percentile
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Отбор постов сохранён: %s. Выберите действие:", text))
+		msg.ReplyMarkup = MainKeyboard
+		if _, err := bot.Send(msg); err != nil {
+			return err
+		}
+		uc.repo.SaveRequest(chatID, request)
+		return nil
+
+	case "Режим дайджеста":
+		keyboard := tgbotapi.NewReplyKeyboard(
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("Полный текст"),
+			),
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("Только заголовки"),
+				tgbotapi.NewKeyboardButton("Краткое содержание"),
+			),
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("Назад"),
+			),
+		)
+		keyboard.ResizeKeyboard = true
+		keyboard.Selective = false
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Как озвучивать посты? Заголовки — только первая фраза поста, краткое содержание — самые важные предложения (текущий режим: %s):", digestLabel(request.DigestMode)))
+		msg.ReplyMarkup = keyboard
+		if _, err := bot.Send(msg); err != nil {
+			return err
+		}
+		uc.repo.SaveRequest(chatID, request)
+		return nil
+
+	case "Полный текст", "Только заголовки", "Краткое содержание":
+		request.DigestMode = digestOptions[text]
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Режим дайджеста сохранён: %s. Выберите действие:", text))
+		msg.ReplyMarkup = MainKeyboard
+		if _, err := bot.Send(msg); err != nil {
+			return err
+		}
+		uc.repo.SaveRequest(chatID, request)
+		return nil
+
+	case "Длительность":
+		keyboard := tgbotapi.NewReplyKeyboard(
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("5 минут"),
+				tgbotapi.NewKeyboardButton("10 минут"),
+			),
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("15 минут"),
+				tgbotapi.NewKeyboardButton("30 минут"),
+			),
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("Без ограничения"),
+			),
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("Назад"),
+			),
+		)
+		keyboard.ResizeKeyboard = true
+		keyboard.Selective = false
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Сколько минут слушать? Длинные посты сократятся, а наименее популярные не попадут в дайджест (текущая длительность: %s):", durationLabel(request.Minutes)))
+		msg.ReplyMarkup = keyboard
+		if _, err := bot.Send(msg); err != nil {
+			return err
+		}
+		uc.repo.SaveRequest(chatID, request)
+		return nil
+
+	case "5 минут", "10 минут", "15 минут", "30 минут", "Без ограничения":
+		request.Minutes = durationOptions[text]
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Длительность сохранена: %s. Выберите действие:", durationLabel(request.Minutes)))
+		msg.ReplyMarkup = MainKeyboard
+		if _, err := bot.Send(msg); err != nil {
+			return err
+		}
+		uc.repo.SaveRequest(chatID, request)
+		return nil
+
+	case "Фильтры":
+		adsButton := "Скрывать рекламу"
+		if request.SkipAds {
+			adsButton = "Показывать рекламу"
+		}
+		dedupButton := "Убирать повторы"
+		if request.Dedup {
+			dedupButton = "Оставлять повторы"
+		}
+		keyboard := tgbotapi.NewReplyKeyboard(
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton(adsButton),
+				tgbotapi.NewKeyboardButton(dedupButton),
+			),
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("Ключевые слова"),
+				tgbotapi.NewKeyboardButton("Исключить слова"),
+			),
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("Сбросить фильтры"),
+			),
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("Назад"),
+			),
+		)
+		keyboard.ResizeKeyboard = true
+		keyboard.Selective = false
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Какие посты пропускать? Реклама распознаётся по пометкам «Реклама», токенам erid и партнёрским ссылкам, повторы — по одинаковым новостям из разных каналов сводки (текущие фильтры: %s):", filtersLabel(request)))
+		msg.ReplyMarkup = keyboard
+		if _, err := bot.Send(msg); err != nil {
+			return err
+		}
+		uc.repo.SaveRequest(chatID, request)
+		return nil
+
+	case "Скрывать рекламу", "Показывать рекламу":
+		request.SkipAds = text == "Скрывать рекламу"
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Фильтры сохранены: %s. Выберите действие:", filtersLabel(request)))
+		msg.ReplyMarkup = MainKeyboard
+		if _, err := bot.Send(msg); err != nil {
+			return err
+		}
+		uc.repo.SaveRequest(chatID, request)
+		return nil
+
+	case "Убирать повторы", "Оставлять повторы":
+		request.Dedup = text == "Убирать повторы"
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Фильтры сохранены: %s. Выберите действие:", filtersLabel(request)))
+		msg.ReplyMarkup = MainKeyboard
+		if _, err := bot.Send(msg); err != nil {
+			return err
+		}
+		uc.repo.SaveRequest(chatID, request)
+		return nil
+
+	case "Ключевые слова", "Исключить слова":
+		request.AwaitingChannelInput = false
+		request.AwaitingSearchInput = false
+		request.AwaitingFilterInput = "include"
+		prompt := "Введите через запятую слова, #хештеги или /регулярные выражения/ — останутся только посты, где есть хотя бы одно из них:"
+		if text == "Исключить слова" {
+			request.AwaitingFilterInput = "exclude"
+			prompt = "Введите через запятую слова, #хештеги или /регулярные выражения/ — посты, где есть хотя бы одно из них, озвучиваться не будут:"
+		}
+		keyboard := tgbotapi.NewReplyKeyboard(
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("Назад"),
+			),
+		)
+		keyboard.ResizeKeyboard = true
+		keyboard.Selective = false
+		msg := tgbotapi.NewMessage(chatID, prompt)
+		msg.ReplyMarkup = keyboard
+		if _, err := bot.Send(msg); err != nil {
+			return err
+		}
+		uc.repo.SaveRequest(chatID, request)
+		return nil
+
+	case "Сбросить фильтры":
+		request.Include = nil
+		request.Exclude = nil
+		request.SkipAds = false
+		request.Dedup = false
+		msg := tgbotapi.NewMessage(chatID, "Фильтры сброшены. Выберите действие:")
+		msg.ReplyMarkup = MainKeyboard
+		if _, err := bot.Send(msg); err != nil {
+			return err
+		}
+		uc.repo.SaveRequest(chatID, request)
+		return nil
+
+	case "Поиск по каналу":
+		request.AwaitingChannelInput = false
+		request.AwaitingFilterInput = ""
+		request.AwaitingSearchInput = true
+		keyboard := tgbotapi.NewReplyKeyboard(
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("Сбросить поиск"),
+			),
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("Назад"),
+			),
+		)
+		keyboard.ResizeKeyboard = true
+		keyboard.Selective = false
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Что найти в канале? Будут озвучены все посты, где это встречается, например: ставка ЦБ. Чтобы искать только посты одного автора, добавьте в конце его имя: ставка ЦБ @author (текущий поиск: %s)", searchLabel(request)))
+		msg.ReplyMarkup = keyboard
+		if _, err := bot.Send(msg); err != nil {
+			return err
+		}
+		uc.repo.SaveRequest(chatID, request)
+		return nil
+
+	case "За сутки", "За неделю", "За месяц":
+		request.SearchDays = searchPeriodOptions[text]
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Поиск сохранён: %s. Выберите действие:", searchLabel(request)))
+		msg.ReplyMarkup = MainKeyboard
+		if _, err := bot.Send(msg); err != nil {
+			return err
+		}
+		uc.repo.SaveRequest(chatID, request)
+		return nil
+
+	case "Сбросить поиск":
+		request.SearchQuery = ""
+		request.SearchFromUser = ""
+		request.SearchDays = 0
+		request.AwaitingSearchInput = false
+		msg := tgbotapi.NewMessage(chatID, "Поиск сброшен, будут озвучены посты за выбранный период. Выберите действие:")
+		msg.ReplyMarkup = MainKeyboard
+		if _, err := bot.Send(msg); err != nil {
+			return err
+		}
+		uc.repo.SaveRequest(chatID, request)
+		return nil
+
 	case "Назад":
+		request.AwaitingFilterInput = ""
+		request.AwaitingSearchInput = false
 		msg := tgbotapi.NewMessage(chatID, "Вернулись в главное меню. Выберите действие:")
 		msg.ReplyMarkup = MainKeyboard
 		if _, err := bot.Send(msg); err != nil {
@@ -180,6 +641,12 @@ func (uc *UseCase) HandleMessage(ctx context.Context, bot *tgbotapi.BotAPI, chat
 
 	case "Отправить":
 		request.ChatID = chatID
+		requestID, err := newRequestID()
+		if err != nil {
+			myLogger.Error("Ошибка генерации идентификатора запроса", "error", err)
+			return err
+		}
+		request.RequestID = requestID
 
 		// Валидация
 		if request.NameChanel == "" {
@@ -193,6 +660,9 @@ func (uc *UseCase) HandleMessage(ctx context.Context, bot *tgbotapi.BotAPI, chat
 		if request.SpeakingRate == 0 {
 			request.SpeakingRate = 1.0
 		}
+		if request.SearchQuery != "" && request.SearchDays == 0 {
+			request.SearchDays = defaultSearchDays
+		}
 
 		// Сериализация запроса в JSON
 		jsonData, err := json.Marshal(request)
@@ -209,20 +679,82 @@ func (uc *UseCase) HandleMessage(ctx context.Context, bot *tgbotapi.BotAPI, chat
 			bot.Send(msg)
 			return err
 		}
-		myLogger.Info(fmt.Sprintf("Запрос под номнром: %v, успешно ушёл в kafka", request.ChatID))
+		myLogger.Info(fmt.Sprintf("Запрос под номнром: %v, успешно ушёл в kafka", request.ChatID), "requestID", request.RequestID)
 
-		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Запрос отправлен в обработку. Канал: %s, Скорость: %.1fx, Период: %d час.", request.NameChanel, request.SpeakingRate, request.TimePeriod))
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Запрос отправлен в обработку. Канал: %s, Скорость: %.1fx, Период: %d час., Голос: %s, Отбор: %s, Режим: %s, Длительность: %s, Фильтры: %s, Поиск: %s", request.NameChanel, request.SpeakingRate, request.TimePeriod, voiceLabel(request.VoiceName), selectionLabel(request.Top, request.Percentile), digestLabel(request.DigestMode), durationLabel(request.Minutes), filtersLabel(request), searchLabel(request)))
 		msg.ReplyMarkup = MainKeyboard
 		bot.Send(msg)
 
-		// Очистка состояния
+		// Очистка состояния (выбранные голос, отбор постов, режим и длительность дайджеста, фильтры — настройки пользователя, их сохраняем;
+		// поиск относится к одному запросу, его сбрасываем вместе с каналом)
 		request.TimePeriod = 0
 		request.SpeakingRate = 0
 		request.NameChanel = ""
+		request.SearchQuery = ""
+		request.SearchFromUser = ""
+		request.SearchDays = 0
 		uc.repo.SaveRequest(chatID, request)
 		return nil
 
 	default:
+		if request.AwaitingSearchInput {
+			query, fromUser := parseSearch(text)
+			if query == "" {
+				msg := tgbotapi.NewMessage(chatID, "Ошибка: Введите, что искать в канале")
+				if _, err := bot.Send(msg); err != nil {
+					return err
+				}
+				uc.repo.SaveRequest(chatID, request)
+				return nil
+			}
+
+			request.SearchQuery = query
+			request.SearchFromUser = fromUser
+			request.AwaitingSearchInput = false
+			keyboard := tgbotapi.NewReplyKeyboard(
+				tgbotapi.NewKeyboardButtonRow(
+					tgbotapi.NewKeyboardButton("За сутки"),
+					tgbotapi.NewKeyboardButton("За неделю"),
+					tgbotapi.NewKeyboardButton("За месяц"),
+				),
+			)
+			keyboard.ResizeKeyboard = true
+			keyboard.Selective = false
+			msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Ищем «%s». За какой период искать посты?", query))
+			msg.ReplyMarkup = keyboard
+			if _, err := bot.Send(msg); err != nil {
+				return err
+			}
+			uc.repo.SaveRequest(chatID, request)
+			return nil
+		}
+
+		if request.AwaitingFilterInput != "" {
+			filters := parseFilters(text)
+			if len(filters) == 0 {
+				msg := tgbotapi.NewMessage(chatID, "Ошибка: Введите хотя бы одно слово, #хештег или /выражение/")
+				if _, err := bot.Send(msg); err != nil {
+					return err
+				}
+				uc.repo.SaveRequest(chatID, request)
+				return nil
+			}
+
+			if request.AwaitingFilterInput == "exclude" {
+				request.Exclude = filters
+			} else {
+				request.Include = filters
+			}
+			request.AwaitingFilterInput = ""
+			msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Фильтры сохранены: %s. Выберите действие:", filtersLabel(request)))
+			msg.ReplyMarkup = MainKeyboard
+			if _, err := bot.Send(msg); err != nil {
+				return err
+			}
+			uc.repo.SaveRequest(chatID, request)
+			return nil
+		}
+
 		if request.AwaitingChannelInput {
 			if len(text) > 0 {
 				//if text[0] != '@' {
@@ -263,6 +795,64 @@ func (uc *UseCase) HandleMessage(ctx context.Context, bot *tgbotapi.BotAPI, chat
 	}
 }
 
+// durationCaption возвращает подпись к аудио дайджеста с его длительностью (пусто — длительность неизвестна)
+func durationCaption(duration time.Duration) string {
+	if duration <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("Длительность: ≈%d мин.", max(int(math.Round(duration.Minutes())), 1))
+}
+
+// DeliverAudio обрабатывает ответ сервиса Text-to-Speech из Kafka: скачивает аудио по ссылке,
+// сверяя размер и контрольную сумму, и отправляет его в чат пользователя; при ошибке синтеза сообщает о ней
+func (uc *UseCase) DeliverAudio(ctx context.Context, bot *tgbotapi.BotAPI, data []byte) error {
+	const lblDeliverAudio = "tg_bot_micserv/internal/tg_bot_user_case/tg_bot_user_case.go/DeliverAudio()"
+	myLogger := logger.NewColorLogger(lblDeliverAudio)
+
+	var resp tts_response.Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("ошибка десериализации ответа Text-to-Speech: %w", err)
+	}
+	// Ответы на запросы не из бота (без чата) доставлять некому
+	if resp.ChatID == 0 {
+		return nil
+	}
+
+	if resp.Error != "" || resp.AudioRef == nil {
+		myLogger.Error("Сервис Text-to-Speech вернул ошибку", "chatID", resp.ChatID, "requestID", resp.RequestID, "error", resp.Error)
+		_, err := bot.Send(tgbotapi.NewMessage(resp.ChatID, "Не удалось озвучить дайджест. Повторите запрос позже."))
+		return err
+	}
+
+	audio, err := tts_response.Fetch(ctx, uc.httpClient, resp.AudioRef)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(resp.ChatID, "Не удалось получить аудио дайджеста. Повторите запрос позже."))
+		return err
+	}
+
+	// Ogg Opus Telegram показывает как голосовое сообщение, остальные форматы — как аудиофайл
+	// Длительность берём из глав синтезированного файла и показываем в подписи к нему
+	file := tgbotapi.FileBytes{Name: "digest" + path.Ext(resp.AudioRef.Key), Bytes: audio}
+	duration := resp.Duration()
+	var msg tgbotapi.Chattable
+	if resp.AudioRef.ContentType == "audio/ogg" {
+		voiceMsg := tgbotapi.NewVoice(resp.ChatID, file)
+		voiceMsg.Duration = int(duration.Seconds())
+		voiceMsg.Caption = durationCaption(duration)
+		msg = voiceMsg
+	} else {
+		audioMsg := tgbotapi.NewAudio(resp.ChatID, file)
+		audioMsg.Duration = int(duration.Seconds())
+		audioMsg.Caption = durationCaption(duration)
+		msg = audioMsg
+	}
+	if _, err := bot.Send(msg); err != nil {
+		return fmt.Errorf("ошибка отправки аудио в чат %d: %w", resp.ChatID, err)
+	}
+	myLogger.Info("Аудио дайджеста доставлено", "chatID", resp.ChatID, "requestID", resp.RequestID, "size", len(audio))
+	return nil
+}
+
 // TODO: Разобраться
 // HandleCallback обрабатывает данные callback-запроса (нажатие на InlineKeyboard кнопки)
 func (uc *UseCase) HandleCallback(bot *tgbotapi.BotAPI, chatID int64, callbackData string) error {